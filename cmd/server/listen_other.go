@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "net"
+
+// listenPrivate relies on the permissions of the socket's directory on
+// platforms without a umask.
+func listenPrivate(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package main
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenPrivate creates the socket with no group or other access, so no
+// other user on a shared host can connect to it.
+func listenPrivate(path string) (net.Listener, error) {
+	old := syscall.Umask(0o077)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -1,13 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/rakeshavasarala/k8s-ui/internal/kube"
 	"github.com/rakeshavasarala/k8s-ui/internal/web"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var (
@@ -21,6 +32,9 @@ func main() {
 		fmt.Printf("version=%s commit=%s date=%s\n", version, commit, date)
 		return
 	}
+	listenUnix := flag.String("listen-unix", os.Getenv("LISTEN_UNIX"), "serve on this Unix domain socket instead of a TCP port")
+	flag.Parse()
+
 	namespace := os.Getenv("POD_NAMESPACE")
 	allowedNamespaces := parseNamespaces(os.Getenv("POD_NAMESPACES"))
 	// If POD_NAMESPACE is not set, we pass empty string to NewManager
@@ -37,22 +51,269 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize kubernetes manager: %v", err)
 	}
+	if raw := os.Getenv("SLOW_API_CALL_THRESHOLD"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid SLOW_API_CALL_THRESHOLD %q: must be a non-negative duration such as 2s", raw)
+		}
+		manager.APIMetrics().SetSlowThreshold(d)
+	}
+	if raw := os.Getenv("API_MAX_IN_FLIGHT"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid API_MAX_IN_FLIGHT %q: must be a non-negative integer", raw)
+		}
+		perSession := max(n/4, 1)
+		if raw := os.Getenv("API_SESSION_MAX_IN_FLIGHT"); raw != "" {
+			perSession, err = strconv.Atoi(raw)
+			if err != nil || perSession < 1 {
+				log.Fatalf("Invalid API_SESSION_MAX_IN_FLIGHT %q: must be a positive integer", raw)
+			}
+		}
+		if n > 0 {
+			if err := manager.SetFairQueue(kube.NewFairQueue(n, perSession)); err != nil {
+				log.Fatalf("Failed to set up the API request queue: %v", err)
+			}
+		}
+	}
+
+	// cleanups run when the process is interrupted or terminated.
+	var cleanups []func()
+	if tracingEnabled() {
+		shutdownTracing, err := setupTracing(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to set up tracing: %v", err)
+		}
+		log.Println("Exporting traces over OTLP")
+		cleanups = append(cleanups, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Printf("Failed to flush traces: %v", err)
+			}
+		})
+	}
+
+	cfg := web.Config{}
+	if raw := os.Getenv("MAX_REPLICAS"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid MAX_REPLICAS %q: must be a non-negative integer", raw)
+		}
+		cfg.MaxReplicas = int32(n)
+	}
+	if raw := os.Getenv("EXEC_IDLE_TIMEOUT"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid EXEC_IDLE_TIMEOUT %q: must be a non-negative duration such as 15m", raw)
+		}
+		cfg.ExecIdleTimeout = d
+	}
+	if raw := os.Getenv("SESSION_MAX_AGE"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid SESSION_MAX_AGE %q: must be a non-negative duration such as 12h", raw)
+		}
+		cfg.SessionMaxAge = d
+	}
+	if raw := os.Getenv("ENABLE_CLUSTER_HEALTH"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			log.Fatalf("Invalid ENABLE_CLUSTER_HEALTH %q: must be true or false", raw)
+		}
+		cfg.ClusterHealth = enabled
+	}
+	if raw := os.Getenv("ENABLE_SESSIONS_PAGE"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			log.Fatalf("Invalid ENABLE_SESSIONS_PAGE %q: must be true or false", raw)
+		}
+		cfg.SessionsPage = enabled
+	}
+	if raw := os.Getenv("MAX_EXEC_SESSIONS"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid MAX_EXEC_SESSIONS %q: must be a non-negative integer", raw)
+		}
+		cfg.MaxExecSessions = n
+	}
+	cfg.ProductionContexts = parseNamespaces(os.Getenv("PRODUCTION_CONTEXTS"))
+	cfg.ProductionNamespaces = parseNamespaces(os.Getenv("PRODUCTION_NAMESPACES"))
+	if raw := os.Getenv("ENABLE_REPLICATION_CONTROLLERS"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			log.Fatalf("Invalid ENABLE_REPLICATION_CONTROLLERS %q: must be true or false", raw)
+		}
+		cfg.ReplicationControllers = enabled
+	}
+	if raw := os.Getenv("EVENT_HISTORY"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid EVENT_HISTORY %q: must be a non-negative duration such as 24h", raw)
+		}
+		cfg.EventHistory = d
+	}
+	cfg.EventHistoryFile = os.Getenv("EVENT_HISTORY_FILE")
+	switch raw := os.Getenv("QUOTA_CHECK"); raw {
+	case "", web.QuotaCheckWarn, web.QuotaCheckBlock, web.QuotaCheckOff:
+		cfg.QuotaCheck = raw
+	default:
+		log.Fatalf("Invalid QUOTA_CHECK %q: must be warn, block or off", raw)
+	}
+	if path := os.Getenv("LINT_RULES_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Failed to open LINT_RULES_FILE: %v", err)
+		}
+		cfg.LintRules, err = web.ParseLintRules(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Invalid LINT_RULES_FILE %s: %v", path, err)
+		}
+	}
+	if raw := os.Getenv("LOG_MAX_LINES"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid LOG_MAX_LINES %q: must be a non-negative integer", raw)
+		}
+		cfg.LogMaxLines = n
+	}
+	if raw := os.Getenv("LOG_MAX_BYTES"); raw != "" {
+		q, err := resource.ParseQuantity(raw)
+		if err != nil || q.Sign() < 0 {
+			log.Fatalf("Invalid LOG_MAX_BYTES %q: must be a non-negative size such as 5Mi", raw)
+		}
+		cfg.LogMaxBytes = q.Value()
+	}
+	if path := os.Getenv("LOG_LIMITS_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Failed to open LOG_LIMITS_FILE: %v", err)
+		}
+		cfg.LogLimits, err = web.ParseLogLimits(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Invalid LOG_LIMITS_FILE %s: %v", path, err)
+		}
+	}
+	if path := os.Getenv("API_TOKENS_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Failed to open API_TOKENS_FILE: %v", err)
+		}
+		cfg.APITokens, err = web.ParseAPITokens(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Invalid API_TOKENS_FILE %s: %v", path, err)
+		}
+	}
+	cfg.SlackSigningSecret = os.Getenv("SLACK_SIGNING_SECRET")
+	cfg.SlackRestartUsers = parseNamespaces(os.Getenv("SLACK_RESTART_USERS"))
+	if raw := os.Getenv("DELETE_UNDO_WINDOW"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid DELETE_UNDO_WINDOW %q: must be a non-negative duration such as 10m", raw)
+		}
+		cfg.DeleteUndoWindow = d
+	}
+	if raw := os.Getenv("LANDING_PAGE"); raw != "" {
+		page, err := web.ParseLandingPage(raw)
+		if err != nil {
+			log.Fatalf("Invalid LANDING_PAGE %q: %v", raw, err)
+		}
+		cfg.LandingPage = page
+	}
+	if raw := os.Getenv("NAV_SECTIONS"); raw != "" {
+		sections, err := web.ParseNavSections(parseNamespaces(raw))
+		if err != nil {
+			log.Fatalf("Invalid NAV_SECTIONS %q: %v", raw, err)
+		}
+		cfg.NavSections = sections
+	}
 
 	// Initialize Web Server
-	srv, err := web.NewServer(manager)
+	srv, err := web.NewServer(manager, cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize server: %v", err)
 	}
 
+	if adminPort := os.Getenv("ADMIN_PORT"); adminPort != "" {
+		go func() {
+			log.Printf("Serving health, metrics and pprof on :%s", adminPort)
+			if err := http.ListenAndServe(":"+adminPort, srv.AdminHandler()); err != nil {
+				log.Fatalf("Admin server failed: %v", err)
+			}
+		}()
+	}
+
+	if *listenUnix != "" {
+		l, err := listenUnixSocket(*listenUnix)
+		if err != nil {
+			log.Fatalf("Failed to listen on %s: %v", *listenUnix, err)
+		}
+		// Shutdown closes the listener, which removes the socket so the
+		// next start can bind it.
+		log.Printf("Starting k8s-ui on unix:%s in namespace %s", *listenUnix, manager.Namespace())
+		serveUntilSignal(srv, func() error { return srv.Serve(l) }, cleanups)
+		return
+	}
+
 	var port string
 	if port = os.Getenv("PORT"); port == "" {
 		port = "3000"
 	}
 
 	log.Printf("Starting k8s-ui on :%s in namespace %s", port, manager.Namespace())
-	if err := srv.ListenAndServe(":" + port); err != nil {
+	serveUntilSignal(srv, func() error { return srv.ListenAndServe(":" + port) }, cleanups)
+}
+
+// shutdownTimeout is how long requests in progress may take to finish on
+// SIGINT or SIGTERM before they are cut off.
+const shutdownTimeout = 10 * time.Second
+
+// serveUntilSignal runs serve until SIGINT or SIGTERM, then shuts srv down,
+// letting requests in progress and background work such as saving the
+// event history finish, and runs cleanups. It only returns on a clean
+// shutdown.
+func serveUntilSignal(srv *web.Server, serve func() error, cleanups []func()) {
+	stopped := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		log.Println("Shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Requests still in progress were cut off: %v", err)
+		}
+		close(stopped)
+	}()
+
+	if err := serve(); !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Server failed: %v", err)
 	}
+	<-stopped
+	for _, cleanup := range cleanups {
+		cleanup()
+	}
+}
+
+// listenUnixSocket listens on a Unix domain socket that only the current
+// user can connect to. A socket left behind by a previous run is replaced,
+// but any other file at path is left alone.
+func listenUnixSocket(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return listenPrivate(path)
 }
 
 func parseNamespaces(raw string) []string {
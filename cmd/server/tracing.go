@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+)
+
+// tracingEnabled reports whether an OTLP endpoint is configured. Without
+// one the UI and its Kubernetes clients keep the no-op tracer.
+func tracingEnabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// setupTracing exports spans over OTLP/HTTP. The exporter reads the
+// standard OTEL_EXPORTER_OTLP_* variables for the endpoint, headers and
+// TLS, and OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES name the service.
+// The returned function flushes pending spans.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName("k8s-ui"), semconv.ServiceVersion(version)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
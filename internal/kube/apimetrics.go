@@ -0,0 +1,181 @@
+package kube
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// APICall identifies a kind of Kubernetes API request, such as a list of
+// pods or a get of deployments.apps.
+type APICall struct {
+	Verb     string
+	Resource string
+}
+
+// APICallStats are the totals recorded for one kind of API call.
+type APICallStats struct {
+	APICall
+	Count    uint64
+	Errors   uint64        // transport failures and 5xx responses
+	Duration time.Duration // total time until the response headers arrived
+}
+
+// APIMetrics records the latency and errors of the requests the UI makes to
+// the Kubernetes API server, to tell apart a slow UI from a slow cluster.
+type APIMetrics struct {
+	mu    sync.Mutex
+	calls map[APICall]*APICallStats
+	slow  atomic.Int64 // threshold in nanoseconds for logging a call, 0 to disable
+}
+
+func NewAPIMetrics() *APIMetrics {
+	return &APIMetrics{calls: make(map[APICall]*APICallStats)}
+}
+
+// SetSlowThreshold logs every call that takes at least d. Zero disables the
+// log.
+func (a *APIMetrics) SetSlowThreshold(d time.Duration) {
+	a.slow.Store(int64(d))
+}
+
+// Wrap makes clients built from config record their requests.
+func (a *APIMetrics) Wrap(config *rest.Config) *rest.Config {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &apiMetricsTransport{metrics: a, next: rt}
+	})
+	return config
+}
+
+// Snapshot returns the recorded totals sorted by resource and verb.
+func (a *APIMetrics) Snapshot() []APICallStats {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	out := make([]APICallStats, 0, len(a.calls))
+	for _, st := range a.calls {
+		out = append(out, *st)
+	}
+	a.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Resource != out[j].Resource {
+			return out[i].Resource < out[j].Resource
+		}
+		return out[i].Verb < out[j].Verb
+	})
+	return out
+}
+
+func (a *APIMetrics) record(call APICall, d time.Duration, failed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st := a.calls[call]
+	if st == nil {
+		st = &APICallStats{APICall: call}
+		a.calls[call] = st
+	}
+	st.Count++
+	st.Duration += d
+	if failed {
+		st.Errors++
+	}
+}
+
+type apiMetricsTransport struct {
+	metrics *APIMetrics
+	next    http.RoundTripper
+}
+
+// RoundTrip times a request until its response headers arrive, so watches
+// and followed logs count the time to start streaming, not their lifetime.
+func (t *apiMetricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	d := time.Since(start)
+
+	call := apiCallFor(req)
+	t.metrics.record(call, d, err != nil || resp.StatusCode >= 500)
+
+	if slow := time.Duration(t.metrics.slow.Load()); slow > 0 && d >= slow {
+		var status string
+		if err != nil {
+			status = "error: " + err.Error()
+		} else {
+			status = resp.Status
+		}
+		log.Printf("Slow Kubernetes API call: %s %s (%s %s) took %s, %s", req.Method, req.URL.Path, call.Verb, call.Resource, d.Round(time.Millisecond), status)
+	}
+	return resp, err
+}
+
+// apiCallFor derives the verb and resource of an API request from its
+// method and path, the way the API server's audit log names them.
+// Resources outside the core group are qualified with their group, such as
+// deployments.apps, and subresources are kept, such as pods/log. Discovery
+// requests share one resource so CRDs do not add a series each.
+func apiCallFor(req *http.Request) APICall {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	var group string
+	switch {
+	case len(parts) >= 1 && parts[0] == "api":
+		parts = parts[min(len(parts), 2):]
+	case len(parts) >= 1 && parts[0] == "apis":
+		if len(parts) < 4 {
+			return APICall{Verb: "get", Resource: "discovery"}
+		}
+		group = parts[1]
+		parts = parts[3:]
+	default:
+		return APICall{Verb: strings.ToLower(req.Method), Resource: "nonresource"}
+	}
+	if len(parts) == 0 {
+		return APICall{Verb: "get", Resource: "discovery"}
+	}
+	// A namespace's own path is /namespaces/{name}; its contents follow.
+	if parts[0] == "namespaces" && len(parts) > 2 {
+		parts = parts[2:]
+	}
+
+	resource := parts[0]
+	if group != "" {
+		resource += "." + group
+	}
+	if len(parts) > 2 {
+		resource += "/" + parts[2]
+	}
+	named := len(parts) > 1
+
+	var verb string
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		switch {
+		case req.URL.Query().Get("watch") == "true" || req.URL.Query().Get("watch") == "1":
+			verb = "watch"
+		case named:
+			verb = "get"
+		default:
+			verb = "list"
+		}
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		verb = "delete"
+		if !named {
+			verb = "deletecollection"
+		}
+	default:
+		verb = strings.ToLower(req.Method)
+	}
+	return APICall{Verb: verb, Resource: resource}
+}
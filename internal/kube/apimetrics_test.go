@@ -0,0 +1,68 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestAPICallFor(t *testing.T) {
+	tests := []struct {
+		method, url string
+		want        APICall
+	}{
+		{"GET", "/api/v1/namespaces/default/pods", APICall{"list", "pods"}},
+		{"GET", "/api/v1/namespaces/default/pods/web-1", APICall{"get", "pods"}},
+		{"GET", "/api/v1/namespaces/default/pods/web-1/log?follow=true", APICall{"get", "pods/log"}},
+		{"GET", "/api/v1/namespaces/default/events?watch=true", APICall{"watch", "events"}},
+		{"GET", "/api/v1/namespaces/default", APICall{"get", "namespaces"}},
+		{"GET", "/api/v1/nodes", APICall{"list", "nodes"}},
+		{"PATCH", "/apis/apps/v1/namespaces/default/deployments/web/scale", APICall{"patch", "deployments.apps/scale"}},
+		{"POST", "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", APICall{"create", "selfsubjectaccessreviews.authorization.k8s.io"}},
+		{"DELETE", "/api/v1/namespaces/default/pods", APICall{"deletecollection", "pods"}},
+		{"GET", "/apis/example.com/v1", APICall{"get", "discovery"}},
+		{"GET", "/api", APICall{"get", "discovery"}},
+		{"GET", "/version", APICall{"get", "nonresource"}},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, nil)
+		if got := apiCallFor(req); got != tt.want {
+			t.Errorf("apiCallFor(%s %s) = %+v, want %+v", tt.method, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestAPIMetricsTransport(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/namespaces/default/pods/broken" {
+			http.Error(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","code":500}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"web"}}`))
+	}))
+	defer api.Close()
+
+	metrics := NewAPIMetrics()
+	client, err := kubernetes.NewForConfig(metrics.Wrap(&rest.Config{Host: api.URL}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pods := client.CoreV1().Pods("default")
+	pods.Get(context.Background(), "web", metav1.GetOptions{})
+	pods.Get(context.Background(), "broken", metav1.GetOptions{})
+
+	got := metrics.Snapshot()
+	if len(got) != 1 || got[0].APICall != (APICall{"get", "pods"}) {
+		t.Fatalf("Snapshot() = %+v, want one series for get pods", got)
+	}
+	if got[0].Count < 2 || got[0].Errors < 1 || got[0].Duration <= 0 {
+		t.Errorf("get pods = %+v, want two calls with an error", got[0])
+	}
+}
@@ -0,0 +1,32 @@
+package kube
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// credentialRefreshInterval is the least time between two refreshes of the
+// credentials, however many requests the API server rejects meanwhile.
+const credentialRefreshInterval = 30 * time.Second
+
+// unauthorizedTransport refreshes the credentials of the manager when the
+// API server answers 401 Unauthorized, so every client notices expired
+// credentials, not only the pages that show the re-authentication page.
+type unauthorizedTransport struct {
+	manager *Manager
+	next    http.RoundTripper
+}
+
+func (t *unauthorizedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		// The refresh takes the manager's lock, which the caller may hold.
+		go func() {
+			if err := t.manager.RefreshCredentials(); err != nil {
+				log.Printf("Kubernetes API server rejected the credentials and reloading them failed: %v", err)
+			}
+		}()
+	}
+	return resp, err
+}
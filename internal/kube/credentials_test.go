@@ -0,0 +1,108 @@
+package kube
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// writeKubeconfig writes a kubeconfig to path with the given contexts, all
+// for the API server at server; the first is the current one.
+func writeKubeconfig(t *testing.T, path, server string, contexts ...string) {
+	t.Helper()
+	config := api.NewConfig()
+	config.Clusters["test"] = &api.Cluster{Server: server}
+	config.AuthInfos["test"] = &api.AuthInfo{Token: "token"}
+	for _, name := range contexts {
+		config.Contexts[name] = &api.Context{Cluster: "test", AuthInfo: "test"}
+	}
+	config.CurrentContext = contexts[0]
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// localManager returns a manager in local mode reading a kubeconfig with
+// one context, dev, and the path of that kubeconfig.
+func localManager(t *testing.T, server string) (*Manager, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	writeKubeconfig(t, path, server, "dev")
+	m := &Manager{isLocal: true, loadingRules: &clientcmd.ClientConfigLoadingRules{ExplicitPath: path}}
+	m.rawConfig.CurrentContext = "dev"
+	return m, path
+}
+
+func TestRefreshCredentialsReloadsKubeconfigOncePerInterval(t *testing.T) {
+	// The default loading rules would read $KUBECONFIG instead.
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	m, path := localManager(t, "https://127.0.0.1:6443")
+
+	if err := m.RefreshCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.rawConfig.Contexts["dev"]; !ok {
+		t.Fatalf("contexts = %v, want dev reloaded from %s", m.rawConfig.Contexts, path)
+	}
+
+	// A context added since is only seen once the interval has passed.
+	writeKubeconfig(t, path, "https://127.0.0.1:6443", "staging", "dev")
+	if err := m.RefreshCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.rawConfig.Contexts["staging"]; ok {
+		t.Error("credentials were reloaded again within the refresh interval")
+	}
+
+	m.lastRefresh = time.Now().Add(-credentialRefreshInterval)
+	if err := m.RefreshCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.rawConfig.Contexts["staging"]; !ok {
+		t.Error("credentials were not reloaded after the refresh interval")
+	}
+	if m.rawConfig.CurrentContext != "dev" {
+		t.Errorf("current context = %q, want dev kept over the kubeconfig's", m.rawConfig.CurrentContext)
+	}
+}
+
+func TestUnauthorizedResponseRefreshesCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	m, _ := localManager(t, srv.URL)
+
+	rt := &unauthorizedTransport{manager: m, next: http.DefaultTransport}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/namespaces", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want the 401 passed on", resp.StatusCode)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.refreshMu.Lock()
+		refreshed := !m.lastRefresh.IsZero()
+		m.refreshMu.Unlock()
+		if refreshed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("a 401 did not refresh the credentials")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if m.Client() == nil {
+		t.Error("no client was built from the reloaded credentials")
+	}
+}
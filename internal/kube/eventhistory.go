@@ -0,0 +1,290 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// eventHistoryRetry is how long the recorder waits before re-establishing a
+// failed watch, and how often it checks for a context or namespace switch.
+const eventHistoryRetry = 10 * time.Second
+
+// EventHistory keeps the events seen in the cluster for longer than the API
+// server does (one hour by default). Events are recorded from a watch and,
+// when a path is set, saved to disk so the history survives restarts.
+type EventHistory struct {
+	manager   *Manager
+	retention time.Duration
+	path      string
+
+	mu     sync.RWMutex
+	events map[string]storedEvent
+	// version counts the changes to events, and saved is the version
+	// last written to path.
+	version uint64
+	saved   uint64
+}
+
+type storedEvent struct {
+	Context string       `json:"context"`
+	Event   corev1.Event `json:"event"`
+}
+
+// NewEventHistory returns a history that keeps events for retention. If path
+// is set, previously saved events are loaded from it.
+func NewEventHistory(m *Manager, retention time.Duration, path string) (*EventHistory, error) {
+	h := &EventHistory{
+		manager:   m,
+		retention: retention,
+		path:      path,
+		events:    make(map[string]storedEvent),
+	}
+	if path == "" {
+		return h, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read event history: %w", err)
+	}
+	var saved []storedEvent
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("parse event history %s: %w", path, err)
+	}
+	for _, se := range saved {
+		h.events[eventKey(se.Context, &se.Event)] = se
+	}
+	h.prune(time.Now())
+	return h, nil
+}
+
+// EventTime returns the most recent time an event was observed.
+func EventTime(e corev1.Event) time.Time {
+	if e.Series != nil && !e.Series.LastObservedTime.IsZero() {
+		return e.Series.LastObservedTime.Time
+	}
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+func eventKey(kubeContext string, e *corev1.Event) string {
+	id := string(e.UID)
+	if id == "" {
+		id = e.Namespace + "/" + e.Name
+	}
+	return kubeContext + "/" + id
+}
+
+// Record stores the latest version of an event seen in the current context.
+// Events in namespaces outside POD_NAMESPACES are ignored.
+func (h *EventHistory) Record(e *corev1.Event) {
+	_, current := h.manager.Contexts()
+	h.record(current, e)
+}
+
+func (h *EventHistory) record(kubeContext string, e *corev1.Event) {
+	if !h.manager.IsNamespaceAllowed(e.Namespace) {
+		return
+	}
+	stored := e.DeepCopy()
+	stored.ManagedFields = nil
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events[eventKey(kubeContext, stored)] = storedEvent{Context: kubeContext, Event: *stored}
+	h.version++
+}
+
+// Events returns the recorded events of a namespace in the current context.
+func (h *EventHistory) Events(namespace string) []corev1.Event {
+	_, current := h.manager.Contexts()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var out []corev1.Event
+	for _, se := range h.events {
+		if se.Context == current && se.Event.Namespace == namespace {
+			out = append(out, se.Event)
+		}
+	}
+	return out
+}
+
+// Retention is how long events are kept.
+func (h *EventHistory) Retention() time.Duration {
+	return h.retention
+}
+
+func (h *EventHistory) prune(now time.Time) {
+	cutoff := now.Add(-h.retention)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for k, se := range h.events {
+		if EventTime(se.Event).Before(cutoff) {
+			delete(h.events, k)
+			h.version++
+		}
+	}
+}
+
+// save writes the history to disk through a temporary file, so a crash
+// never leaves a truncated file behind. The history only counts as saved
+// once the file is in place, so a failed save is retried the next time.
+func (h *EventHistory) save() error {
+	if h.path == "" {
+		return nil
+	}
+	h.mu.RLock()
+	if h.saved == h.version {
+		h.mu.RUnlock()
+		return nil
+	}
+	version := h.version
+	saved := make([]storedEvent, 0, len(h.events))
+	for _, se := range h.events {
+		saved = append(saved, se)
+	}
+	h.mu.RUnlock()
+
+	sort.Slice(saved, func(i, j int) bool { return EventTime(saved[i].Event).Before(EventTime(saved[j].Event)) })
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), h.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	h.mu.Lock()
+	h.saved = version
+	h.mu.Unlock()
+	return nil
+}
+
+// Run watches events until ctx is cancelled, pruning and saving the history
+// once a minute. The watch covers all namespaces when the identity may list
+// them cluster-wide, and the selected namespace otherwise; it restarts when
+// the context or that namespace is switched. Run returns once the history
+// has been saved a last time.
+func (h *EventHistory) Run(ctx context.Context) {
+	saverDone := make(chan struct{})
+	defer func() { <-saverDone }()
+	go func() {
+		defer close(saverDone)
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if err := h.save(); err != nil {
+					log.Printf("Failed to save event history: %v", err)
+				}
+				return
+			case now := <-ticker.C:
+				h.prune(now)
+				if err := h.save(); err != nil {
+					log.Printf("Failed to save event history: %v", err)
+				}
+			}
+		}
+	}()
+
+	for ctx.Err() == nil {
+		if err := h.watch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Event history watch stopped: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(eventHistoryRetry):
+		}
+	}
+}
+
+func (h *EventHistory) watch(ctx context.Context) error {
+	_, kubeContext := h.manager.Contexts()
+	client := h.manager.Client()
+	if client == nil {
+		return errors.New("no Kubernetes client")
+	}
+
+	namespace := metav1.NamespaceAll
+	list, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if apierrors.IsForbidden(err) {
+		namespace = h.manager.Namespace()
+		list, err = client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	}
+	if err != nil {
+		return err
+	}
+	for i := range list.Items {
+		h.record(kubeContext, &list.Items[i])
+	}
+
+	w, err := client.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{ResourceVersion: list.ResourceVersion})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	check := time.NewTicker(eventHistoryRetry)
+	defer check.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-check.C:
+			_, current := h.manager.Contexts()
+			if current != kubeContext || (namespace != metav1.NamespaceAll && h.manager.Namespace() != namespace) {
+				return nil
+			}
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			switch ev.Type {
+			case watch.Added, watch.Modified:
+				if e, ok := ev.Object.(*corev1.Event); ok {
+					h.record(kubeContext, e)
+				}
+			case watch.Error:
+				return apierrors.FromObject(ev.Object)
+			}
+		}
+	}
+}
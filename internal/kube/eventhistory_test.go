@@ -0,0 +1,115 @@
+package kube
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEventHistory(t *testing.T) {
+	now := time.Now()
+	event := func(uid, ns string, age time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:    metav1.ObjectMeta{Name: uid, Namespace: ns, UID: types.UID(uid)},
+			LastTimestamp: metav1.NewTime(now.Add(-age)),
+			Reason:        "Test",
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "events.json")
+	m := &Manager{allowedNamespaces: []string{"team-a"}}
+	h, err := NewEventHistory(m, 24*time.Hour, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Record(event("recent", "team-a", time.Hour))
+	h.Record(event("old", "team-a", 48*time.Hour))
+	h.Record(event("other", "team-b", time.Hour))
+	updated := event("recent", "team-a", time.Minute)
+	updated.Count = 2
+	h.Record(updated)
+
+	h.prune(now)
+	got := h.Events("team-a")
+	if len(got) != 1 || got[0].Name != "recent" || got[0].Count != 2 {
+		t.Fatalf("Events(team-a) = %+v, want only the updated recent event", got)
+	}
+	if got := h.Events("team-b"); len(got) != 0 {
+		t.Errorf("Events(team-b) = %d events, want none outside POD_NAMESPACES", len(got))
+	}
+
+	if err := h.save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewEventHistory(m, 24*time.Hour, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Events("team-a"); len(got) != 1 || got[0].Name != "recent" {
+		t.Errorf("reloaded Events(team-a) = %+v, want the recent event", got)
+	}
+
+	shorter, err := NewEventHistory(m, time.Second, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := shorter.Events("team-a"); len(got) != 0 {
+		t.Errorf("Events after loading with a shorter retention = %d, want none", len(got))
+	}
+}
+
+func TestEventHistorySaveRetriesAfterFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	path := filepath.Join(dir, "events.json")
+	h, err := NewEventHistory(&Manager{}, time.Hour, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Record(&corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "e", Namespace: "default", UID: "e"}, LastTimestamp: metav1.Now()})
+
+	if err := h.save(); err == nil {
+		t.Fatal("save into a missing directory succeeded")
+	}
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("history not saved after a failed save: %v", err)
+	}
+}
+
+func TestEventHistoryRunSavesBeforeReturning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	h, err := NewEventHistory(&Manager{clientset: fake.NewSimpleClientset()}, time.Hour, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Record(&corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "e", Namespace: "default", UID: "e"}, LastTimestamp: metav1.Now()})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after its context was cancelled")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("history not saved when Run returned: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"k8s.io/client-go/rest"
+)
+
+type sessionKey struct{}
+
+// WithSession tags the API requests made with ctx as coming from session,
+// such as one browser or one API token, for the fair queue. Requests
+// without a session, such as background work, share one.
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+func sessionFrom(ctx context.Context) string {
+	s, _ := ctx.Value(sessionKey{}).(string)
+	return s
+}
+
+// FairQueue limits how many requests to the API server are in flight at
+// once, and how many of them one session may have, so that one user paging
+// through thousands of pods cannot starve the others when they all share
+// one identity. When the limits are reached requests wait, and the sessions
+// waiting are served in turn rather than in arrival order. A request holds
+// its slot until the response headers arrive, so watches and followed logs
+// do not keep one for their lifetime.
+type FairQueue struct {
+	maxInFlight int
+	perSession  int
+
+	mu       sync.Mutex
+	inFlight int
+	sessions map[string]*fairSession
+	turns    []string // sessions with waiting requests, next to serve first
+}
+
+type fairSession struct {
+	inFlight int
+	waiting  []*fairWaiter
+}
+
+type fairWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// NewFairQueue allows maxInFlight requests at once, at most perSession of
+// them from the same session.
+func NewFairQueue(maxInFlight, perSession int) *FairQueue {
+	return &FairQueue{
+		maxInFlight: maxInFlight,
+		perSession:  min(max(perSession, 1), maxInFlight),
+		sessions:    make(map[string]*fairSession),
+	}
+}
+
+// Wrap makes clients built from config queue their requests. It turns off
+// client-go's own rate limit of 5 requests per second, a single token
+// bucket shared by everyone that would otherwise be drained by one user
+// before the queue gets to share anything.
+func (q *FairQueue) Wrap(config *rest.Config) *rest.Config {
+	config.QPS = -1
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &fairQueueTransport{queue: q, next: rt}
+	})
+	return config
+}
+
+// Acquire waits for a slot for a request of session and returns the
+// function that gives it back. It fails when ctx ends first.
+func (q *FairQueue) Acquire(ctx context.Context, session string) (func(), error) {
+	q.mu.Lock()
+	s := q.sessions[session]
+	if s == nil {
+		s = &fairSession{}
+		q.sessions[session] = s
+	}
+	if len(s.waiting) == 0 && q.inFlight < q.maxInFlight && s.inFlight < q.perSession {
+		q.inFlight++
+		s.inFlight++
+		q.mu.Unlock()
+		return q.releaser(session), nil
+	}
+	w := &fairWaiter{ready: make(chan struct{})}
+	s.waiting = append(s.waiting, w)
+	if len(s.waiting) == 1 {
+		q.turns = append(q.turns, session)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return q.releaser(session), nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	if w.granted {
+		// The slot was handed over just as ctx ended.
+		q.mu.Unlock()
+		q.releaser(session)()
+		return nil, ctx.Err()
+	}
+	for i, other := range s.waiting {
+		if other == w {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			break
+		}
+	}
+	if len(s.waiting) == 0 {
+		q.removeTurnLocked(session)
+	}
+	q.forgetLocked(session, s)
+	q.mu.Unlock()
+	return nil, ctx.Err()
+}
+
+func (q *FairQueue) releaser(session string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			s := q.sessions[session]
+			s.inFlight--
+			q.inFlight--
+			q.dispatchLocked()
+			q.forgetLocked(session, s)
+		})
+	}
+}
+
+// dispatchLocked hands free slots to waiting requests, one session at a
+// time. A session that is served goes to the back of the turns; one that
+// is at its own limit is skipped until a request of its own finishes.
+func (q *FairQueue) dispatchLocked() {
+	for q.inFlight < q.maxInFlight {
+		next := -1
+		for i, session := range q.turns {
+			if q.sessions[session].inFlight < q.perSession {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return
+		}
+		session := q.turns[next]
+		s := q.sessions[session]
+		w := s.waiting[0]
+		s.waiting = s.waiting[1:]
+		s.inFlight++
+		q.inFlight++
+		w.granted = true
+		close(w.ready)
+
+		q.turns = append(q.turns[:next], q.turns[next+1:]...)
+		if len(s.waiting) > 0 {
+			q.turns = append(q.turns, session)
+		}
+	}
+}
+
+func (q *FairQueue) removeTurnLocked(session string) {
+	for i, other := range q.turns {
+		if other == session {
+			q.turns = append(q.turns[:i], q.turns[i+1:]...)
+			return
+		}
+	}
+}
+
+// forgetLocked drops the state of a session with nothing in flight or
+// waiting, so sessions of closed browsers do not pile up.
+func (q *FairQueue) forgetLocked(session string, s *fairSession) {
+	if s.inFlight == 0 && len(s.waiting) == 0 {
+		delete(q.sessions, session)
+	}
+}
+
+// Waiting returns how many requests are waiting for a slot.
+func (q *FairQueue) Waiting() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for _, s := range q.sessions {
+		n += len(s.waiting)
+	}
+	return n
+}
+
+type fairQueueTransport struct {
+	queue *FairQueue
+	next  http.RoundTripper
+}
+
+func (t *fairQueueTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.queue.Acquire(req.Context(), sessionFrom(req.Context()))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return t.next.RoundTrip(req)
+}
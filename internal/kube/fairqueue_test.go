@@ -0,0 +1,115 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// queueWaiter starts a request of session that waits for a slot, and
+// returns the channel its release function arrives on once it has one.
+func queueWaiter(t *testing.T, q *FairQueue, session string) <-chan func() {
+	t.Helper()
+	before := q.Waiting()
+	granted := make(chan func(), 1)
+	go func() {
+		release, err := q.Acquire(context.Background(), session)
+		if err != nil {
+			t.Errorf("Acquire(%s): %v", session, err)
+			return
+		}
+		granted <- release
+	}()
+	for q.Waiting() == before {
+		time.Sleep(time.Millisecond)
+	}
+	return granted
+}
+
+func TestFairQueueTakesTurns(t *testing.T) {
+	q := NewFairQueue(1, 1)
+	release, err := q.Acquire(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Session a queues two requests before b queues one; b is served
+	// between them.
+	a2 := queueWaiter(t, q, "a")
+	a3 := queueWaiter(t, q, "a")
+	b1 := queueWaiter(t, q, "b")
+
+	for _, next := range []struct {
+		name string
+		ch   <-chan func()
+	}{{"a2", a2}, {"b1", b1}, {"a3", a3}} {
+		release()
+		select {
+		case release = <-next.ch:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s was not served next", next.name)
+		}
+	}
+	release()
+	if n := q.Waiting(); n != 0 {
+		t.Errorf("Waiting() = %d after all requests finished", n)
+	}
+	if len(q.sessions) != 0 {
+		t.Errorf("queue still tracks %d sessions", len(q.sessions))
+	}
+}
+
+func TestFairQueuePerSessionLimit(t *testing.T) {
+	q := NewFairQueue(3, 1)
+	release, err := q.Acquire(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Session a is at its limit while slots are free, so it waits and b
+	// does not.
+	a2 := queueWaiter(t, q, "a")
+	releaseB, err := q.Acquire(context.Background(), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseB()
+	select {
+	case <-a2:
+		t.Fatal("a second request of session a got a slot")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case release = <-a2:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the waiting request of session a was not served")
+	}
+	release()
+}
+
+func TestFairQueueCancel(t *testing.T) {
+	q := NewFairQueue(1, 1)
+	release, err := q.Acquire(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Acquire(ctx, "b"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire with an ended context = %v, want DeadlineExceeded", err)
+	}
+	if n := q.Waiting(); n != 0 {
+		t.Errorf("Waiting() = %d after the request gave up", n)
+	}
+
+	release()
+	release, err = q.Acquire(context.Background(), "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
@@ -0,0 +1,63 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultFanOutLimit is the number of calls FanOut runs at once when no
+// limit is given. It keeps pages fast without flooding the API server.
+const DefaultFanOutLimit = 8
+
+// Task is one call issued by FanOut. Name identifies it in failures,
+// e.g. the resource kind being listed.
+type Task struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// TaskError is a failed Task.
+type TaskError struct {
+	Name string
+	Err  error
+}
+
+func (e TaskError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+func (e TaskError) Unwrap() error {
+	return e.Err
+}
+
+// FanOut runs tasks in parallel, at most limit at a time (DefaultFanOutLimit
+// when limit is zero or negative). A failed task does not stop the others,
+// so callers can show partial results; the failures are returned in task
+// order. Tasks must only write to state they own, such as their own slot
+// in a results slice.
+func FanOut(ctx context.Context, limit int, tasks []Task) []TaskError {
+	if limit <= 0 {
+		limit = DefaultFanOutLimit
+	}
+
+	errs := make([]error, len(tasks))
+	var g errgroup.Group
+	g.SetLimit(limit)
+	for i, t := range tasks {
+		g.Go(func() error {
+			errs[i] = t.Run(ctx)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var failures []TaskError
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, TaskError{Name: tasks[i].Name, Err: err})
+		}
+	}
+	return failures
+}
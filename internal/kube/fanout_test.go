@@ -0,0 +1,39 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFanOut(t *testing.T) {
+	var running, peak atomic.Int32
+	task := func(name string, err error) Task {
+		return Task{Name: name, Run: func(ctx context.Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			return err
+		}}
+	}
+
+	boom := errors.New("boom")
+	tasks := []Task{task("a", nil), task("b", boom), task("c", nil), task("d", boom), task("e", nil)}
+	failures := FanOut(context.Background(), 2, tasks)
+
+	if len(failures) != 2 || failures[0].Name != "b" || failures[1].Name != "d" {
+		t.Fatalf("FanOut failures = %v, want b and d", failures)
+	}
+	if !errors.Is(failures[0], boom) {
+		t.Errorf("failure does not wrap the task error: %v", failures[0])
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("FanOut ran %d tasks at once, limit was 2", p)
+	}
+}
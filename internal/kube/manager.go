@@ -1,13 +1,17 @@
 package kube
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -15,6 +19,10 @@ import (
 	"k8s.io/client-go/util/homedir"
 )
 
+// switchContextTimeout is how long SwitchContext waits for the new
+// context's API server to answer.
+const switchContextTimeout = 5 * time.Second
+
 // Manager handles Kubernetes client and context state.
 type Manager struct {
 	mu           sync.RWMutex
@@ -24,6 +32,21 @@ type Manager struct {
 	clientConfig clientcmd.ClientConfig
 	isLocal      bool
 	allowedNamespaces []string
+	// lastNamespaces remembers the namespace last used in each context,
+	// so switching back to a context restores it. Like the current context
+	// and namespace it is shared by every user of the process: the UI has
+	// one selection, not one per browser.
+	lastNamespaces map[string]string
+	apiMetrics     *APIMetrics
+	fairQueue      *FairQueue
+	// loadingRules find the kubeconfig in local mode.
+	loadingRules *clientcmd.ClientConfigLoadingRules
+
+	// refreshMu makes credential refreshes run one at a time; a refresh
+	// within credentialRefreshInterval of the last one returns its result.
+	refreshMu      sync.Mutex
+	lastRefresh    time.Time
+	lastRefreshErr error
 }
 
 // NewManager initializes the manager.
@@ -33,6 +56,7 @@ func NewManager(initialNamespace string, allowedNamespaces []string) (*Manager,
 	m := &Manager{
 		namespace:         strings.TrimSpace(initialNamespace),
 		allowedNamespaces: normalizeNamespaces(allowedNamespaces),
+		apiMetrics:        NewAPIMetrics(),
 	}
 	if len(m.allowedNamespaces) > 0 {
 		if m.namespace == "" || !m.isNamespaceAllowedLocked(m.namespace) {
@@ -54,7 +78,7 @@ func NewManager(initialNamespace string, allowedNamespaces []string) (*Manager,
 			}
 		}
 		
-		clientset, err := kubernetes.NewForConfig(config)
+		clientset, err := kubernetes.NewForConfig(m.instrument(config))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create in-cluster clientset: %w", err)
 		}
@@ -76,8 +100,9 @@ func NewManager(initialNamespace string, allowedNamespaces []string) (*Manager,
 	}
 
 	// Load raw config to get contexts
+	m.loadingRules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig}
 	m.clientConfig = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		m.loadingRules,
 		&clientcmd.ConfigOverrides{},
 	)
 	
@@ -103,7 +128,7 @@ func NewManager(initialNamespace string, allowedNamespaces []string) (*Manager,
 		return nil, fmt.Errorf("failed to create rest config: %w", err)
 	}
 
-	clientset, err := kubernetes.NewForConfig(restConfig)
+	clientset, err := kubernetes.NewForConfig(m.instrument(restConfig))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create clientset: %w", err)
 	}
@@ -128,6 +153,18 @@ func (m *Manager) SetNamespace(ns string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.namespace = ns
+	m.rememberNamespaceLocked()
+}
+
+// rememberNamespaceLocked records the current namespace for the current context.
+func (m *Manager) rememberNamespaceLocked() {
+	if !m.isLocal {
+		return
+	}
+	if m.lastNamespaces == nil {
+		m.lastNamespaces = make(map[string]string)
+	}
+	m.lastNamespaces[m.rawConfig.CurrentContext] = m.namespace
 }
 
 func (m *Manager) AllowedNamespaces() []string {
@@ -165,25 +202,25 @@ func (m *Manager) Contexts() ([]string, string) {
 	return contexts, m.rawConfig.CurrentContext
 }
 
-func (m *Manager) SwitchContext(name string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// SwitchContext makes name the current kubeconfig context, once its API
+// server has answered; on error the current context is kept.
+func (m *Manager) SwitchContext(ctx context.Context, name string) error {
+	m.mu.RLock()
+	isLocal := m.isLocal
+	_, found := m.rawConfig.Contexts[name]
+	m.mu.RUnlock()
 
-	if !m.isLocal {
+	if !isLocal {
 		return fmt.Errorf("cannot switch context in in-cluster mode")
 	}
-
-	if _, ok := m.rawConfig.Contexts[name]; !ok {
+	if !found {
 		return fmt.Errorf("context %s not found", name)
 	}
 
-	// Update current context in raw config (in memory only)
-	m.rawConfig.CurrentContext = name
-	
 	// Re-create client config with override
 	overrides := &clientcmd.ConfigOverrides{CurrentContext: name}
 	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		clientcmd.NewDefaultClientConfigLoadingRules(),
+		m.loadingRules,
 		overrides,
 	)
 
@@ -192,16 +229,39 @@ func (m *Manager) SwitchContext(name string) error {
 		return fmt.Errorf("failed to create rest config for context %s: %w", name, err)
 	}
 
-	clientset, err := kubernetes.NewForConfig(restConfig)
+	m.mu.RLock()
+	clientset, err := kubernetes.NewForConfig(m.instrument(restConfig))
+	m.mu.RUnlock()
 	if err != nil {
 		return fmt.Errorf("failed to create clientset for context %s: %w", name, err)
 	}
 
+	// Check that the context's API server answers before switching, so a
+	// dead or unreachable cluster does not leave every page failing. This
+	// is done without the lock, which pages need meanwhile.
+	ctx, cancel := context.WithTimeout(ctx, switchContextTimeout)
+	defer cancel()
+	if err := clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error(); err != nil {
+		return fmt.Errorf("cannot reach the API server of context %s: %w", name, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Remember where we were before leaving the current context.
+	m.rememberNamespaceLocked()
+
+	// Update current context in raw config (in memory only)
+	m.rawConfig.CurrentContext = name
 	m.clientset = clientset
 	m.clientConfig = clientConfig
-	
-	// Update namespace to the new context's default if available, or keep current?
-	// Usually switching context implies switching to that context's namespace.
+
+	// Restore the namespace last used in this context; otherwise switch to
+	// the context's default namespace.
+	if ns, ok := m.lastNamespaces[name]; ok && m.isNamespaceAllowedLocked(ns) {
+		m.namespace = ns
+		return nil
+	}
 	ns, _, err := clientConfig.Namespace()
 	if err == nil && ns != "" {
 		m.namespace = ns
@@ -212,17 +272,145 @@ func (m *Manager) SwitchContext(name string) error {
 	return nil
 }
 
+// RefreshCredentials rebuilds the clientset from freshly loaded
+// credentials. It is used when the API server rejects the current ones,
+// for example after an exec-plugin token expired or the service account
+// token was rotated. Refreshes run one at a time, and one asked for within
+// credentialRefreshInterval of the last returns that one's result, so a
+// burst of rejected requests reloads the credentials once.
+func (m *Manager) RefreshCredentials() error {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	if !m.lastRefresh.IsZero() && time.Since(m.lastRefresh) < credentialRefreshInterval {
+		return m.lastRefreshErr
+	}
+	m.lastRefreshErr = m.refreshCredentials()
+	m.lastRefresh = time.Now()
+	return m.lastRefreshErr
+}
+
+func (m *Manager) refreshCredentials() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var restConfig *rest.Config
+	var clientConfig clientcmd.ClientConfig
+	var rawConfig api.Config
+	var err error
+	if !m.isLocal {
+		restConfig, err = rest.InClusterConfig()
+	} else {
+		// Reload the kubeconfig from disk so credentials written by a
+		// login command since startup are picked up.
+		clientConfig = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			m.loadingRules,
+			&clientcmd.ConfigOverrides{CurrentContext: m.rawConfig.CurrentContext},
+		)
+		restConfig, err = clientConfig.ClientConfig()
+		if err == nil {
+			rawConfig, err = clientConfig.RawConfig()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reload credentials: %w", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(m.instrument(restConfig))
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	m.clientset = clientset
+	if clientConfig != nil {
+		// The current context is kept in memory only.
+		rawConfig.CurrentContext = m.rawConfig.CurrentContext
+		m.rawConfig = rawConfig
+		m.clientConfig = clientConfig
+	}
+	return nil
+}
+
 // RESTConfig returns the REST config for the current context.
 func (m *Manager) RESTConfig() (*rest.Config, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	var config *rest.Config
+	var err error
 	if !m.isLocal {
 		// In-cluster mode
-		return rest.InClusterConfig()
+		config, err = rest.InClusterConfig()
+	} else {
+		config, err = m.clientConfig.ClientConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.instrument(config), nil
+}
+
+// SetFairQueue makes all clients, including the current one, share their
+// requests to the API server fairly among sessions through q.
+func (m *Manager) SetFairQueue(q *FairQueue) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.fairQueue = q
+
+	// Rebuild the current client from the config it was made from, so the
+	// context and kubeconfig in use are kept.
+	var restConfig *rest.Config
+	var err error
+	if !m.isLocal {
+		restConfig, err = rest.InClusterConfig()
+	} else {
+		restConfig, err = m.clientConfig.ClientConfig()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create rest config: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(m.instrument(restConfig))
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
 	}
+	m.clientset = clientset
+	return nil
+}
+
+// FairQueue returns the queue set with SetFairQueue, or nil.
+func (m *Manager) FairQueue() *FairQueue {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.fairQueue
+}
 
-	return m.clientConfig.ClientConfig()
+// APIMetrics returns the telemetry of the requests made to the API server.
+func (m *Manager) APIMetrics() *APIMetrics {
+	return m.apiMetrics
+}
+
+// instrument makes clients built from config record their API requests,
+// and trace them when a tracer provider is installed. Requests wait in the
+// fair queue, when there is one, before they are traced and timed, so the
+// recorded latency is the API server's. A request the API server answers
+// with 401 Unauthorized refreshes the credentials.
+func (m *Manager) instrument(config *rest.Config) *rest.Config {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &unauthorizedTransport{manager: m, next: rt}
+	})
+	if m.apiMetrics != nil {
+		m.apiMetrics.Wrap(config)
+	}
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return otelhttp.NewTransport(rt, otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+			call := apiCallFor(req)
+			return "kube " + call.Verb + " " + call.Resource
+		}))
+	})
+	if m.fairQueue != nil {
+		m.fairQueue.Wrap(config)
+	}
+	return config
 }
 
 func (m *Manager) isNamespaceAllowedLocked(ns string) bool {
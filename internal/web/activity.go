@@ -0,0 +1,263 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rakeshavasarala/k8s-ui/internal/kube"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// activityLimit caps the entries shown on the activity page, newest first.
+const activityLimit = 1000
+
+// activityEventReasons are the Normal events that record a change worth
+// seeing in the activity feed. Other Normal events, such as image pulls and
+// container starts, are routine and left to the events page; Warning events
+// are always included.
+var activityEventReasons = map[string]bool{
+	"ScalingReplicaSet":  true,
+	"SuccessfulRescale":  true,
+	"DeploymentRollback": true,
+	"Killing":            true,
+	"Completed":          true,
+	"SuspendedJob":       true,
+	"Evicted":            true,
+}
+
+// Activity is one entry in a namespace's activity feed.
+type Activity struct {
+	Time    time.Time
+	Source  string // "k8s-ui", "event", "rollout" or "container"
+	Warning bool
+	Object  string // lower-case kind and name, such as "deployment web"
+	Message string
+	Actor   string // set for changes made through k8s-ui
+	URL     string // the object's page, set for changes made through k8s-ui
+}
+
+// auditActivity turns the changes made through k8s-ui into activity.
+func auditActivity(entries []AuditEntry) []Activity {
+	out := make([]Activity, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, Activity{
+			Time:    e.Time,
+			Source:  "k8s-ui",
+			Object:  e.Kind + " " + e.Name,
+			Message: e.Action,
+			Actor:   e.Actor,
+			URL:     auditEntryURL(e),
+		})
+	}
+	return out
+}
+
+// auditEntryURL links an audit entry to the page of the object it changed,
+// in the entry's namespace. Kinds without a list page in k8s-ui, such as
+// daemonset, are not linked.
+func auditEntryURL(e AuditEntry) string {
+	for resource, gvr := range batchResources {
+		if strings.TrimSuffix(gvr.Resource, "s") == e.Kind || strings.TrimSuffix(gvr.Resource, "es") == e.Kind {
+			return resourcePageURL(resource, e.Namespace, e.Name)
+		}
+	}
+	return ""
+}
+
+// eventActivity keeps the Warning events and the Normal events listed in
+// activityEventReasons.
+func eventActivity(events []corev1.Event) []Activity {
+	var out []Activity
+	for _, e := range events {
+		warning := e.Type == corev1.EventTypeWarning
+		if !warning && !activityEventReasons[e.Reason] {
+			continue
+		}
+		msg := e.Reason + ": " + e.Message
+		if n := eventCount(e); n > 1 {
+			msg += fmt.Sprintf(" (%d times)", n)
+		}
+		out = append(out, Activity{
+			Time:    eventTime(e),
+			Source:  "event",
+			Warning: warning,
+			Object:  strings.ToLower(e.InvolvedObject.Kind) + " " + e.InvolvedObject.Name,
+			Message: msg,
+		})
+	}
+	return out
+}
+
+// rolloutActivity lists when each revision of a Deployment was rolled out,
+// taken from its ReplicaSets. A rollback reuses the ReplicaSet of the
+// earlier revision, so it shows as the event the Deployment records rather
+// than here.
+func rolloutActivity(replicaSets []appsv1.ReplicaSet) []Activity {
+	var out []Activity
+	for _, rs := range replicaSets {
+		owner := metav1.GetControllerOf(&rs)
+		revision := rs.Annotations["deployment.kubernetes.io/revision"]
+		if owner == nil || owner.Kind != "Deployment" || revision == "" {
+			continue
+		}
+		var images []string
+		for _, c := range rs.Spec.Template.Spec.Containers {
+			images = append(images, c.Image)
+		}
+		msg := fmt.Sprintf("revision %s rolled out with %s", revision, strings.Join(images, ", "))
+		if cause := rs.Annotations["kubernetes.io/change-cause"]; cause != "" {
+			msg += " (" + cause + ")"
+		}
+		out = append(out, Activity{
+			Time:    rs.CreationTimestamp.Time,
+			Source:  "rollout",
+			Object:  "deployment " + owner.Name,
+			Message: msg,
+		})
+	}
+	return out
+}
+
+// terminationActivity lists the last termination of each container that
+// the kubelet still reports, such as an OOM kill. Earlier terminations are
+// only visible as events.
+func terminationActivity(pods []corev1.Pod) []Activity {
+	var out []Activity
+	for _, p := range pods {
+		statuses := append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			t := cs.LastTerminationState.Terminated
+			if t == nil || t.FinishedAt.IsZero() {
+				continue
+			}
+			reason := t.Reason
+			if reason == "" {
+				reason = "Terminated"
+			}
+			out = append(out, Activity{
+				Time:    t.FinishedAt.Time,
+				Source:  "container",
+				Warning: reason != "Completed",
+				Object:  "pod " + p.Name,
+				Message: fmt.Sprintf("container %s %s (exit code %d)", cs.Name, reason, t.ExitCode),
+			})
+		}
+	}
+	return out
+}
+
+// ActivityView is an activity entry as shown on the page.
+type ActivityView struct {
+	Activity
+	Age  string
+	When string
+}
+
+type ActivityPage struct {
+	BasePage
+	Items    []ActivityView
+	Ranges   []EventRange
+	Since    string
+	Warnings []string
+	History  string
+	Limited  bool
+}
+
+// handleActivity shows a single chronological feed of what happened in
+// the namespace: changes made through k8s-ui, Warning and notable Normal
+// events, Deployment rollouts and container terminations, newest first.
+func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace := s.namespace(r)
+	client := s.manager.Client()
+	_, current := s.manager.Contexts()
+
+	data := ActivityPage{
+		BasePage: BasePage{Namespace: namespace, Title: "Activity", Active: "events"},
+		Ranges:   eventRanges,
+	}
+	var since time.Duration
+	for _, er := range eventRanges {
+		if er.Key == r.URL.Query().Get("since") {
+			since, _ = time.ParseDuration(er.Key)
+			data.Since = er.Key
+		}
+	}
+
+	found := make([][]Activity, 3)
+	tasks := []kube.Task{
+		{Name: "events", Run: func(ctx context.Context) error {
+			list, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			events := list.Items
+			if s.history != nil {
+				for i := range events {
+					s.history.Record(&events[i])
+				}
+				events = s.history.Events(namespace)
+			}
+			found[0] = eventActivity(events)
+			return nil
+		}},
+		{Name: "replicasets", Run: func(ctx context.Context) error {
+			list, err := client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			found[1] = rolloutActivity(list.Items)
+			return nil
+		}},
+		{Name: "pods", Run: func(ctx context.Context) error {
+			list, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			found[2] = terminationActivity(list.Items)
+			return nil
+		}},
+	}
+	for _, f := range kube.FanOut(r.Context(), 0, tasks) {
+		data.Warnings = append(data.Warnings, f.Error())
+	}
+	if s.history != nil {
+		data.History = formatDuration(s.history.Retention())
+	}
+
+	items := auditActivity(s.auditLog.list(current, namespace))
+	for _, f := range found {
+		items = append(items, f...)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Time.After(items[j].Time) })
+
+	cutoff := time.Time{}
+	if since > 0 {
+		cutoff = time.Now().Add(-since)
+	}
+	for _, a := range items {
+		if a.Time.Before(cutoff) {
+			break
+		}
+		if len(data.Items) == activityLimit {
+			data.Limited = true
+			break
+		}
+		data.Items = append(data.Items, ActivityView{
+			Activity: a,
+			Age:      formatAge(a.Time),
+			When:     a.Time.Local().Format(eventTimeLayout),
+		})
+	}
+
+	s.renderTemplate(w, "activity.html", data)
+}
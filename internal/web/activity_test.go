@@ -0,0 +1,112 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestAuditLogKeepsMostRecent(t *testing.T) {
+	var l auditLog
+	for i := 0; i < auditLogSize+10; i++ {
+		l.add(AuditEntry{Context: "prod", Namespace: "web", Name: "d", Action: "restarted"})
+	}
+	l.add(AuditEntry{Context: "prod", Namespace: "other", Name: "d", Action: "restarted"})
+	if got := len(l.list("prod", "web")); got != auditLogSize-1 {
+		t.Errorf("kept %d entries for prod/web, want %d", got, auditLogSize-1)
+	}
+	if got := len(l.list("dev", "web")); got != 0 {
+		t.Errorf("kept %d entries for dev/web, want 0", got)
+	}
+}
+
+func TestAuditEntryURL(t *testing.T) {
+	tests := []struct {
+		kind, want string
+	}{
+		{"deployment", "/deployments/web?namespace=shop"},
+		{"service", "/services/web?namespace=shop"},
+		{"ingress", "/describe?name=web&namespace=shop&resource=ingresses"},
+		{"daemonset", ""},
+	}
+	for _, tt := range tests {
+		if got := auditEntryURL(AuditEntry{Namespace: "shop", Kind: tt.kind, Name: "web"}); got != tt.want {
+			t.Errorf("auditEntryURL(%s) = %q, want %q", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestEventActivity(t *testing.T) {
+	now := metav1.NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	event := func(typ, reason string, count int32) corev1.Event {
+		return corev1.Event{
+			Type:           typ,
+			Reason:         reason,
+			Message:        "msg",
+			Count:          count,
+			LastTimestamp:  now,
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-1"},
+		}
+	}
+	got := eventActivity([]corev1.Event{
+		event(corev1.EventTypeNormal, "Pulled", 1),
+		event(corev1.EventTypeNormal, "Killing", 1),
+		event(corev1.EventTypeWarning, "BackOff", 4),
+	})
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want Killing and BackOff: %+v", len(got), got)
+	}
+	if got[0].Warning || got[0].Message != "Killing: msg" || got[0].Object != "pod web-1" {
+		t.Errorf("Killing entry = %+v", got[0])
+	}
+	if !got[1].Warning || got[1].Message != "BackOff: msg (4 times)" {
+		t.Errorf("BackOff entry = %+v", got[1])
+	}
+}
+
+func TestRolloutActivity(t *testing.T) {
+	rs := func(name, owner, revision, cause string) appsv1.ReplicaSet {
+		r := appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{"deployment.kubernetes.io/revision": revision},
+		}}
+		if cause != "" {
+			r.Annotations["kubernetes.io/change-cause"] = cause
+		}
+		if owner != "" {
+			r.OwnerReferences = []metav1.OwnerReference{{Kind: "Deployment", Name: owner, Controller: ptr.To(true)}}
+		}
+		r.Spec.Template.Spec.Containers = []corev1.Container{{Name: "app", Image: "web:2"}}
+		return r
+	}
+	got := rolloutActivity([]appsv1.ReplicaSet{
+		rs("web-abc", "web", "3", "bump image"),
+		rs("orphan", "", "1", ""),
+	})
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1: %+v", len(got), got)
+	}
+	if want := "revision 3 rolled out with web:2 (bump image)"; got[0].Message != want || got[0].Object != "deployment web" {
+		t.Errorf("entry = %+v, want %q on deployment web", got[0], want)
+	}
+}
+
+func TestTerminationActivity(t *testing.T) {
+	finished := metav1.NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1"}}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "app", LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137, FinishedAt: finished}}},
+		{Name: "sidecar"},
+	}
+	got := terminationActivity([]corev1.Pod{pod})
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1: %+v", len(got), got)
+	}
+	if want := "container app OOMKilled (exit code 137)"; got[0].Message != want || !got[0].Warning || !got[0].Time.Equal(finished.Time) {
+		t.Errorf("entry = %+v, want %q", got[0], want)
+	}
+}
@@ -0,0 +1,164 @@
+package web
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sync/atomic"
+	"time"
+
+	"github.com/rakeshavasarala/k8s-ui/internal/kube"
+)
+
+// serverMetrics counts the requests served by the UI for /metrics.
+type serverMetrics struct {
+	requests [6]atomic.Uint64 // by status class, 1xx to 5xx; 0 is unused
+	duration atomic.Int64     // total nanoseconds spent serving requests
+	inFlight atomic.Int64
+}
+
+// statusRecorder captures the response status for metrics. It passes
+// flushing and hijacking through, which log streaming and the exec
+// terminal's websocket need.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	if sr.status == 0 {
+		sr.status = code
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := sr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	// A hijacked connection is upgraded, for example to a websocket.
+	sr.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+func (s *Server) withMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		s.metrics.inFlight.Add(1)
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			s.metrics.inFlight.Add(-1)
+			s.metrics.duration.Add(int64(time.Since(start)))
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if class := status / 100; class >= 1 && class <= 5 {
+				s.metrics.requests[class].Add(1)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// AdminHandler serves the operational endpoints: /healthz, /readyz,
+// /metrics in the Prometheus text format, and /debug/pprof. It is meant for
+// an internal port that is not exposed through the public ingress.
+func (s *Server) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// handleHealthz reports that the process is serving.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz reports whether the Kubernetes API server can be reached.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	client := s.manager.Client()
+	if client == nil {
+		http.Error(w, "no Kubernetes client", http.StatusServiceUnavailable)
+		return
+	}
+	if _, err := client.Discovery().ServerVersion(); err != nil {
+		http.Error(w, "Kubernetes API unreachable: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	fmt.Fprintln(w, "# HELP k8s_ui_http_requests_total UI requests served, by status class.")
+	fmt.Fprintln(w, "# TYPE k8s_ui_http_requests_total counter")
+	for class := 1; class <= 5; class++ {
+		fmt.Fprintf(w, "k8s_ui_http_requests_total{code=\"%dxx\"} %d\n", class, s.metrics.requests[class].Load())
+	}
+	fmt.Fprintln(w, "# HELP k8s_ui_http_request_duration_seconds_total Time spent serving UI requests.")
+	fmt.Fprintln(w, "# TYPE k8s_ui_http_request_duration_seconds_total counter")
+	fmt.Fprintf(w, "k8s_ui_http_request_duration_seconds_total %g\n", time.Duration(s.metrics.duration.Load()).Seconds())
+	fmt.Fprintln(w, "# HELP k8s_ui_http_requests_in_flight UI requests being served, including open exec terminals and log streams.")
+	fmt.Fprintln(w, "# TYPE k8s_ui_http_requests_in_flight gauge")
+	fmt.Fprintf(w, "k8s_ui_http_requests_in_flight %d\n", s.metrics.inFlight.Load())
+	fmt.Fprintln(w, "# HELP k8s_ui_exec_sessions Open exec terminals.")
+	fmt.Fprintln(w, "# TYPE k8s_ui_exec_sessions gauge")
+	fmt.Fprintf(w, "k8s_ui_exec_sessions %d\n", s.execSessions.count())
+	if s.manager != nil && s.manager.FairQueue() != nil {
+		fmt.Fprintln(w, "# HELP k8s_ui_kube_api_requests_waiting Kubernetes API requests waiting in the fair queue.")
+		fmt.Fprintln(w, "# TYPE k8s_ui_kube_api_requests_waiting gauge")
+		fmt.Fprintf(w, "k8s_ui_kube_api_requests_waiting %d\n", s.manager.FairQueue().Waiting())
+	}
+
+	var calls []kube.APICallStats
+	if s.manager != nil {
+		calls = s.manager.APIMetrics().Snapshot()
+	}
+	fmt.Fprintln(w, "# HELP k8s_ui_kube_api_requests_total Requests made to the Kubernetes API server.")
+	fmt.Fprintln(w, "# TYPE k8s_ui_kube_api_requests_total counter")
+	for _, c := range calls {
+		fmt.Fprintf(w, "k8s_ui_kube_api_requests_total{verb=%q,resource=%q} %d\n", c.Verb, c.Resource, c.Count)
+	}
+	fmt.Fprintln(w, "# HELP k8s_ui_kube_api_request_errors_total Kubernetes API requests that failed or returned a 5xx status.")
+	fmt.Fprintln(w, "# TYPE k8s_ui_kube_api_request_errors_total counter")
+	for _, c := range calls {
+		fmt.Fprintf(w, "k8s_ui_kube_api_request_errors_total{verb=%q,resource=%q} %d\n", c.Verb, c.Resource, c.Errors)
+	}
+	fmt.Fprintln(w, "# HELP k8s_ui_kube_api_request_duration_seconds_total Time spent waiting for the Kubernetes API server to respond.")
+	fmt.Fprintln(w, "# TYPE k8s_ui_kube_api_request_duration_seconds_total counter")
+	for _, c := range calls {
+		fmt.Fprintf(w, "k8s_ui_kube_api_request_duration_seconds_total{verb=%q,resource=%q} %g\n", c.Verb, c.Resource, c.Duration.Seconds())
+	}
+}
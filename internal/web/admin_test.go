@@ -0,0 +1,40 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithMetrics(t *testing.T) {
+	s := &Server{}
+	h := s.withMetrics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/stream":
+			w.Write([]byte("line"))
+			if _, ok := w.(http.Flusher); !ok {
+				t.Error("wrapped writer does not support flushing")
+			}
+		}
+	}))
+	for _, path := range []string{"/", "/stream", "/missing"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := rec.Body.String()
+	for _, want := range []string{
+		`k8s_ui_http_requests_total{code="2xx"} 2`,
+		`k8s_ui_http_requests_total{code="4xx"} 1`,
+		`k8s_ui_http_requests_in_flight 0`,
+		`k8s_ui_exec_sessions 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+}
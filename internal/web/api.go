@@ -0,0 +1,209 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+// APIPodStatus is a pod as the JSON API reports it.
+type APIPodStatus struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Phase     string    `json:"phase"`
+	Ready     string    `json:"ready"`
+	Restarts  int32     `json:"restarts"`
+	Node      string    `json:"node,omitempty"`
+	Created   time.Time `json:"created"`
+}
+
+// APIDeploymentStatus is a Deployment as the JSON API reports it.
+// Complete is true once the latest rollout has finished.
+type APIDeploymentStatus struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Replicas  int32     `json:"replicas"`
+	Ready     int32     `json:"ready"`
+	Updated   int32     `json:"updated"`
+	Available int32     `json:"available"`
+	Complete  bool      `json:"complete"`
+	Images    []string  `json:"images"`
+	Created   time.Time `json:"created"`
+}
+
+func apiPodStatus(p *corev1.Pod) APIPodStatus {
+	return APIPodStatus{
+		Name:      p.Name,
+		Namespace: p.Namespace,
+		Phase:     string(p.Status.Phase),
+		Ready:     readyContainers(*p),
+		Restarts:  totalRestarts(*p),
+		Node:      p.Spec.NodeName,
+		Created:   p.CreationTimestamp.Time,
+	}
+}
+
+// apiDeploymentStatus reports a rollout as complete the way kubectl rollout
+// status does: the controller has seen the latest spec and every replica is
+// updated and available.
+func apiDeploymentStatus(d *appsv1.Deployment) APIDeploymentStatus {
+	replicas := ptr.Deref(d.Spec.Replicas, 1)
+	images := make([]string, 0, len(d.Spec.Template.Spec.Containers))
+	for _, c := range d.Spec.Template.Spec.Containers {
+		images = append(images, c.Image)
+	}
+	st := d.Status
+	return APIDeploymentStatus{
+		Name:      d.Name,
+		Namespace: d.Namespace,
+		Replicas:  replicas,
+		Ready:     st.ReadyReplicas,
+		Updated:   st.UpdatedReplicas,
+		Available: st.AvailableReplicas,
+		Complete: st.ObservedGeneration >= d.Generation && st.UpdatedReplicas == replicas &&
+			st.Replicas == replicas && st.AvailableReplicas == replicas,
+		Images:  images,
+		Created: d.CreationTimestamp.Time,
+	}
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	writeAPIJSON(w, status, map[string]string{"error": msg})
+}
+
+// writeAPIK8sError passes on the status of a Kubernetes API error, so a
+// missing resource is a 404 and a denied one a 403.
+func writeAPIK8sError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if se, ok := err.(apierrors.APIStatus); ok && se.Status().Code != 0 {
+		status = int(se.Status().Code)
+	}
+	writeAPIError(w, status, err.Error())
+}
+
+// apiName returns the single name following prefix in the request path,
+// such as "web" for /api/v1/deployments/web with prefix /api/v1/deployments/.
+func apiName(r *http.Request, prefix, suffix string) (string, bool) {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, prefix), suffix)
+	return name, ok && name != "" && !strings.Contains(name, "/")
+}
+
+func (s *Server) handleAPIPods(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	pods, err := s.manager.Client().CoreV1().Pods(s.namespace(r)).List(r.Context(), metav1.ListOptions{LabelSelector: r.URL.Query().Get("selector")})
+	if err != nil {
+		writeAPIK8sError(w, err)
+		return
+	}
+	items := make([]APIPodStatus, 0, len(pods.Items))
+	for i := range pods.Items {
+		items = append(items, apiPodStatus(&pods.Items[i]))
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (s *Server) handleAPIPod(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	name, ok := apiName(r, "/api/v1/pods/", "")
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "not found")
+		return
+	}
+	pod, err := s.manager.Client().CoreV1().Pods(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		writeAPIK8sError(w, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, apiPodStatus(pod))
+}
+
+func (s *Server) handleAPIDeployments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	list, err := s.manager.Client().AppsV1().Deployments(s.namespace(r)).List(r.Context(), metav1.ListOptions{LabelSelector: r.URL.Query().Get("selector")})
+	if err != nil {
+		writeAPIK8sError(w, err)
+		return
+	}
+	items := make([]APIDeploymentStatus, 0, len(list.Items))
+	for i := range list.Items {
+		items = append(items, apiDeploymentStatus(&list.Items[i]))
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (s *Server) handleAPIDeployment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	name, ok := apiName(r, "/api/v1/deployments/", "")
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "not found")
+		return
+	}
+	d, err := s.manager.Client().AppsV1().Deployments(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		writeAPIK8sError(w, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, apiDeploymentStatus(d))
+}
+
+// handleAPIDeploymentRestart performs a rollout restart. Production
+// namespaces need a typed confirmation in the UI, which a token cannot
+// give, so they are refused.
+func (s *Server) handleAPIDeploymentRestart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	name, ok := apiName(r, "/api/v1/deployments/", "/restart")
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "not found")
+		return
+	}
+	ns := s.namespace(r)
+	if s.isProduction(ns) {
+		writeAPIError(w, http.StatusForbidden, "namespace "+ns+" is a production scope; restart it from the UI")
+		return
+	}
+
+	payload, err := rolloutRestartPatch()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	d, err := s.manager.Client().AppsV1().Deployments(ns).Patch(r.Context(), name, types.MergePatchType, payload, metav1.PatchOptions{})
+	if err != nil {
+		writeAPIK8sError(w, err)
+		return
+	}
+	log.Printf("API token %s restarted deployment %s/%s", r.Context().Value(apiTokenKey{}), ns, name)
+	s.audit(requestActor(r), ns, "deployment", name, "restarted")
+	writeAPIJSON(w, http.StatusOK, apiDeploymentStatus(d))
+}
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/rakeshavasarala/k8s-ui/internal/kube"
+)
+
+// API token scopes. ScopeRead allows the GET endpoints of the JSON API;
+// each other scope allows one change.
+const (
+	ScopeRead    = "read"
+	ScopeRestart = "restart"
+)
+
+var apiScopes = []string{ScopeRead, ScopeRestart}
+
+// APIToken lets automation, such as a CI pipeline or a chat bot, call the
+// JSON API under /api/v1 without cluster credentials of its own. Calls run
+// with the server's credentials, limited to the token's scopes.
+type APIToken struct {
+	Name   string // shown in logs and errors, never the token itself
+	Scopes []string
+	Token  string
+}
+
+// ParseAPITokens reads one token per line as "name scope[,scope...] token".
+// Blank lines and lines starting with # are skipped.
+func ParseAPITokens(r io.Reader) ([]APIToken, error) {
+	var tokens []APIToken
+	names := make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: want \"name scopes token\"", n)
+		}
+		t := APIToken{Name: fields[0], Scopes: strings.Split(fields[1], ","), Token: fields[2]}
+		if names[t.Name] {
+			return nil, fmt.Errorf("line %d: duplicate token name %q", n, t.Name)
+		}
+		names[t.Name] = true
+		for _, scope := range t.Scopes {
+			if !slices.Contains(apiScopes, scope) {
+				return nil, fmt.Errorf("line %d: unknown scope %q, want one of %s", n, scope, strings.Join(apiScopes, ", "))
+			}
+		}
+		if len(t.Token) < 16 {
+			return nil, fmt.Errorf("line %d: token %q is shorter than 16 characters", n, t.Name)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, sc.Err()
+}
+
+type apiTokenKey struct{}
+
+// apiTokenFor returns the configured token matching the request's bearer
+// token. Tokens are compared by hash in constant time.
+func (s *Server) apiTokenFor(r *http.Request) (APIToken, bool) {
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || bearer == "" {
+		return APIToken{}, false
+	}
+	got := sha256.Sum256([]byte(bearer))
+	for _, t := range s.config.APITokens {
+		want := sha256.Sum256([]byte(t.Token))
+		if subtle.ConstantTimeCompare(got[:], want[:]) == 1 {
+			return t, true
+		}
+	}
+	return APIToken{}, false
+}
+
+// withAPIToken requires a token with scope for the JSON API handler next,
+// and records the token on the request for logging.
+func (s *Server) withAPIToken(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, ok := s.apiTokenFor(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="k8s-ui"`)
+			writeAPIError(w, http.StatusUnauthorized, "missing or unknown API token")
+			return
+		}
+		if !slices.Contains(t.Scopes, scope) {
+			writeAPIError(w, http.StatusForbidden, fmt.Sprintf("API token %s does not have the %s scope", t.Name, scope))
+			return
+		}
+		ctx := context.WithValue(r.Context(), apiTokenKey{}, t.Name)
+		next(w, r.WithContext(kube.WithSession(ctx, "API token "+t.Name)))
+	}
+}
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseAPITokens(t *testing.T) {
+	tokens, err := ParseAPITokens(strings.NewReader(`
+# CI only reads status
+ci read 0123456789abcdef
+chatbot read,restart fedcba9876543210
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].Name != "ci" || len(tokens[1].Scopes) != 2 || tokens[1].Token != "fedcba9876543210" {
+		t.Errorf("ParseAPITokens() = %+v", tokens)
+	}
+
+	for _, bad := range []string{
+		"ci read",
+		"ci delete 0123456789abcdef",
+		"ci read short",
+		"ci read 0123456789abcdef\nci read fedcba9876543210",
+	} {
+		if _, err := ParseAPITokens(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseAPITokens(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestWithAPIToken(t *testing.T) {
+	s := &Server{config: Config{APITokens: []APIToken{
+		{Name: "ci", Scopes: []string{ScopeRead}, Token: "0123456789abcdef"},
+	}}}
+	h := s.withAPIToken(ScopeRestart, func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong-token-value", http.StatusUnauthorized},
+		{"Basic 0123456789abcdef", http.StatusUnauthorized},
+		{"Bearer 0123456789abcdef", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/deployments/web/restart", nil)
+		req.Header.Set("Authorization", tt.auth)
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Authorization %q: status %d, want %d", tt.auth, rec.Code, tt.want)
+		}
+	}
+}
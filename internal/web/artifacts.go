@@ -0,0 +1,333 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSessionMaxAge is how long exec terminals and followed streams
+	// may stay open when Config.SessionMaxAge is not set.
+	defaultSessionMaxAge = 12 * time.Hour
+
+	// tempDirMaxAge is how long a download with a temporary folder may
+	// run. Downloads take seconds to minutes; one this old hangs, and a
+	// folder this old was left by a run that ended without removing it.
+	tempDirMaxAge = time.Hour
+
+	// artifactReapInterval is how often the reaper looks for expired
+	// sessions and downloads.
+	artifactReapInterval = time.Minute
+)
+
+// tempDirPrefixes are the patterns of the temporary folders of downloads,
+// by which folders left by an earlier run are found.
+var tempDirPrefixes = []string{"k8s-ui-logs-", "k8s-ui-bundle-"}
+
+var (
+	errArtifactExpired = errors.New("open longer than SESSION_MAX_AGE")
+	errArtifactEnded   = errors.New("ended from the Server Sessions page")
+	errDownloadExpired = errors.New("the download took longer than an hour")
+)
+
+// artifact is a long-running request the server holds open: an exec
+// terminal, a followed stream or a download with a temporary folder. The
+// reaper releases it once Expires has passed, by cancelling the request,
+// even when the client keeps it open.
+type artifact struct {
+	ID        string
+	Kind      string
+	Context   string
+	Namespace string
+	Name      string
+	Actor     string
+	Created   time.Time
+	Expires   time.Time
+	release   func(cause error)
+}
+
+// artifactRegistry keeps the artifacts that are open.
+type artifactRegistry struct {
+	mu    sync.Mutex
+	seq   uint64
+	items map[string]*artifact
+}
+
+// add tracks a and returns the func that stops tracking it, to be called
+// when the request that opened it is done.
+func (ar *artifactRegistry) add(a *artifact) func() {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	if ar.items == nil {
+		ar.items = make(map[string]*artifact)
+	}
+	ar.seq++
+	a.ID = strconv.FormatUint(ar.seq, 10)
+	ar.items[a.ID] = a
+	return func() {
+		ar.mu.Lock()
+		defer ar.mu.Unlock()
+		delete(ar.items, a.ID)
+	}
+}
+
+// take stops tracking the artifact with the given ID and returns it, or
+// nil when it is no longer open.
+func (ar *artifactRegistry) take(id string) *artifact {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	a := ar.items[id]
+	delete(ar.items, id)
+	return a
+}
+
+// expired stops tracking the artifacts whose deadline has passed at now
+// and returns them.
+func (ar *artifactRegistry) expired(now time.Time) []*artifact {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	var out []*artifact
+	for id, a := range ar.items {
+		if !now.Before(a.Expires) {
+			out = append(out, a)
+			delete(ar.items, id)
+		}
+	}
+	return out
+}
+
+// list returns the open artifacts, oldest first.
+func (ar *artifactRegistry) list() []artifact {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	out := make([]artifact, 0, len(ar.items))
+	for _, a := range ar.items {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.Before(out[j].Created) })
+	return out
+}
+
+func (s *Server) sessionMaxAge() time.Duration {
+	if s.config.SessionMaxAge > 0 {
+		return s.config.SessionMaxAge
+	}
+	return defaultSessionMaxAge
+}
+
+// trackSession tracks a long-running request, such as an exec terminal,
+// as an artifact of kind. It returns the request with a context that is
+// cancelled, with errArtifactExpired or errArtifactEnded as its cause,
+// when the session is reaped or ended by hand, and the func to call when
+// the request is done.
+func (s *Server) trackSession(r *http.Request, kind, name string) (*http.Request, func()) {
+	ctx, cancel := context.WithCancelCause(r.Context())
+	now := time.Now()
+	_, current := s.manager.Contexts()
+	remove := s.artifacts.add(&artifact{
+		Kind:      kind,
+		Context:   current,
+		Namespace: s.namespace(r),
+		Name:      name,
+		Actor:     requestActor(r),
+		Created:   now,
+		Expires:   now.Add(s.sessionMaxAge()),
+		release:   cancel,
+	})
+	return r.WithContext(ctx), func() {
+		remove()
+		cancel(context.Canceled)
+	}
+}
+
+// trackDownload tracks a download that reads into a temporary folder,
+// named by pattern as for os.MkdirTemp. Like trackSession it returns the
+// request with a context that is cancelled when the download is reaped,
+// after tempDirMaxAge with errDownloadExpired as the cause, or ended by
+// hand. The folder is only removed by the returned func, once the request
+// is done with it, so a reaped download fails instead of reading from
+// files that are gone.
+func (s *Server) trackDownload(r *http.Request, pattern, name string) (*http.Request, string, func(), error) {
+	dir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return r, "", nil, err
+	}
+	ctx, cancel := context.WithCancelCause(r.Context())
+	now := time.Now()
+	_, current := s.manager.Contexts()
+	remove := s.artifacts.add(&artifact{
+		Kind:      "download",
+		Context:   current,
+		Namespace: s.namespace(r),
+		Name:      name,
+		Actor:     requestActor(r),
+		Created:   now,
+		Expires:   now.Add(tempDirMaxAge),
+		release: func(cause error) {
+			if errors.Is(cause, errArtifactExpired) {
+				cause = errDownloadExpired
+			}
+			cancel(cause)
+		},
+	})
+	return r.WithContext(ctx), dir, func() {
+		remove()
+		cancel(context.Canceled)
+		os.RemoveAll(dir)
+	}, nil
+}
+
+// downloadStopped returns why a download tracked by trackDownload was
+// reaped or ended by hand, or nil.
+func downloadStopped(r *http.Request) error {
+	cause := context.Cause(r.Context())
+	if errors.Is(cause, errDownloadExpired) || errors.Is(cause, errArtifactEnded) {
+		return cause
+	}
+	return nil
+}
+
+// removeStaleTempDirs removes the download folders in dir that are older
+// than tempDirMaxAge, left by a run that ended before it could remove
+// them, and returns their names.
+func removeStaleTempDirs(dir string, now time.Time) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var removed []string
+	for _, e := range entries {
+		if !e.IsDir() || !hasAnyPrefix(e.Name(), tempDirPrefixes) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) < tempDirMaxAge {
+			continue
+		}
+		if os.RemoveAll(filepath.Join(dir, e.Name())) == nil {
+			removed = append(removed, e.Name())
+		}
+	}
+	return removed
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// runReaper releases expired artifacts every artifactReapInterval until
+// ctx is done. Download folders left by an earlier run are removed first.
+func (s *Server) runReaper(ctx context.Context) {
+	for _, name := range removeStaleTempDirs(os.TempDir(), time.Now()) {
+		log.Printf("Removed stale temporary folder %s", name)
+	}
+	ticker := time.NewTicker(artifactReapInterval)
+	defer ticker.Stop()
+	for {
+		s.reap(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) reap(now time.Time) {
+	for _, a := range s.artifacts.expired(now) {
+		log.Printf("Reaping %s %s in %s/%s of %s, open since %s", a.Kind, a.Name, a.Context, a.Namespace, a.Actor, a.Created.Format(time.RFC3339))
+		a.release(errArtifactExpired)
+	}
+}
+
+// SessionsPage lists what the server holds open.
+type SessionsPage struct {
+	BasePage
+	Sessions []SessionView
+	MaxAge   string
+}
+
+type SessionView struct {
+	ID        string
+	Kind      string
+	Context   string
+	Namespace string
+	Name      string
+	Actor     string
+	Age       string
+	ExpiresIn string
+}
+
+// handleSessions lists the open exec terminals, streams and downloads of
+// all users. It is only routed when Config.SessionsPage is set, as it
+// shows and ends the sessions of everyone.
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	now := time.Now()
+	data := SessionsPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Server Sessions", Active: "resources"},
+		MaxAge:   formatDuration(s.sessionMaxAge()),
+	}
+	for _, a := range s.artifacts.list() {
+		data.Sessions = append(data.Sessions, SessionView{
+			ID:        a.ID,
+			Kind:      a.Kind,
+			Context:   a.Context,
+			Namespace: a.Namespace,
+			Name:      a.Name,
+			Actor:     a.Actor,
+			Age:       formatAge(a.Created),
+			ExpiresIn: formatDuration(a.Expires.Sub(now)),
+		})
+	}
+	s.renderTemplate(w, "sessions.html", data)
+}
+
+// handleSessionEnd ends the session or download at
+// /tools/sessions/{id}/end.
+func (s *Server) handleSessionEnd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := apiName(r, "/tools/sessions/", "/end")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	a := s.artifacts.take(id)
+	if a == nil {
+		http.Error(w, "The session has already ended", http.StatusNotFound)
+		return
+	}
+	a.release(errArtifactEnded)
+	log.Printf("Ended %s %s in %s/%s of %s", a.Kind, a.Name, a.Context, a.Namespace, a.Actor)
+	s.redirectBack(w, r, "/tools/sessions")
+}
+
+// artifactName names the object an artifact belongs to, such as
+// "pod/web-1 (app)".
+func artifactName(kind, name, container string) string {
+	if container == "" {
+		return kind + "/" + name
+	}
+	return fmt.Sprintf("%s/%s (%s)", kind, name, container)
+}
@@ -0,0 +1,106 @@
+package web
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestArtifactRegistry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var released []string
+	release := func(name string) func(error) {
+		return func(error) { released = append(released, name) }
+	}
+	var ar artifactRegistry
+	ar.add(&artifact{Name: "old", Created: now.Add(-2 * time.Hour), Expires: now, release: release("old")})
+	removeDone := ar.add(&artifact{Name: "done", Created: now.Add(-time.Hour), Expires: now.Add(-time.Minute), release: release("done")})
+	ar.add(&artifact{Name: "open", Created: now.Add(-time.Minute), Expires: now.Add(time.Hour), release: release("open")})
+
+	removeDone()
+	var names []string
+	for _, a := range ar.list() {
+		names = append(names, a.Name)
+	}
+	if !slices.Equal(names, []string{"old", "open"}) {
+		t.Fatalf("list = %v, want old and open", names)
+	}
+
+	expired := ar.expired(now)
+	if len(expired) != 1 || expired[0].Name != "old" {
+		t.Fatalf("expired = %v, want old", expired)
+	}
+	if got := ar.expired(now); len(got) != 0 {
+		t.Errorf("expired returned %v again", got)
+	}
+
+	id := ar.list()[0].ID
+	if a := ar.take(id); a == nil || a.Name != "open" {
+		t.Fatalf("take(%s) = %v, want open", id, a)
+	}
+	if a := ar.take(id); a != nil {
+		t.Errorf("take returned the same artifact twice")
+	}
+	if len(released) != 0 {
+		t.Errorf("registry released %v itself, want the caller to", released)
+	}
+}
+
+func TestDownloadStopped(t *testing.T) {
+	for _, tt := range []struct {
+		cause error
+		want  error
+	}{
+		{nil, nil},
+		{context.Canceled, nil},
+		{errDownloadExpired, errDownloadExpired},
+		{errArtifactEnded, errArtifactEnded},
+	} {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		if tt.cause != nil {
+			cancel(tt.cause)
+		}
+		r := httptest.NewRequest("GET", "/deployments/web/logs.zip", nil).WithContext(ctx)
+		if got := downloadStopped(r); got != tt.want {
+			t.Errorf("downloadStopped after %v = %v, want %v", tt.cause, got, tt.want)
+		}
+		cancel(nil)
+	}
+}
+
+func TestRemoveStaleTempDirs(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	for _, d := range []struct {
+		name string
+		age  time.Duration
+	}{
+		{"k8s-ui-logs-1", 2 * time.Hour},
+		{"k8s-ui-bundle-2", 2 * time.Hour},
+		{"k8s-ui-logs-3", time.Minute},
+		{"other-4", 2 * time.Hour},
+	} {
+		path := filepath.Join(dir, d.name)
+		if err := os.Mkdir(path, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-d.age), now.Add(-d.age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed := removeStaleTempDirs(dir, now)
+	slices.Sort(removed)
+	if !slices.Equal(removed, []string{"k8s-ui-bundle-2", "k8s-ui-logs-1"}) {
+		t.Errorf("removed = %v, want the old download folders", removed)
+	}
+	for _, name := range []string{"k8s-ui-logs-3", "other-4"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was removed: %v", name, err)
+		}
+	}
+}
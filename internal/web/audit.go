@@ -0,0 +1,73 @@
+package web
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// auditLogSize is how many changes the audit log keeps; older ones are
+// dropped first.
+const auditLogSize = 5000
+
+// AuditEntry is a change made to the cluster through k8s-ui.
+type AuditEntry struct {
+	Time      time.Time
+	Context   string
+	Namespace string
+	Actor     string // who asked for it, such as "web UI" or "API token ci"
+	Kind      string // lower-case kind, such as "deployment"
+	Name      string
+	Action    string // what was done, such as "scaled 3→5"
+}
+
+// auditLog keeps the most recent changes made through k8s-ui in memory.
+type auditLog struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+func (l *auditLog) add(e AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
+	if n := len(l.entries) - auditLogSize; n > 0 {
+		l.entries = append(l.entries[:0], l.entries[n:]...)
+	}
+}
+
+// list returns the changes made in a context and namespace, oldest first.
+func (l *auditLog) list(kubeContext, namespace string) []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []AuditEntry
+	for _, e := range l.entries {
+		if e.Context == kubeContext && e.Namespace == namespace {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// requestActor names who made a request. The UI has no login of its own,
+// so only API tokens identify a caller.
+func requestActor(r *http.Request) string {
+	if name, ok := r.Context().Value(apiTokenKey{}).(string); ok {
+		return "API token " + name
+	}
+	return "web UI"
+}
+
+// audit records a change made in the current context.
+func (s *Server) audit(actor, namespace, kind, name, action string) {
+	_, current := s.manager.Contexts()
+	s.auditLog.add(AuditEntry{
+		Time:      time.Now(),
+		Context:   current,
+		Namespace: namespace,
+		Actor:     actor,
+		Kind:      kind,
+		Name:      name,
+		Action:    action,
+	})
+}
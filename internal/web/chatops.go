@@ -0,0 +1,210 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// slackMaxSkew is how old a signed Slack request may be, as Slack
+// recommends, so a captured request cannot be replayed later.
+const slackMaxSkew = 5 * time.Minute
+
+const slackHelp = "Usage: `status deployment NAME`, `status pod NAME` or `restart deployment NAME`, optionally followed by `-n NAMESPACE`."
+
+// ChatCommand is a parsed slash command such as "restart deployment web -n shop".
+type ChatCommand struct {
+	Verb      string // status or restart
+	Kind      string // deployment or pod
+	Name      string
+	Namespace string // empty for the current namespace
+}
+
+// parseChatCommand parses the text of a slash command. Kinds may be given
+// as kubectl abbreviations, such as deploy or po.
+func parseChatCommand(text string) (ChatCommand, error) {
+	var cmd ChatCommand
+	var args []string
+	fields := strings.Fields(text)
+	for i := 0; i < len(fields); i++ {
+		if fields[i] == "-n" || fields[i] == "--namespace" {
+			if i+1 == len(fields) {
+				return cmd, fmt.Errorf("%s needs a namespace", fields[i])
+			}
+			cmd.Namespace = fields[i+1]
+			i++
+			continue
+		}
+		args = append(args, fields[i])
+	}
+	if len(args) != 3 {
+		return cmd, fmt.Errorf("expected a verb, a kind and a name")
+	}
+
+	cmd.Verb, cmd.Name = strings.ToLower(args[0]), args[2]
+	switch strings.ToLower(args[1]) {
+	case "deployment", "deployments", "deploy":
+		cmd.Kind = "deployment"
+	case "pod", "pods", "po":
+		cmd.Kind = "pod"
+	default:
+		return cmd, fmt.Errorf("unknown kind %q", args[1])
+	}
+	switch {
+	case cmd.Verb == "status":
+	case cmd.Verb == "restart" && cmd.Kind == "deployment":
+	default:
+		return cmd, fmt.Errorf("cannot %s a %s", cmd.Verb, cmd.Kind)
+	}
+	return cmd, nil
+}
+
+// verifySlackSignature checks the X-Slack-Signature of a request body with
+// the app's signing secret.
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	ts := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing request timestamp")
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > slackMaxSkew || d < -slackMaxSkew {
+		return fmt.Errorf("request timestamp is too far from the current time")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(header.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// slackReply answers a slash command. In-channel replies are seen by
+// everyone in the channel, ephemeral ones only by the user.
+func slackReply(w http.ResponseWriter, inChannel bool, text string) {
+	responseType := "ephemeral"
+	if inChannel {
+		responseType = "in_channel"
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]string{"response_type": responseType, "text": text})
+}
+
+// handleSlackCommand serves a Slack slash command. Anyone in the workspace
+// can ask for status; restarts are limited to Config.SlackRestartUsers and
+// refused in production scopes, which need the typed confirmation in the
+// UI. Errors are replied to the user, since Slack shows nothing for a
+// failed request.
+func (s *Server) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := verifySlackSignature(s.config.SlackSigningSecret, r.Header, body, time.Now()); err != nil {
+		http.Error(w, "Invalid Slack request: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "Invalid form: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	text := strings.TrimSpace(form.Get("text"))
+	if text == "" || text == "help" {
+		slackReply(w, false, slackHelp)
+		return
+	}
+	cmd, err := parseChatCommand(text)
+	if err != nil {
+		slackReply(w, false, fmt.Sprintf("%s. %s", err, slackHelp))
+		return
+	}
+	ns := cmd.Namespace
+	if ns == "" {
+		ns = s.manager.Namespace()
+	} else if errs := validation.IsDNS1123Label(ns); len(errs) > 0 || !s.manager.IsNamespaceAllowed(ns) {
+		slackReply(w, false, fmt.Sprintf("Namespace %s is not available.", ns))
+		return
+	}
+
+	if cmd.Verb == "restart" {
+		user := form.Get("user_id")
+		if !slices.Contains(s.config.SlackRestartUsers, user) {
+			slackReply(w, false, "You are not allowed to restart workloads from Slack.")
+			return
+		}
+		if s.isProduction(ns) {
+			slackReply(w, false, fmt.Sprintf("Namespace %s is a production scope; restart %s from the UI.", ns, cmd.Name))
+			return
+		}
+		payload, err := rolloutRestartPatch()
+		if err != nil {
+			slackReply(w, false, err.Error())
+			return
+		}
+		_, err = s.manager.Client().AppsV1().Deployments(ns).Patch(r.Context(), cmd.Name, types.MergePatchType, payload, metav1.PatchOptions{})
+		if err != nil {
+			slackReply(w, false, slackK8sError(err))
+			return
+		}
+		log.Printf("Slack user %s (%s) restarted deployment %s/%s", form.Get("user_name"), user, ns, cmd.Name)
+		s.audit("Slack user "+form.Get("user_name"), ns, "deployment", cmd.Name, "restarted")
+		slackReply(w, true, fmt.Sprintf("<@%s> restarted deployment `%s` in `%s`.", user, cmd.Name, ns))
+		return
+	}
+
+	switch cmd.Kind {
+	case "deployment":
+		d, err := s.manager.Client().AppsV1().Deployments(ns).Get(r.Context(), cmd.Name, metav1.GetOptions{})
+		if err != nil {
+			slackReply(w, false, slackK8sError(err))
+			return
+		}
+		st := apiDeploymentStatus(d)
+		rollout := "in progress"
+		if st.Complete {
+			rollout = "complete"
+		}
+		slackReply(w, false, fmt.Sprintf("*deployment/%s* in `%s`: %d/%d ready, %d updated, %d available, rollout %s\nImages: %s",
+			st.Name, ns, st.Ready, st.Replicas, st.Updated, st.Available, rollout, strings.Join(st.Images, ", ")))
+	case "pod":
+		p, err := s.manager.Client().CoreV1().Pods(ns).Get(r.Context(), cmd.Name, metav1.GetOptions{})
+		if err != nil {
+			slackReply(w, false, slackK8sError(err))
+			return
+		}
+		st := apiPodStatus(p)
+		slackReply(w, false, fmt.Sprintf("*pod/%s* in `%s`: %s, %s ready, %d restarts, age %s, node %s",
+			st.Name, ns, st.Phase, st.Ready, st.Restarts, formatAge(st.Created), st.Node))
+	}
+}
+
+// slackK8sError words a Kubernetes API error for a chat reply.
+func slackK8sError(err error) string {
+	switch {
+	case apierrors.IsNotFound(err):
+		return "Not found: " + err.Error()
+	case apierrors.IsForbidden(err):
+		return "k8s-ui is not allowed to do that: " + err.Error()
+	}
+	return "Failed: " + err.Error()
+}
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseChatCommand(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    ChatCommand
+		wantErr bool
+	}{
+		{text: "status deployment web", want: ChatCommand{Verb: "status", Kind: "deployment", Name: "web"}},
+		{text: "Status deploy web -n shop", want: ChatCommand{Verb: "status", Kind: "deployment", Name: "web", Namespace: "shop"}},
+		{text: "status --namespace shop po web-1", want: ChatCommand{Verb: "status", Kind: "pod", Name: "web-1", Namespace: "shop"}},
+		{text: "restart deployment web", want: ChatCommand{Verb: "restart", Kind: "deployment", Name: "web"}},
+		{text: "restart pod web-1", wantErr: true},
+		{text: "delete deployment web", wantErr: true},
+		{text: "status service web", wantErr: true},
+		{text: "status deployment", wantErr: true},
+		{text: "status deployment web -n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseChatCommand(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseChatCommand(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseChatCommand(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	// The example from Slack's documentation on verifying requests.
+	secret := "8f742231b10e8888abcd99yyyzzz85a5"
+	body := []byte("token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c")
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", "1531420618")
+	header.Set("X-Slack-Signature", "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503")
+	signedAt := time.Unix(1531420618, 0)
+
+	if err := verifySlackSignature(secret, header, body, signedAt.Add(time.Minute)); err != nil {
+		t.Errorf("valid request: %v", err)
+	}
+	if err := verifySlackSignature(secret, header, body, signedAt.Add(10*time.Minute)); err == nil {
+		t.Error("replayed request was accepted")
+	}
+	if err := verifySlackSignature("other-secret", header, body, signedAt); err == nil {
+		t.Error("request signed with another secret was accepted")
+	}
+	if err := verifySlackSignature(secret, header, append(body, '1'), signedAt); err == nil {
+		t.Error("modified body was accepted")
+	}
+}
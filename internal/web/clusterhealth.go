@@ -0,0 +1,219 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Health states of a core component.
+const (
+	healthOK       = "Healthy"
+	healthDegraded = "Degraded"
+	healthDown     = "Down"
+	healthUnknown  = "Unknown"
+)
+
+// cniDaemonSets are name fragments of the DaemonSets of common network
+// plugins, to tell them apart from other DaemonSets in kube-system.
+var cniDaemonSets = []string{"calico", "cilium", "flannel", "weave", "aws-node", "kindnet", "antrea", "canal", "azure-cni", "kube-router", "ovn", "multus"}
+
+// leaderLeases are the Leases in kube-system held by the control plane
+// components that elect a leader.
+var leaderLeases = []struct {
+	Component string
+	Name      string
+}{
+	{"Controller Manager", "kube-controller-manager"},
+	{"Scheduler", "kube-scheduler"},
+}
+
+// ComponentHealthView is the health of one core component.
+type ComponentHealthView struct {
+	Component string
+	Object    string
+	Status    string
+	Detail    string
+}
+
+type ClusterHealthPage struct {
+	BasePage
+	Components []ComponentHealthView
+	Unhealthy  int
+}
+
+// deploymentHealth rates a Deployment by its ready replicas.
+func deploymentHealth(d *appsv1.Deployment) (string, string) {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	ready := d.Status.ReadyReplicas
+	detail := fmt.Sprintf("%d/%d replicas ready", ready, desired)
+	switch {
+	case desired == 0:
+		return healthDown, "Scaled to 0 replicas"
+	case ready == 0:
+		return healthDown, detail
+	case ready < desired:
+		return healthDegraded, detail
+	}
+	return healthOK, detail
+}
+
+// daemonSetHealth rates a DaemonSet by the pods ready on the nodes it
+// should run on.
+func daemonSetHealth(ds *appsv1.DaemonSet) (string, string) {
+	desired := ds.Status.DesiredNumberScheduled
+	ready := ds.Status.NumberReady
+	detail := fmt.Sprintf("Ready on %d of %d nodes", ready, desired)
+	switch {
+	case desired == 0:
+		return healthUnknown, "Scheduled on no nodes"
+	case ready == 0:
+		return healthDown, detail
+	case ready < desired:
+		return healthDegraded, detail
+	}
+	return healthOK, detail
+}
+
+// leaseHealth rates a leader election Lease: a component is down when its
+// leader has not renewed the Lease within the lease duration.
+func leaseHealth(lease *coordinationv1.Lease, now time.Time) (string, string) {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" || lease.Spec.RenewTime == nil {
+		return healthDown, "No leader holds the lease"
+	}
+	holder := *lease.Spec.HolderIdentity
+	renewed := lease.Spec.RenewTime.Time
+	duration := 15 * time.Second
+	if lease.Spec.LeaseDurationSeconds != nil {
+		duration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	if now.Sub(renewed) > duration {
+		return healthDown, fmt.Sprintf("Leader %s last renewed the lease %s ago, longer than its %s duration", holder, formatDuration(now.Sub(renewed)), formatDuration(duration))
+	}
+	return healthOK, fmt.Sprintf("Leader %s, renewed %s ago", holder, formatDuration(now.Sub(renewed)))
+}
+
+// daemonSetComponent names the component a kube-system DaemonSet is.
+func daemonSetComponent(name string) string {
+	if strings.HasPrefix(name, "kube-proxy") {
+		return "kube-proxy"
+	}
+	for _, cni := range cniDaemonSets {
+		if strings.Contains(name, cni) {
+			return "Network (CNI)"
+		}
+	}
+	return "DaemonSet"
+}
+
+// componentError turns the error of reading a component into its row: its
+// health is unknown, as the error may be missing permissions or a
+// transient API server failure rather than a problem of the component.
+func componentError(component, object, verb, resource string, err error) ComponentHealthView {
+	detail := err.Error()
+	if apierrors.IsForbidden(err) {
+		detail = fmt.Sprintf("The current identity cannot %s %s", verb, resource)
+	}
+	return ComponentHealthView{Component: component, Object: object, Status: healthUnknown, Detail: detail}
+}
+
+// deploymentComponents reads the kube-system Deployments with the given
+// label as the component, or reports that there are none.
+func deploymentComponents(ctx context.Context, client kubernetes.Interface, component, selector, missing string) []ComponentHealthView {
+	list, err := client.AppsV1().Deployments(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return []ComponentHealthView{componentError(component, selector, "list", "deployments in kube-system", err)}
+	}
+	if len(list.Items) == 0 {
+		return []ComponentHealthView{{Component: component, Object: selector, Status: healthUnknown, Detail: missing}}
+	}
+	var out []ComponentHealthView
+	for i := range list.Items {
+		status, detail := deploymentHealth(&list.Items[i])
+		out = append(out, ComponentHealthView{Component: component, Object: "deployment/" + list.Items[i].Name, Status: status, Detail: detail})
+	}
+	return out
+}
+
+// handleClusterHealth shows the health of the core components in
+// kube-system that applications depend on: cluster DNS, metrics-server
+// and the metrics API, the network plugin and kube-proxy DaemonSets, and
+// the leader leases of the controller manager and scheduler. A cluster
+// problem then shows on one page instead of as odd failures of many apps.
+// Components the credentials cannot read are shown as unknown. It is only
+// routed when Config.ClusterHealth is set.
+func (s *Server) handleClusterHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	client := s.manager.Client()
+	data := ClusterHealthPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Cluster Health", Active: "resources"},
+	}
+
+	data.Components = append(data.Components, deploymentComponents(ctx, client, "DNS", "k8s-app=kube-dns",
+		"No Deployment labelled k8s-app=kube-dns; the cluster may run another DNS")...)
+	data.Components = append(data.Components, deploymentComponents(ctx, client, "Metrics Server", "k8s-app=metrics-server",
+		"No Deployment labelled k8s-app=metrics-server; CPU and memory usage and autoscaling need it")...)
+	if _, err := client.Discovery().ServerResourcesForGroupVersion("metrics.k8s.io/v1beta1"); apierrors.IsNotFound(err) {
+		data.Components = append(data.Components, ComponentHealthView{Component: "Metrics API", Object: "metrics.k8s.io/v1beta1", Status: healthDown,
+			Detail: "Not served, so kubectl top and resource-based autoscaling do not work"})
+	} else if err != nil {
+		data.Components = append(data.Components, componentError("Metrics API", "metrics.k8s.io/v1beta1", "discover", "the metrics API", err))
+	} else {
+		data.Components = append(data.Components, ComponentHealthView{Component: "Metrics API", Object: "metrics.k8s.io/v1beta1", Status: healthOK, Detail: "Served"})
+	}
+
+	daemonSets, err := client.AppsV1().DaemonSets(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		data.Components = append(data.Components, componentError("DaemonSets", "kube-system", "list", "daemonsets in kube-system", err))
+	} else {
+		var rows []ComponentHealthView
+		for i := range daemonSets.Items {
+			ds := &daemonSets.Items[i]
+			status, detail := daemonSetHealth(ds)
+			rows = append(rows, ComponentHealthView{Component: daemonSetComponent(ds.Name), Object: "daemonset/" + ds.Name, Status: status, Detail: detail})
+		}
+		// The network plugin first, then kube-proxy, then the others.
+		rank := map[string]int{"Network (CNI)": 0, "kube-proxy": 1, "DaemonSet": 2}
+		sort.SliceStable(rows, func(i, j int) bool { return rank[rows[i].Component] < rank[rows[j].Component] })
+		data.Components = append(data.Components, rows...)
+	}
+
+	now := time.Now()
+	for _, l := range leaderLeases {
+		object := "lease/" + l.Name
+		lease, err := client.CoordinationV1().Leases(metav1.NamespaceSystem).Get(ctx, l.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			data.Components = append(data.Components, ComponentHealthView{Component: l.Component, Object: object, Status: healthUnknown,
+				Detail: "No lease; managed control planes often do not publish it"})
+		case err != nil:
+			data.Components = append(data.Components, componentError(l.Component, object, "get", "leases in kube-system", err))
+		default:
+			status, detail := leaseHealth(lease, now)
+			data.Components = append(data.Components, ComponentHealthView{Component: l.Component, Object: object, Status: status, Detail: detail})
+		}
+	}
+
+	for _, c := range data.Components {
+		if c.Status == healthDegraded || c.Status == healthDown {
+			data.Unhealthy++
+		}
+	}
+	s.renderTemplate(w, "cluster_health.html", data)
+}
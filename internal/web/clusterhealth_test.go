@@ -0,0 +1,83 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestDeploymentHealth(t *testing.T) {
+	tests := []struct {
+		replicas *int32
+		ready    int32
+		want     string
+	}{
+		{ptr.To[int32](2), 2, healthOK},
+		{ptr.To[int32](2), 1, healthDegraded},
+		{ptr.To[int32](2), 0, healthDown},
+		{ptr.To[int32](0), 0, healthDown},
+		{nil, 1, healthOK},
+	}
+	for _, tt := range tests {
+		d := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: tt.replicas}, Status: appsv1.DeploymentStatus{ReadyReplicas: tt.ready}}
+		if got, detail := deploymentHealth(d); got != tt.want {
+			t.Errorf("deploymentHealth(%v replicas, %d ready) = %s (%s), want %s", tt.replicas, tt.ready, got, detail, tt.want)
+		}
+	}
+}
+
+func TestDaemonSetHealth(t *testing.T) {
+	tests := []struct {
+		desired, ready int32
+		want           string
+	}{
+		{3, 3, healthOK},
+		{3, 2, healthDegraded},
+		{3, 0, healthDown},
+		{0, 0, healthUnknown},
+	}
+	for _, tt := range tests {
+		ds := &appsv1.DaemonSet{Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: tt.desired, NumberReady: tt.ready}}
+		if got, detail := daemonSetHealth(ds); got != tt.want {
+			t.Errorf("daemonSetHealth(%d/%d) = %s (%s), want %s", tt.ready, tt.desired, got, detail, tt.want)
+		}
+	}
+}
+
+func TestLeaseHealth(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	lease := func(holder string, renewed time.Duration) *coordinationv1.Lease {
+		return &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To(holder),
+			LeaseDurationSeconds: ptr.To[int32](15),
+			RenewTime:            &metav1.MicroTime{Time: now.Add(-renewed)},
+		}}
+	}
+	if got, detail := leaseHealth(lease("cp-1_abc", 2*time.Second), now); got != healthOK {
+		t.Errorf("fresh lease = %s (%s), want %s", got, detail, healthOK)
+	}
+	if got, detail := leaseHealth(lease("cp-1_abc", time.Minute), now); got != healthDown {
+		t.Errorf("stale lease = %s (%s), want %s", got, detail, healthDown)
+	}
+	if got, _ := leaseHealth(lease("", time.Second), now); got != healthDown {
+		t.Errorf("lease without holder = %s, want %s", got, healthDown)
+	}
+}
+
+func TestDaemonSetComponent(t *testing.T) {
+	for name, want := range map[string]string{
+		"kube-proxy":      "kube-proxy",
+		"calico-node":     "Network (CNI)",
+		"aws-node":        "Network (CNI)",
+		"kube-flannel-ds": "Network (CNI)",
+		"ebs-csi-node":    "DaemonSet",
+	} {
+		if got := daemonSetComponent(name); got != want {
+			t.Errorf("daemonSetComponent(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
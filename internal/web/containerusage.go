@@ -0,0 +1,96 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podMetricsResource is the metrics-server API for the current usage of a
+// pod's containers.
+var podMetricsResource = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
+// podMetrics is the part of a metrics.k8s.io PodMetrics object the pod page
+// uses.
+type podMetrics struct {
+	Timestamp  metav1.Time `json:"timestamp"`
+	Containers []struct {
+		Name  string              `json:"name"`
+		Usage corev1.ResourceList `json:"usage"`
+	} `json:"containers"`
+}
+
+// ContainerUsageView compares a container's current CPU and memory usage
+// with its limits.
+type ContainerUsageView struct {
+	Name        string
+	CPU         string
+	CPULimit    string
+	CPUBar      PercentBar
+	Memory      string
+	MemoryLimit string
+	MemoryBar   PercentBar
+	// OOMRisk is set when memory use is at or above percentBarError percent
+	// of the limit, close to where the container is OOM killed.
+	OOMRisk bool
+}
+
+// containerUsage pairs the usage metrics-server reports for each running
+// container of a pod with the limits of its spec. Containers without a limit
+// get no bar, as they can use what the node has.
+func containerUsage(pod *corev1.Pod, m *podMetrics) []ContainerUsageView {
+	limits := make(map[string]corev1.ResourceList)
+	for _, c := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		limits[c.Name] = c.Resources.Limits
+	}
+
+	var views []ContainerUsageView
+	for _, c := range m.Containers {
+		limit := limits[c.Name]
+		cpu := c.Usage[corev1.ResourceCPU]
+		memory := c.Usage[corev1.ResourceMemory]
+		view := ContainerUsageView{
+			Name:        c.Name,
+			CPU:         fmt.Sprintf("%dm", cpu.MilliValue()),
+			CPULimit:    "-",
+			CPUBar:      PercentBar{Class: "status-neutral", Label: "no limit"},
+			Memory:      formatBytes(memory),
+			MemoryLimit: "-",
+			MemoryBar:   PercentBar{Class: "status-neutral", Label: "no limit"},
+		}
+		if q, ok := limit[corev1.ResourceCPU]; ok && !q.IsZero() {
+			view.CPULimit = q.String()
+			view.CPUBar = percentBar(cpu, q)
+		}
+		if q, ok := limit[corev1.ResourceMemory]; ok && !q.IsZero() {
+			view.MemoryLimit = formatBytes(q)
+			view.MemoryBar = percentBar(memory, q)
+			view.OOMRisk = view.MemoryBar.Percent >= percentBarError
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// podUsage reads a pod's current container usage from metrics-server. It
+// fails when metrics-server is not installed or has not sampled the pod yet.
+func (s *Server) podUsage(ctx context.Context, pod *corev1.Pod) ([]ContainerUsageView, time.Time, error) {
+	dyn, err := s.newDynamicClient()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	u, err := dyn.Resource(podMetricsResource).Namespace(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var m podMetrics
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &m); err != nil {
+		return nil, time.Time{}, err
+	}
+	return containerUsage(pod, &m), m.Timestamp.Time, nil
+}
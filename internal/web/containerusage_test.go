@@ -0,0 +1,63 @@
+package web
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestContainerUsage(t *testing.T) {
+	// A PodMetrics object as the dynamic client returns it.
+	obj := map[string]any{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "PodMetrics",
+		"timestamp":  "2024-05-01T10:00:00Z",
+		"containers": []any{
+			map[string]any{"name": "app", "usage": map[string]any{"cpu": "250000000n", "memory": "950Mi"}},
+			map[string]any{"name": "proxy", "usage": map[string]any{"cpu": "5m", "memory": "20Mi"}},
+			map[string]any{"name": "logs", "usage": map[string]any{"cpu": "1m", "memory": "8Mi"}},
+		},
+	}
+	var m podMetrics
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	limits := func(cpu, memory string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}}
+	}
+	always := corev1.ContainerRestartPolicyAlways
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "logs", RestartPolicy: &always, Resources: limits("100m", "64Mi")}},
+		Containers: []corev1.Container{
+			{Name: "app", Resources: limits("1", "1Gi")},
+			{Name: "proxy"},
+		},
+	}}
+
+	got := containerUsage(pod, &m)
+	if len(got) != 3 {
+		t.Fatalf("containerUsage() = %+v, want 3 containers", got)
+	}
+	app, proxy, logs := got[0], got[1], got[2]
+	if app.CPU != "250m" || app.CPULimit != "1" || app.CPUBar.Percent != 25 {
+		t.Errorf("app CPU = %s of %s (%d%%), want 250m of 1 (25%%)", app.CPU, app.CPULimit, app.CPUBar.Percent)
+	}
+	if app.MemoryBar.Percent != 93 || !app.OOMRisk {
+		t.Errorf("app memory = %d%%, OOM risk %v, want 93%% and a risk", app.MemoryBar.Percent, app.OOMRisk)
+	}
+	if proxy.CPULimit != "-" || proxy.MemoryBar.Label != "no limit" || proxy.OOMRisk {
+		t.Errorf("proxy without limits = %+v", proxy)
+	}
+	if logs.MemoryBar.Percent != 13 || logs.OOMRisk {
+		t.Errorf("logs sidecar memory = %d%%, OOM risk %v, want 13%% and no risk", logs.MemoryBar.Percent, logs.OOMRisk)
+	}
+	if m.Timestamp.IsZero() {
+		t.Error("the sample time was not decoded")
+	}
+}
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/kubectl/pkg/describe"
+)
+
+// DescribePage shows a resource the way kubectl describe prints it.
+type DescribePage struct {
+	BasePage
+	Resource string
+	Name     string
+	Output   string
+	YAMLURL  string
+	BackURL  string
+}
+
+// describeYAMLURL returns the single YAML view of a resource described as
+// ?resource=&name=.
+func describeYAMLURL(resource, name string) string {
+	if _, ok := batchResources[resource]; ok {
+		return "/" + resource + "/" + name + "/yaml"
+	}
+	return "/crds/" + resource + "/" + name + "/yaml"
+}
+
+// handleDescribe renders ?resource=&name= with kubectl's own describers, so
+// the output, including the recent events at the end, matches what
+// kubectl describe prints. resource takes the same values as the batch
+// YAML view.
+func (s *Server) handleDescribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resource := r.URL.Query().Get("resource")
+	name := r.URL.Query().Get("name")
+	gvr, backURL, active, ok := batchResource(resource)
+	if !ok || (resource == "replicationcontrollers" && !s.config.ReplicationControllers) {
+		http.Error(w, fmt.Sprintf("Unknown resource %q", resource), http.StatusBadRequest)
+		return
+	}
+	if name == "" {
+		http.Redirect(w, r, backURL, http.StatusFound)
+		return
+	}
+
+	cfg, err := s.manager.RESTConfig()
+	if err != nil {
+		http.Error(w, "failed to get Kubernetes config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	disco, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		http.Error(w, "failed to create discovery client: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	groups, err := restmapper.GetAPIGroupResources(disco)
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		if s.handleK8sForbidden(w, r, err, "discover", "API resources", "", backURL, active) {
+			return
+		}
+		http.Error(w, "API discovery failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groups)
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("The API server does not serve %s.", gvr.GroupResource()), http.StatusNotFound)
+		return
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	describer, ok := describe.DescriberFor(gvk.GroupKind(), cfg)
+	if !ok {
+		describer, ok = describe.GenericDescriberFor(mapping, cfg)
+	}
+	if !ok {
+		http.Error(w, fmt.Sprintf("%s cannot be described", gvk.Kind), http.StatusBadRequest)
+		return
+	}
+	out, err := describer.Describe(s.namespace(r), name, describe.DescriberSettings{ShowEvents: true, ChunkSize: 500})
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "get", gvr.GroupResource().String(), name, backURL, active) {
+			return
+		}
+		if apierrors.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(out))
+		return
+	}
+	s.renderTemplate(w, "describe.html", DescribePage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Describe: " + name, Active: active},
+		Resource: resource,
+		Name:     name,
+		Output:   out,
+		YAMLURL:  describeYAMLURL(resource, name),
+		BackURL:  backURL,
+	})
+}
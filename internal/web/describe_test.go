@@ -0,0 +1,19 @@
+package web
+
+import "testing"
+
+func TestDescribeYAMLURL(t *testing.T) {
+	tests := []struct {
+		resource, name, want string
+	}{
+		{"pods", "web-1", "/pods/web-1/yaml"},
+		{"pvcs", "data", "/pvcs/data/yaml"},
+		{"example.com/v1/widgets", "w", "/crds/example.com/v1/widgets/w/yaml"},
+	}
+
+	for _, tt := range tests {
+		if got := describeYAMLURL(tt.resource, tt.name); got != tt.want {
+			t.Errorf("describeYAMLURL(%q, %q) = %q, want %q", tt.resource, tt.name, got, tt.want)
+		}
+	}
+}
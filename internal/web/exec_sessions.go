@@ -0,0 +1,45 @@
+package web
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// execSessions counts the open exec terminals so the server can enforce
+// Config.MaxExecSessions.
+type execSessions struct {
+	mu     sync.Mutex
+	active int
+}
+
+// acquire reserves a session slot. A zero max means no limit.
+func (e *execSessions) acquire(max int) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if max > 0 && e.active >= max {
+		return false
+	}
+	e.active++
+	return true
+}
+
+func (e *execSessions) release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.active--
+}
+
+func (e *execSessions) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.active
+}
+
+// closeTerminal sends a WebSocket close frame so the browser reports the
+// session as disconnected once the server ends it.
+func closeTerminal(conn *websocket.Conn) {
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(10*time.Second))
+}
@@ -0,0 +1,21 @@
+package web
+
+import "testing"
+
+func TestExecSessionsLimit(t *testing.T) {
+	var e execSessions
+
+	if !e.acquire(2) || !e.acquire(2) {
+		t.Fatal("acquire under the limit failed")
+	}
+	if e.acquire(2) {
+		t.Error("acquire over the limit succeeded")
+	}
+	e.release()
+	if !e.acquire(2) {
+		t.Error("acquire after release failed")
+	}
+	if !e.acquire(0) {
+		t.Error("acquire with no limit failed")
+	}
+}
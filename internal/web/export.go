@@ -0,0 +1,215 @@
+package web
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// YAMLPage is the data of the YAML views. BackURL and ResourceID are only
+// used by the custom resource view.
+type YAMLPage struct {
+	BasePage
+	Name       string
+	Kind       string
+	YAML       string
+	Path       string
+	Export     bool
+	BackURL    string
+	ResourceID string
+	// DescribeURL is set for custom resources, which the view cannot
+	// derive from Kind alone.
+	DescribeURL string
+}
+
+// exportedMetadata lists the metadata fields the API server sets, which
+// must not be in a manifest that is applied again.
+var exportedMetadata = []string{
+	"uid", "resourceVersion", "creationTimestamp", "generation", "managedFields",
+	"selfLink", "ownerReferences", "deletionTimestamp", "deletionGracePeriodSeconds",
+}
+
+// exportedAnnotations are annotations written by kubectl and controllers.
+var exportedAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/selected-node",
+}
+
+// exportedJobLabels are the labels the Job controller adds to the pod
+// template and selector of every Job.
+var exportedJobLabels = []string{
+	"controller-uid", "batch.kubernetes.io/controller-uid",
+	"job-name", "batch.kubernetes.io/job-name",
+}
+
+// renderYAML shows obj on a YAML view. With ?export=1 the object is shown as
+// a manifest ready to re-apply, and ?export=download sends that manifest as
+// a file.
+func (s *Server) renderYAML(w http.ResponseWriter, r *http.Request, tmpl string, obj runtime.Object, data YAMLPage) {
+	export := r.URL.Query().Get("export")
+	var content any = obj
+	if export != "" {
+		manifest, err := exportManifest(obj)
+		if err != nil {
+			http.Error(w, "failed to export manifest: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		content = manifest
+	} else if o, ok := obj.(metav1.Object); ok {
+		o.SetManagedFields(nil)
+	}
+
+	y, err := yaml.Marshal(content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if export == "download" {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+data.Name+".yaml\"")
+		w.Write(y)
+		return
+	}
+
+	data.YAML = string(y)
+	data.Path = r.URL.Path
+	data.Export = export != ""
+	s.renderTemplate(w, tmpl, data)
+}
+
+// exportManifest converts obj to a manifest that can be committed to Git and
+// applied to another cluster: status, server-set metadata, values assigned
+// by the cluster and fields added by controllers are removed.
+func exportManifest(obj runtime.Object) (map[string]any, error) {
+	var m map[string]any
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		m = u.DeepCopy().Object
+	} else {
+		var err error
+		if m, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, err
+		}
+		// Typed clients drop apiVersion and kind from the objects they return.
+		if gvks, _, err := scheme.Scheme.ObjectKinds(obj); err == nil && len(gvks) > 0 {
+			m["apiVersion"], m["kind"] = gvks[0].GroupVersion().String(), gvks[0].Kind
+		}
+	}
+
+	delete(m, "status")
+	for _, f := range exportedMetadata {
+		unstructured.RemoveNestedField(m, "metadata", f)
+	}
+	for _, a := range exportedAnnotations {
+		unstructured.RemoveNestedField(m, "metadata", "annotations", a)
+	}
+	if annotations, _, _ := unstructured.NestedMap(m, "metadata", "annotations"); len(annotations) == 0 {
+		unstructured.RemoveNestedField(m, "metadata", "annotations")
+	}
+
+	switch m["kind"] {
+	case "Pod":
+		unstructured.RemoveNestedField(m, "spec", "nodeName")
+		removeServiceAccountVolume(m)
+	case "Service":
+		// A headless service must keep clusterIP: None.
+		if ip, _, _ := unstructured.NestedString(m, "spec", "clusterIP"); ip != "None" {
+			unstructured.RemoveNestedField(m, "spec", "clusterIP")
+			unstructured.RemoveNestedField(m, "spec", "clusterIPs")
+		}
+	case "PersistentVolumeClaim":
+		unstructured.RemoveNestedField(m, "spec", "volumeName")
+	case "Job":
+		if manual, _, _ := unstructured.NestedBool(m, "spec", "manualSelector"); !manual {
+			unstructured.RemoveNestedField(m, "spec", "selector")
+			for _, l := range exportedJobLabels {
+				unstructured.RemoveNestedField(m, "spec", "template", "metadata", "labels", l)
+			}
+		}
+	}
+
+	removeNullTimestamps(m)
+	return m, nil
+}
+
+// removeServiceAccountVolume drops the kube-api-access volume and mounts the
+// service account admission plugin injects into every pod.
+func removeServiceAccountVolume(m map[string]any) {
+	volumes, _, _ := unstructured.NestedSlice(m, "spec", "volumes")
+	var injected []string
+	kept := volumes[:0]
+	for _, v := range volumes {
+		name, _, _ := unstructured.NestedString(v.(map[string]any), "name")
+		if strings.HasPrefix(name, "kube-api-access-") {
+			injected = append(injected, name)
+			continue
+		}
+		kept = append(kept, v)
+	}
+	if len(injected) == 0 {
+		return
+	}
+	if len(kept) == 0 {
+		unstructured.RemoveNestedField(m, "spec", "volumes")
+	} else {
+		unstructured.SetNestedSlice(m, kept, "spec", "volumes")
+	}
+
+	for _, list := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(m, "spec", list)
+		for _, c := range containers {
+			cm := c.(map[string]any)
+			mounts, _, _ := unstructured.NestedSlice(cm, "volumeMounts")
+			keptMounts := mounts[:0]
+			for _, vm := range mounts {
+				name, _, _ := unstructured.NestedString(vm.(map[string]any), "name")
+				if !slices.Contains(injected, name) {
+					keptMounts = append(keptMounts, vm)
+				}
+			}
+			if len(keptMounts) == 0 {
+				delete(cm, "volumeMounts")
+			} else {
+				cm["volumeMounts"] = keptMounts
+			}
+		}
+		if containers != nil {
+			unstructured.SetNestedSlice(m, containers, "spec", list)
+		}
+	}
+}
+
+// removeNullTimestamps drops the creationTimestamp: null that typed objects
+// carry in embedded templates.
+func removeNullTimestamps(m map[string]any) {
+	for k, v := range m {
+		switch v := v.(type) {
+		case nil:
+			if k == "creationTimestamp" {
+				delete(m, k)
+			}
+		case map[string]any:
+			removeNullTimestamps(v)
+			if k == "metadata" && len(v) == 0 {
+				delete(m, k)
+			}
+		case []any:
+			for _, item := range v {
+				if im, ok := item.(map[string]any); ok {
+					removeNullTimestamps(im)
+				}
+			}
+		}
+	}
+}
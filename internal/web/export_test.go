@@ -0,0 +1,123 @@
+package web
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+func TestExportManifest(t *testing.T) {
+	meta := metav1.ObjectMeta{
+		Name:              "web",
+		Namespace:         "shop",
+		UID:               "1234",
+		ResourceVersion:   "99",
+		Generation:        3,
+		CreationTimestamp: metav1.NewTime(time.Now()),
+		Labels:            map[string]string{"app": "web"},
+		Annotations: map[string]string{
+			"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			"deployment.kubernetes.io/revision":                "4",
+		},
+		ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+	}
+	one := int32(1)
+
+	tests := []struct {
+		name    string
+		obj     runtime.Object
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "deployment",
+			obj: &appsv1.Deployment{
+				ObjectMeta: meta,
+				Spec:       appsv1.DeploymentSpec{Replicas: &one, Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "web:1"}}}}},
+				Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+			},
+			want:    []string{"apiVersion: apps/v1", "kind: Deployment", "name: web", "namespace: shop", "app: web", "image: web:1"},
+			notWant: []string{"status", "uid", "resourceVersion", "generation", "creationTimestamp", "managedFields", "annotations", "revision"},
+		},
+		{
+			name: "service keeps headless clusterIP",
+			obj:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "db"}, Spec: corev1.ServiceSpec{ClusterIP: "None", ClusterIPs: []string{"None"}}},
+			want: []string{"clusterIP: None"},
+		},
+		{
+			name:    "service drops assigned clusterIP",
+			obj:     &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web"}, Spec: corev1.ServiceSpec{ClusterIP: "10.0.0.7", ClusterIPs: []string{"10.0.0.7"}}},
+			notWant: []string{"10.0.0.7", "clusterIP"},
+		},
+		{
+			name: "pod drops node and service account volume",
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-1"},
+				Spec: corev1.PodSpec{
+					NodeName: "node-a",
+					Volumes:  []corev1.Volume{{Name: "data"}, {Name: "kube-api-access-x7k2p"}},
+					Containers: []corev1.Container{{Name: "app", VolumeMounts: []corev1.VolumeMount{
+						{Name: "data", MountPath: "/data"},
+						{Name: "kube-api-access-x7k2p", MountPath: "/var/run/secrets/kubernetes.io/serviceaccount"},
+					}}},
+				},
+			},
+			want:    []string{"name: data", "mountPath: /data"},
+			notWant: []string{"nodeName", "kube-api-access", "serviceaccount"},
+		},
+		{
+			name: "job drops generated selector",
+			obj: &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "migrate"},
+				Spec: batchv1.JobSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"batch.kubernetes.io/controller-uid": "abc"}},
+					Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+						"batch.kubernetes.io/controller-uid": "abc", "job-name": "migrate", "team": "data",
+					}}},
+				},
+			},
+			want:    []string{"team: data"},
+			notWant: []string{"selector", "controller-uid", "job-name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := exportManifest(tt.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			y, err := yaml.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := string(y)
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("manifest is missing %q:\n%s", w, out)
+				}
+			}
+			for _, nw := range tt.notWant {
+				if strings.Contains(out, nw) {
+					t.Errorf("manifest still contains %q:\n%s", nw, out)
+				}
+			}
+		})
+	}
+
+	// The exported object must not be modified.
+	d := &appsv1.Deployment{ObjectMeta: meta}
+	if _, err := exportManifest(d); err != nil {
+		t.Fatal(err)
+	}
+	if d.UID == "" || len(d.ManagedFields) == 0 {
+		t.Error("exportManifest modified its input")
+	}
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// PercentBar is a usage bar computed on the server, rendered by the
+// "percent_bar" template.
+type PercentBar struct {
+	Percent int    // rounded, may exceed 100 when over the limit
+	Width   int    // bar width in percent, capped at 100
+	Class   string // status class for the fill colour
+	Label   string
+}
+
+// percentBarWarning and percentBarError are the usage levels at which a bar
+// turns amber and red.
+const (
+	percentBarWarning = 75
+	percentBarError   = 90
+)
+
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// formatBytes renders a byte count, such as a memory or storage quantity,
+// in binary units with at most one decimal: "1.5 GiB".
+func formatBytes(v any) string {
+	f, ok := toFloat(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	unit := 0
+	for math.Abs(f) >= 1024 && unit < len(byteUnits)-1 {
+		f /= 1024
+		unit++
+	}
+	if unit == 0 || f == math.Trunc(f) {
+		return fmt.Sprintf("%.0f %s", f, byteUnits[unit])
+	}
+	return fmt.Sprintf("%.1f %s", f, byteUnits[unit])
+}
+
+// percentBar returns a bar for used out of total. Quantities are compared by
+// value, so CPU "500m" of "2" is 25%. A zero or unknown total gives no bar.
+func percentBar(used, total any) PercentBar {
+	u, uok := toFloat(used)
+	t, tok := toFloat(total)
+	if !uok || !tok || t <= 0 {
+		return PercentBar{Class: "status-neutral", Label: "-"}
+	}
+	pct := int(math.Round(u / t * 100))
+	bar := PercentBar{Percent: pct, Width: min(max(pct, 0), 100), Class: "status-success", Label: strconv.Itoa(pct) + "%"}
+	switch {
+	case pct >= percentBarError:
+		bar.Class = "status-error"
+	case pct >= percentBarWarning:
+		bar.Class = "status-warning"
+	}
+	return bar
+}
+
+// toFloat converts the numeric values templates pass to the helpers.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case resource.Quantity:
+		return n.AsApproximateFloat64(), true
+	case *resource.Quantity:
+		if n == nil {
+			return 0, false
+		}
+		return n.AsApproximateFloat64(), true
+	case string:
+		q, err := resource.ParseQuantity(n)
+		if err != nil {
+			return 0, false
+		}
+		return q.AsApproximateFloat64(), true
+	}
+	return 0, false
+}
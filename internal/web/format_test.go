@@ -0,0 +1,43 @@
+package web
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestFormatHelpers(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"bytes", formatBytes(int64(512)), "512 B"},
+		{"bytes quantity", formatBytes(resource.MustParse("1536Mi")), "1.5 GiB"},
+		{"bytes decimal quantity", formatBytes(resource.MustParse("1G")), "953.7 MiB"},
+		{"bytes string", formatBytes("10Gi"), "10 GiB"},
+		{"bytes not a number", formatBytes("lots"), "lots"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestPercentBar(t *testing.T) {
+	tests := []struct {
+		used, total any
+		want        PercentBar
+	}{
+		{int64(1), int64(4), PercentBar{Percent: 25, Width: 25, Class: "status-success", Label: "25%"}},
+		{resource.MustParse("1500m"), resource.MustParse("2"), PercentBar{Percent: 75, Width: 75, Class: "status-warning", Label: "75%"}},
+		{"3Gi", "2Gi", PercentBar{Percent: 150, Width: 100, Class: "status-error", Label: "150%"}},
+		{1, 0, PercentBar{Class: "status-neutral", Label: "-"}},
+	}
+	for _, tt := range tests {
+		if got := percentBar(tt.used, tt.total); got != tt.want {
+			t.Errorf("percentBar(%v, %v) = %+v, want %+v", tt.used, tt.total, got, tt.want)
+		}
+	}
+}
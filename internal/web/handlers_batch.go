@@ -0,0 +1,155 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// maxBatchYAML caps how many resources one batch YAML view fetches.
+const maxBatchYAML = 200
+
+// batchResources maps the list pages that offer batch YAML to their API
+// resources. Custom resources are addressed as group/version/resource.
+var batchResources = map[string]schema.GroupVersionResource{
+	"pods":                   {Version: "v1", Resource: "pods"},
+	"deployments":            {Group: "apps", Version: "v1", Resource: "deployments"},
+	"statefulsets":           {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	"replicationcontrollers": {Version: "v1", Resource: "replicationcontrollers"},
+	"jobs":                   {Group: "batch", Version: "v1", Resource: "jobs"},
+	"cronjobs":               {Group: "batch", Version: "v1", Resource: "cronjobs"},
+	"configmaps":             {Version: "v1", Resource: "configmaps"},
+	"secrets":                {Version: "v1", Resource: "secrets"},
+	"services":               {Version: "v1", Resource: "services"},
+	"ingresses":              {Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+	"pvcs":                   {Version: "v1", Resource: "persistentvolumeclaims"},
+}
+
+type BatchYAMLPage struct {
+	BasePage
+	Resource    string
+	Names       []string
+	YAML        string
+	Export      bool
+	Errors      []string
+	BackURL     string
+	YAMLURL     string
+	ExportURL   string
+	DownloadURL string
+}
+
+// handleBatchYAML renders the selected resources of one list page as a
+// multi-document YAML stream. ?export=1 cleans each document the way the
+// single YAML view's export does, and ?export=download sends the stream as
+// a file.
+func (s *Server) handleBatchYAML(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resource := r.URL.Query().Get("resource")
+	gvr, backURL, active, ok := batchResource(resource)
+	if !ok || (resource == "replicationcontrollers" && !s.config.ReplicationControllers) {
+		http.Error(w, fmt.Sprintf("Unknown resource %q", resource), http.StatusBadRequest)
+		return
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, n := range r.URL.Query()["name"] {
+		if n = strings.TrimSpace(n); n != "" && !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		http.Redirect(w, r, backURL, http.StatusFound)
+		return
+	}
+	if len(names) > maxBatchYAML {
+		http.Error(w, fmt.Sprintf("At most %d resources can be selected at once", maxBatchYAML), http.StatusBadRequest)
+		return
+	}
+
+	dc, err := s.newDynamicClient()
+	if err != nil {
+		http.Error(w, "failed to create dynamic client: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	export := r.URL.Query().Get("export")
+	data := BatchYAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + resource, Active: active},
+		Resource: resource,
+		Names:    names,
+		Export:   export != "",
+		BackURL:  backURL,
+	}
+	var docs []string
+	for _, name := range names {
+		obj, err := dc.Resource(gvr).Namespace(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
+		if err != nil {
+			if s.handleK8sUnauthorized(w, r, err, backURL, active) {
+				return
+			}
+			data.Errors = append(data.Errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		var content any = obj.Object
+		if data.Export {
+			if content, err = exportManifest(obj); err != nil {
+				data.Errors = append(data.Errors, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+		} else {
+			obj.SetManagedFields(nil)
+		}
+		y, err := yaml.Marshal(content)
+		if err != nil {
+			data.Errors = append(data.Errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		docs = append(docs, string(y))
+	}
+	data.YAML = strings.Join(docs, "---\n")
+
+	if export == "download" {
+		// Failures are kept in the file so a capture shows what is missing.
+		var header strings.Builder
+		for _, e := range data.Errors {
+			header.WriteString("# Not included: " + e + "\n")
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+gvr.Resource+".yaml\"")
+		w.Write([]byte(header.String() + data.YAML))
+		return
+	}
+
+	v := url.Values{"resource": {resource}, "name": names}
+	data.YAMLURL = "/yaml?" + v.Encode()
+	v.Set("export", "1")
+	data.ExportURL = "/yaml?" + v.Encode()
+	v.Set("export", "download")
+	data.DownloadURL = "/yaml?" + v.Encode()
+
+	s.renderTemplate(w, "batch_yaml.html", data)
+}
+
+// batchResource resolves the resource parameter of the batch YAML view to
+// its API resource, the list it was selected on and the active menu.
+func batchResource(resource string) (schema.GroupVersionResource, string, string, bool) {
+	if gvr, ok := batchResources[resource]; ok {
+		return gvr, "/" + resource, resource, true
+	}
+	parts := strings.Split(resource, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" || isBuiltInAPIGroup(parts[0]) {
+		return schema.GroupVersionResource{}, "", "", false
+	}
+	return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, "/crds/" + resource, "resources", true
+}
@@ -0,0 +1,32 @@
+package web
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestBatchResource(t *testing.T) {
+	tests := []struct {
+		resource string
+		want     schema.GroupVersionResource
+		back     string
+		ok       bool
+	}{
+		{resource: "pvcs", want: schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, back: "/pvcs", ok: true},
+		{resource: "deployments", want: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, back: "/deployments", ok: true},
+		{resource: "cert-manager.io/v1/certificates", want: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}, back: "/crds/cert-manager.io/v1/certificates", ok: true},
+		// Built-in groups are only reachable through their own list pages.
+		{resource: "rbac.authorization.k8s.io/v1/roles"},
+		{resource: "nodes"},
+		{resource: "example.com/v1"},
+		{resource: ""},
+	}
+
+	for _, tt := range tests {
+		gvr, back, _, ok := batchResource(tt.resource)
+		if ok != tt.ok || gvr != tt.want || back != tt.back {
+			t.Errorf("batchResource(%q) = %v, %q, %v; want %v, %q, %v", tt.resource, gvr, back, ok, tt.want, tt.back, tt.ok)
+		}
+	}
+}
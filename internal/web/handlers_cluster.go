@@ -0,0 +1,163 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// maxKubeletSkew is the number of minor versions a kubelet may lag behind
+// the API server under the Kubernetes version skew policy (since 1.28).
+const maxKubeletSkew = 3
+
+// removedAPIVersions lists beta API versions that have been removed from
+// Kubernetes, with the release that removed them. A cluster still serving
+// one must migrate manifests before upgrading past that release.
+var removedAPIVersions = map[string]string{
+	"extensions/v1beta1":                   "1.22",
+	"networking.k8s.io/v1beta1":            "1.22",
+	"admissionregistration.k8s.io/v1beta1": "1.22",
+	"apiextensions.k8s.io/v1beta1":         "1.22",
+	"apiregistration.k8s.io/v1beta1":       "1.22",
+	"authentication.k8s.io/v1beta1":        "1.22",
+	"authorization.k8s.io/v1beta1":         "1.22",
+	"certificates.k8s.io/v1beta1":          "1.22",
+	"coordination.k8s.io/v1beta1":          "1.22",
+	"rbac.authorization.k8s.io/v1beta1":    "1.22",
+	"scheduling.k8s.io/v1beta1":            "1.22",
+	"batch/v1beta1":                        "1.25",
+	"discovery.k8s.io/v1beta1":             "1.25",
+	"events.k8s.io/v1beta1":                "1.25",
+	"node.k8s.io/v1beta1":                  "1.25",
+	"policy/v1beta1":                       "1.25",
+	"autoscaling/v2beta1":                  "1.25",
+	"autoscaling/v2beta2":                  "1.26",
+	"storage.k8s.io/v1beta1":               "1.27",
+	"flowcontrol.apiserver.k8s.io/v1beta1": "1.26",
+	"flowcontrol.apiserver.k8s.io/v1beta2": "1.29",
+	"flowcontrol.apiserver.k8s.io/v1beta3": "1.32",
+}
+
+// NodeVersionView is a node's kubelet version and its skew from the API server.
+type NodeVersionView struct {
+	Name    string
+	Kubelet string
+	Runtime string
+	Skew    string
+	OK      bool
+}
+
+// DeprecatedAPIView is a served API version that a later release removes.
+type DeprecatedAPIView struct {
+	GroupVersion string
+	RemovedIn    string
+}
+
+type ClusterVersionsPage struct {
+	BasePage
+	ServerVersion string
+	Platform      string
+	Nodes         []NodeVersionView
+	SkewedNodes   int
+	Deprecated    []DeprecatedAPIView
+	NodesWarning  string
+	GroupsWarning string
+}
+
+func (s *Server) handleClusterVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	client := s.manager.Client()
+	info, err := client.Discovery().ServerVersion()
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "get", "version", "", "/resources", "resources") {
+			return
+		}
+		http.Error(w, "failed to get server version: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := ClusterVersionsPage{
+		BasePage:      BasePage{Namespace: s.namespace(r), Title: "Cluster Versions", Active: "resources"},
+		ServerVersion: info.GitVersion,
+		Platform:      info.Platform,
+	}
+	server, _ := version.ParseGeneric(info.GitVersion)
+
+	nodes, err := client.CoreV1().Nodes().List(r.Context(), metav1.ListOptions{})
+	switch {
+	case err == nil:
+		for _, n := range nodes.Items {
+			skew, ok := kubeletSkew(server, n.Status.NodeInfo.KubeletVersion)
+			if !ok {
+				data.SkewedNodes++
+			}
+			data.Nodes = append(data.Nodes, NodeVersionView{
+				Name:    n.Name,
+				Kubelet: n.Status.NodeInfo.KubeletVersion,
+				Runtime: n.Status.NodeInfo.ContainerRuntimeVersion,
+				Skew:    skew,
+				OK:      ok,
+			})
+		}
+		sort.Slice(data.Nodes, func(i, j int) bool { return data.Nodes[i].Name < data.Nodes[j].Name })
+	case apierrors.IsForbidden(err):
+		data.NodesWarning = "The current identity cannot list nodes, so kubelet versions are not shown."
+	default:
+		if s.handleK8sUnauthorized(w, r, err, "/resources", "resources") {
+			return
+		}
+		data.NodesWarning = "Failed to list nodes: " + err.Error()
+	}
+
+	groups, err := client.Discovery().ServerGroups()
+	if err != nil {
+		data.GroupsWarning = "Failed to discover API groups: " + err.Error()
+	} else {
+		for _, g := range groups.Groups {
+			for _, v := range g.Versions {
+				if removed, ok := removedAPIVersions[v.GroupVersion]; ok {
+					data.Deprecated = append(data.Deprecated, DeprecatedAPIView{GroupVersion: v.GroupVersion, RemovedIn: removed})
+				}
+			}
+		}
+		sort.Slice(data.Deprecated, func(i, j int) bool { return data.Deprecated[i].GroupVersion < data.Deprecated[j].GroupVersion })
+	}
+
+	s.renderTemplate(w, "cluster_versions.html", data)
+}
+
+// kubeletSkew describes how far a kubelet is from the API server and whether
+// that is within the skew policy: a kubelet must not be newer than the API
+// server and may be at most maxKubeletSkew minor versions older.
+func kubeletSkew(server *version.Version, kubelet string) (string, bool) {
+	if server == nil {
+		return "API server version unknown", true
+	}
+	kv, err := version.ParseGeneric(kubelet)
+	if err != nil {
+		return "Unrecognised version", true
+	}
+	if kv.Major() != server.Major() {
+		return fmt.Sprintf("Major version %d differs from the API server", kv.Major()), false
+	}
+
+	diff := int(server.Minor()) - int(kv.Minor())
+	switch {
+	case diff < 0:
+		return fmt.Sprintf("%d minor version(s) newer than the API server", -diff), false
+	case diff == 0:
+		return "Same minor version", true
+	case diff > maxKubeletSkew:
+		return fmt.Sprintf("%d minor versions older; at most %d is supported", diff, maxKubeletSkew), false
+	default:
+		return fmt.Sprintf("%d minor version(s) older", diff), true
+	}
+}
@@ -0,0 +1,30 @@
+package web
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestKubeletSkew(t *testing.T) {
+	server := version.MustParseGeneric("v1.30.2")
+	tests := []struct {
+		kubelet string
+		ok      bool
+	}{
+		{"v1.30.0", true},
+		{"v1.27.9-eks-1234", true},
+		{"v1.26.1", false},
+		{"v1.31.0", false},
+		{"v2.0.0", false},
+		{"unknown", true},
+	}
+	for _, tt := range tests {
+		if got, ok := kubeletSkew(server, tt.kubelet); ok != tt.ok {
+			t.Errorf("kubeletSkew(%q) = %q, %v; want ok=%v", tt.kubelet, got, ok, tt.ok)
+		}
+	}
+	if _, ok := kubeletSkew(nil, "v1.20.0"); !ok {
+		t.Error("kubeletSkew with unknown server version should not flag the node")
+	}
+}
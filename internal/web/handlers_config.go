@@ -1,7 +1,11 @@
 package web
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -18,6 +22,16 @@ type ConfigMapView struct {
 type ConfigMapsListPage struct {
 	BasePage
 	ConfigMaps []ConfigMapView
+	Query      *ListQuery
+}
+
+var configMapListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Keys"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
 }
 
 func (s *Server) handleConfigMapsList(w http.ResponseWriter, r *http.Request) {
@@ -26,17 +40,22 @@ func (s *Server) handleConfigMapsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cms, err := s.manager.Client().CoreV1().ConfigMaps(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, configMapListSpec)
+	cms, err := s.manager.Client().CoreV1().ConfigMaps(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "configmaps", "", "/configmaps", "configmaps") {
+		if s.handleK8sForbidden(w, r, err, "list", "configmaps", "", "/configmaps", "configmaps") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = cms.Continue
 
 	var views []ConfigMapView
 	for _, cm := range cms.Items {
+		if !q.Keep(cm.Name, "") {
+			continue
+		}
 		var keys []string
 		for k := range cm.Data {
 			keys = append(keys, k)
@@ -53,8 +72,9 @@ func (s *Server) handleConfigMapsList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := ConfigMapsListPage{
-		BasePage:   BasePage{Namespace: s.manager.Namespace(), Title: "ConfigMaps", Active: "configmaps"},
+		BasePage:   BasePage{Namespace: s.namespace(r), Title: "ConfigMaps", Active: "configmaps"},
 		ConfigMaps: views,
+		Query:      q,
 	}
 
 	s.renderTemplate(w, "configmaps_list.html", data)
@@ -70,6 +90,17 @@ type SecretView struct {
 type SecretsListPage struct {
 	BasePage
 	Secrets []SecretView
+	Query   *ListQuery
+}
+
+var secretListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Type", Key: "type"},
+		{Label: "Keys"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
 }
 
 func (s *Server) handleSecretsList(w http.ResponseWriter, r *http.Request) {
@@ -78,17 +109,26 @@ func (s *Server) handleSecretsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	secrets, err := s.manager.Client().CoreV1().Secrets(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, secretListSpec)
+	secrets, err := s.manager.Client().CoreV1().Secrets(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "secrets", "", "/secrets", "secrets") {
+		if s.handleK8sForbidden(w, r, err, "list", "secrets", "", "/secrets", "secrets") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = secrets.Continue
+
+	sortItems(secrets.Items, q, map[string]func(a, b *corev1.Secret) int{
+		"type": func(a, b *corev1.Secret) int { return strings.Compare(string(a.Type), string(b.Type)) },
+	})
 
 	var views []SecretView
 	for _, sec := range secrets.Items {
+		if !q.Keep(sec.Name, "") {
+			continue
+		}
 		var keys []string
 		for k := range sec.Data {
 			keys = append(keys, k)
@@ -106,8 +146,9 @@ func (s *Server) handleSecretsList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := SecretsListPage{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "Secrets", Active: "secrets"},
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Secrets", Active: "secrets"},
 		Secrets:  views,
+		Query:    q,
 	}
 
 	s.renderTemplate(w, "secrets_list.html", data)
@@ -121,35 +162,20 @@ func (s *Server) handleConfigMapYAML(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	cm, err := s.manager.Client().CoreV1().ConfigMaps(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	cm, err := s.manager.Client().CoreV1().ConfigMaps(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "configmaps", name, "/configmaps", "configmaps") {
+		if s.handleK8sForbidden(w, r, err, "get", "configmaps", name, "/configmaps", "configmaps") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	cm.ManagedFields = nil
-	y, err := yaml.Marshal(cm)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	data := struct {
-		BasePage
-		Name string
-		Kind string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "configmaps"},
+	s.renderYAML(w, r, "yaml_view.html", cm, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "configmaps"},
 		Name:     name,
 		Kind:     "configmaps",
-		YAML:     string(y),
-	}
-
-	s.renderTemplate(w, "yaml_view.html", data)
+	})
 }
 
 func (s *Server) handleConfigMapEditGET(w http.ResponseWriter, r *http.Request) {
@@ -161,9 +187,9 @@ func (s *Server) handleConfigMapEditGET(w http.ResponseWriter, r *http.Request)
 	}
 	name := parts[2]
 
-	cm, err := s.manager.Client().CoreV1().ConfigMaps(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	cm, err := s.manager.Client().CoreV1().ConfigMaps(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "configmaps", name, "/configmaps", "configmaps") {
+		if s.handleK8sForbidden(w, r, err, "get", "configmaps", name, "/configmaps", "configmaps") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -179,12 +205,8 @@ func (s *Server) handleConfigMapEditGET(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	data := struct {
-		BasePage
-		Name string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "Edit ConfigMap: " + name, Active: "configmaps"},
+	data := YAMLEditPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Edit ConfigMap: " + name, Active: "configmaps"},
 		Name:     name,
 		YAML:     string(y),
 	}
@@ -209,19 +231,31 @@ func (s *Server) handleConfigMapEditPOST(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Force namespace and name to match URL to prevent confusion
-	cm.Namespace = s.manager.Namespace()
+	cm.Namespace = s.namespace(r)
 	cm.Name = name
 
-	_, err := s.manager.Client().CoreV1().ConfigMaps(s.manager.Namespace()).Update(r.Context(), &cm, metav1.UpdateOptions{})
+	page := YAMLEditPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Edit ConfigMap: " + name, Active: "configmaps"},
+		Name:     name,
+		YAML:     yamlContent,
+	}
+	if !s.validateLint(w, r, &cm, corev1.SchemeGroupVersion.WithKind("ConfigMap"), "configmaps_edit.html", page) {
+		return
+	}
+
+	_, err := s.manager.Client().CoreV1().ConfigMaps(s.namespace(r)).Update(r.Context(), &cm, metav1.UpdateOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "update", "configmaps", name, "/configmaps", "configmaps") {
+		if s.handleK8sForbidden(w, r, err, "update", "configmaps", name, "/configmaps", "configmaps") {
 			return
 		}
 		http.Error(w, "Update failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.audit(requestActor(r), s.namespace(r), "configmap", name, "edited")
 
-	http.Redirect(w, r, "/configmaps", http.StatusSeeOther)
+	// Offer to restart the workloads consuming the ConfigMap, since mounted and
+	// env-sourced config is not reloaded by running pods.
+	http.Redirect(w, r, keepNamespace(r, "/configmaps/"+name+"/rollout?saved=1"), http.StatusSeeOther)
 }
 
 func (s *Server) handleSecretYAML(w http.ResponseWriter, r *http.Request) {
@@ -232,9 +266,9 @@ func (s *Server) handleSecretYAML(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	sec, err := s.manager.Client().CoreV1().Secrets(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	sec, err := s.manager.Client().CoreV1().Secrets(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "secrets", name, "/secrets", "secrets") {
+		if s.handleK8sForbidden(w, r, err, "get", "secrets", name, "/secrets", "secrets") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -243,35 +277,32 @@ func (s *Server) handleSecretYAML(w http.ResponseWriter, r *http.Request) {
 
 	// Mask data in YAML view for safety, or show it base64 encoded as is?
 	// Usually "Edit YAML" shows base64. Let's keep it as is.
-	sec.ManagedFields = nil
-	y, err := yaml.Marshal(sec)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	data := struct {
-		BasePage
-		Name string
-		Kind string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "secrets"},
+	s.renderYAML(w, r, "yaml_view.html", sec, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "secrets"},
 		Name:     name,
 		Kind:     "secrets",
-		YAML:     string(y),
-	}
-
-	s.renderTemplate(w, "yaml_view.html", data)
+	})
 }
 
 type SecretDetailView struct {
 	BasePage
-	Name      string
-	Namespace string
-	Type      string
-	Age       string
-	Data      map[string]string
+	Name         string
+	Namespace    string
+	Type         string
+	Age          string
+	Data         map[string]string
+	Registries   []RegistryCredentialView
+	RegistryErr  string
+	UsedBy       []WorkloadRef
+	UsageWarning string
+}
+
+// RegistryCredentialView is a registry entry from an image pull secret. Passwords
+// and tokens are deliberately never exposed.
+type RegistryCredentialView struct {
+	Registry string
+	Username string
+	HasAuth  bool
 }
 
 func (s *Server) handleSecretDetail(w http.ResponseWriter, r *http.Request) {
@@ -282,9 +313,9 @@ func (s *Server) handleSecretDetail(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	sec, err := s.manager.Client().CoreV1().Secrets(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	sec, err := s.manager.Client().CoreV1().Secrets(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "secrets", name, "/secrets", "secrets") {
+		if s.handleK8sForbidden(w, r, err, "get", "secrets", name, "/secrets", "secrets") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -300,7 +331,7 @@ func (s *Server) handleSecretDetail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := SecretDetailView{
-		BasePage:  BasePage{Namespace: s.manager.Namespace(), Title: "Secret: " + name, Active: "secrets"},
+		BasePage:  BasePage{Namespace: s.namespace(r), Title: "Secret: " + name, Active: "secrets"},
 		Name:      sec.Name,
 		Namespace: sec.Namespace,
 		Type:      string(sec.Type),
@@ -308,5 +339,172 @@ func (s *Server) handleSecretDetail(w http.ResponseWriter, r *http.Request) {
 		Data:      decodedData,
 	}
 
+	if sec.Type == corev1.SecretTypeDockerConfigJson || sec.Type == corev1.SecretTypeDockercfg {
+		registries, err := registryCredentials(sec)
+		if err != nil {
+			data.RegistryErr = err.Error()
+		}
+		data.Registries = registries
+
+		refs, warnings := s.workloadsUsing(r.Context(), sec.Namespace, func(spec *corev1.PodSpec) bool {
+			for _, ref := range spec.ImagePullSecrets {
+				if ref.Name == sec.Name {
+					return true
+				}
+			}
+			return false
+		})
+		if sas, err := s.manager.Client().CoreV1().ServiceAccounts(sec.Namespace).List(r.Context(), metav1.ListOptions{}); err != nil {
+			warnings = append(warnings, fmt.Sprintf("serviceaccounts: %v", err))
+		} else {
+			for _, sa := range sas.Items {
+				for _, ref := range sa.ImagePullSecrets {
+					if ref.Name == sec.Name {
+						refs = append(refs, WorkloadRef{Kind: "ServiceAccount", Name: sa.Name})
+						break
+					}
+				}
+			}
+		}
+		data.UsedBy = refs
+		if len(warnings) > 0 {
+			data.UsageWarning = "Some resources could not be checked: " + strings.Join(warnings, "; ")
+		}
+	}
+
 	s.renderTemplate(w, "secret_detail.html", data)
 }
+
+// registryCredentials decodes a dockerconfigjson or legacy dockercfg secret into
+// its registries and usernames.
+func registryCredentials(sec *corev1.Secret) ([]RegistryCredentialView, error) {
+	type authEntry struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	}
+
+	var auths map[string]authEntry
+	switch sec.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		var cfg struct {
+			Auths map[string]authEntry `json:"auths"`
+		}
+		if err := json.Unmarshal(sec.Data[corev1.DockerConfigJsonKey], &cfg); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", corev1.DockerConfigJsonKey, err)
+		}
+		auths = cfg.Auths
+	case corev1.SecretTypeDockercfg:
+		if err := json.Unmarshal(sec.Data[corev1.DockerConfigKey], &auths); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", corev1.DockerConfigKey, err)
+		}
+	}
+
+	views := make([]RegistryCredentialView, 0, len(auths))
+	for registry, entry := range auths {
+		username := entry.Username
+		if username == "" && entry.Auth != "" {
+			// auth is base64("username:password"); keep only the username.
+			if decoded, err := base64.StdEncoding.DecodeString(entry.Auth); err == nil {
+				username, _, _ = strings.Cut(string(decoded), ":")
+			}
+		}
+		views = append(views, RegistryCredentialView{
+			Registry: registry,
+			Username: username,
+			HasAuth:  entry.Password != "" || entry.Auth != "",
+		})
+	}
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].Registry < views[j].Registry
+	})
+	return views, nil
+}
+
+type ConfigRolloutPage struct {
+	BasePage
+	Kind         string // "configmaps" or "secrets"
+	Name         string
+	Saved        bool
+	Consumers    []WorkloadRef
+	UsageWarning string
+	Results      []string
+	Errors       []string
+}
+
+// handleConfigRollout lists the workloads consuming a ConfigMap or Secret and, on
+// POST, rollout-restarts the selected ones so they pick up the new data.
+func (s *Server) handleConfigRollout(w http.ResponseWriter, r *http.Request, kind string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// /{kind}/{name}/rollout
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 4 {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+	name := parts[2]
+	namespace := s.namespace(r)
+
+	var err error
+	var match func(spec *corev1.PodSpec) bool
+	switch kind {
+	case "configmaps":
+		_, err = s.manager.Client().CoreV1().ConfigMaps(namespace).Get(r.Context(), name, metav1.GetOptions{})
+		match = func(spec *corev1.PodSpec) bool { return podSpecUsesConfigMap(spec, name) }
+	case "secrets":
+		_, err = s.manager.Client().CoreV1().Secrets(namespace).Get(r.Context(), name, metav1.GetOptions{})
+		match = func(spec *corev1.PodSpec) bool { return podSpecUsesSecret(spec, name) }
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "get", kind, name, "/"+kind, kind) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	consumers, warnings := s.workloadsUsing(r.Context(), namespace, match)
+	data := ConfigRolloutPage{
+		BasePage:  BasePage{Namespace: namespace, Title: "Restart consumers: " + name, Active: kind},
+		Kind:      kind,
+		Name:      name,
+		Saved:     r.URL.Query().Get("saved") == "1",
+		Consumers: consumers,
+	}
+	if len(warnings) > 0 {
+		data.UsageWarning = "Some workload kinds could not be checked: " + strings.Join(warnings, "; ")
+	}
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form", http.StatusBadRequest)
+			return
+		}
+		selected := make(map[string]bool)
+		for _, v := range r.Form["workload"] {
+			selected[v] = true
+		}
+		for _, ref := range consumers {
+			if !ref.Restartable() || !selected[ref.Kind+"/"+ref.Name] {
+				continue
+			}
+			if err := restartWorkload(r.Context(), s.manager.Client(), namespace, ref); err != nil {
+				data.Errors = append(data.Errors, fmt.Sprintf("%s %s: %v", ref.Kind, ref.Name, err))
+				continue
+			}
+			data.Results = append(data.Results, fmt.Sprintf("Restarted %s %s", ref.Kind, ref.Name))
+			s.audit(requestActor(r), namespace, strings.ToLower(ref.Kind), ref.Name, fmt.Sprintf("restarted for %s %s", strings.TrimSuffix(kind, "s"), name))
+		}
+		if len(data.Results) == 0 && len(data.Errors) == 0 {
+			data.Errors = append(data.Errors, "No workloads were selected.")
+		}
+	}
+
+	s.renderTemplate(w, "config_rollout.html", data)
+}
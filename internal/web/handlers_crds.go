@@ -3,6 +3,7 @@ package web
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -11,7 +12,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
-	"sigs.k8s.io/yaml"
 )
 
 type CRDResourceView struct {
@@ -26,6 +26,7 @@ type CRDResourceView struct {
 type CRDsListPage struct {
 	BasePage
 	Resources []CRDResourceView
+	Query     *ListQuery
 }
 
 type CRDItemView struct {
@@ -42,6 +43,17 @@ type CRDItemsListPage struct {
 	Items      []CRDItemView
 	BackURL    string
 	ResourceID string
+	Query      *ListQuery
+}
+
+var crdListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Group", Key: "group"},
+		{Label: "Version"},
+		{Label: "Resource", Key: "resource"},
+		{Label: "Kind", Key: "kind"},
+	},
+	DefaultSort: "group",
 }
 
 func (s *Server) handleCRDsList(w http.ResponseWriter, r *http.Request) {
@@ -58,8 +70,11 @@ func (s *Server) handleCRDsList(w http.ResponseWriter, r *http.Request) {
 
 	disco, err := discovery.NewDiscoveryClientForConfig(cfg)
 	if err != nil {
+		if s.handleK8sUnauthorized(w, r, err, "/resources", "resources") {
+			return
+		}
 		if apierrors.IsForbidden(err) {
-			s.renderPermissionDenied(w, "Cannot discover custom resources", "The current identity does not have permission to discover API resources.", "/resources", "resources")
+			s.renderPermissionDenied(w, r, "Cannot discover custom resources", "The current identity does not have permission to discover API resources.", "/resources", "resources")
 			return
 		}
 		http.Error(w, "failed to create discovery client: "+err.Error(), http.StatusInternalServerError)
@@ -69,8 +84,11 @@ func (s *Server) handleCRDsList(w http.ResponseWriter, r *http.Request) {
 	resourceLists, err := disco.ServerPreferredNamespacedResources()
 	if err != nil {
 		if !discovery.IsGroupDiscoveryFailedError(err) {
+			if s.handleK8sUnauthorized(w, r, err, "/resources", "resources") {
+				return
+			}
 			if apierrors.IsForbidden(err) {
-				s.renderPermissionDenied(w, "Cannot list custom resources", "The current identity is not allowed to read API discovery information for CRDs.", "/resources", "resources")
+				s.renderPermissionDenied(w, r, "Cannot list custom resources", "The current identity is not allowed to read API discovery information for CRDs.", "/resources", "resources")
 				return
 			}
 			http.Error(w, "failed to discover resources: "+err.Error(), http.StatusInternalServerError)
@@ -78,6 +96,11 @@ func (s *Server) handleCRDsList(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Discovery is not paged and has no labels, so only the name filter
+	// and sorting apply here.
+	q := s.listQuery(r, crdListSpec)
+	q.Local = true
+
 	resources := make([]CRDResourceView, 0)
 	for _, rl := range resourceLists {
 		gv, err := schema.ParseGroupVersion(rl.GroupVersion)
@@ -99,6 +122,9 @@ func (s *Server) handleCRDsList(w http.ResponseWriter, r *http.Request) {
 			if !supportsVerb(res.Verbs, "list") || !supportsVerb(res.Verbs, "get") {
 				continue
 			}
+			if !q.Keep(res.Name+"."+gv.Group, "") {
+				continue
+			}
 
 			resources = append(resources, CRDResourceView{
 				Group:      gv.Group,
@@ -121,14 +147,36 @@ func (s *Server) handleCRDsList(w http.ResponseWriter, r *http.Request) {
 		return resources[i].Group < resources[j].Group
 	})
 
+	if key := crdSortKey(q.Sort); key != nil {
+		sort.SliceStable(resources, func(i, j int) bool {
+			if q.Desc {
+				return key(resources[i]) > key(resources[j])
+			}
+			return key(resources[i]) < key(resources[j])
+		})
+	}
+
 	data := CRDsListPage{
-		BasePage:  BasePage{Namespace: s.manager.Namespace(), Title: "CRDs", Active: "resources"},
+		BasePage:  BasePage{Namespace: s.namespace(r), Title: "CRDs", Active: "resources"},
 		Resources: resources,
+		Query:     q,
 	}
 
 	s.renderTemplate(w, "crds_list.html", data)
 }
 
+func crdSortKey(sort string) func(CRDResourceView) string {
+	switch sort {
+	case "group":
+		return func(v CRDResourceView) string { return v.Group }
+	case "resource":
+		return func(v CRDResourceView) string { return v.Resource }
+	case "kind":
+		return func(v CRDResourceView) string { return v.Kind }
+	}
+	return nil
+}
+
 func (s *Server) handleCRDsSubroutes(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/crds/")
 	if path == "" {
@@ -150,6 +198,14 @@ func (s *Server) handleCRDsSubroutes(w http.ResponseWriter, r *http.Request) {
 	http.NotFound(w, r)
 }
 
+var crdObjectListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
+}
+
 func (s *Server) handleCRDObjectsList(w http.ResponseWriter, r *http.Request, group, version, resource string) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -163,19 +219,30 @@ func (s *Server) handleCRDObjectsList(w http.ResponseWriter, r *http.Request, gr
 	}
 
 	gvr := schema.GroupVersionResource{Group: group, Version: version, Resource: resource}
-	list, err := dc.Resource(gvr).Namespace(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, crdObjectListSpec)
+	list, err := dc.Resource(gvr).Namespace(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
+		if s.handleK8sUnauthorized(w, r, err, "/resources", "resources") {
+			return
+		}
 		if apierrors.IsForbidden(err) {
-			s.renderPermissionDenied(w, "Access denied for CRD list", fmt.Sprintf("You are not allowed to list %s in namespace %s.", resource, s.manager.Namespace()), "/resources", "resources")
+			s.renderPermissionDenied(w, r, "Access denied for CRD list", fmt.Sprintf("You are not allowed to list %s in namespace %s.", resource, s.namespace(r)), "/resources", "resources")
 			return
 		}
 		http.Error(w, "failed to list resources: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	q.Next = list.GetContinue()
+
+	sortItems(list.Items, q, nil)
+
 	items := make([]CRDItemView, 0, len(list.Items))
 	for _, it := range list.Items {
 		name := it.GetName()
+		if !q.Keep(name, "") {
+			continue
+		}
 		items = append(items, CRDItemView{
 			Name:    name,
 			Age:     formatAge(it.GetCreationTimestamp().Time),
@@ -183,19 +250,16 @@ func (s *Server) handleCRDObjectsList(w http.ResponseWriter, r *http.Request, gr
 		})
 	}
 
-	sort.Slice(items, func(i, j int) bool {
-		return items[i].Name < items[j].Name
-	})
-
 	resourceID := fmt.Sprintf("%s/%s (%s)", resource, version, group)
 	data := CRDItemsListPage{
-		BasePage:   BasePage{Namespace: s.manager.Namespace(), Title: "CRD Instances", Active: "resources"},
+		BasePage:   BasePage{Namespace: s.namespace(r), Title: "CRD Instances", Active: "resources"},
 		Group:      group,
 		Version:    version,
 		Resource:   resource,
 		Items:      items,
 		BackURL:    "/resources",
 		ResourceID: resourceID,
+		Query:      q,
 	}
 
 	s.renderTemplate(w, "crd_items_list.html", data)
@@ -214,40 +278,28 @@ func (s *Server) handleCRDYAML(w http.ResponseWriter, r *http.Request, group, ve
 	}
 
 	gvr := schema.GroupVersionResource{Group: group, Version: version, Resource: resource}
-	obj, err := dc.Resource(gvr).Namespace(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	obj, err := dc.Resource(gvr).Namespace(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
+		if s.handleK8sUnauthorized(w, r, err, fmt.Sprintf("/crds/%s/%s/%s", group, version, resource), "resources") {
+			return
+		}
 		if apierrors.IsForbidden(err) {
-			s.renderPermissionDenied(w, "Access denied for CRD YAML", fmt.Sprintf("You are not allowed to read %s/%s in namespace %s.", resource, name, s.manager.Namespace()), fmt.Sprintf("/crds/%s/%s/%s", group, version, resource), "resources")
+			s.renderPermissionDenied(w, r, "Access denied for CRD YAML", fmt.Sprintf("You are not allowed to read %s/%s in namespace %s.", resource, name, s.namespace(r)), fmt.Sprintf("/crds/%s/%s/%s", group, version, resource), "resources")
 			return
 		}
 		http.Error(w, "failed to get resource: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	obj.SetManagedFields(nil)
-	y, err := yaml.Marshal(obj.Object)
-	if err != nil {
-		http.Error(w, "failed to marshal yaml: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	data := struct {
-		BasePage
-		Name       string
-		Kind       string
-		YAML       string
-		BackURL    string
-		ResourceID string
-	}{
-		BasePage:   BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "resources"},
+	s.renderYAML(w, r, "crd_yaml_view.html", obj, YAMLPage{
+		BasePage:   BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "resources"},
 		Name:       name,
 		Kind:       resource,
-		YAML:       string(y),
 		BackURL:    fmt.Sprintf("/crds/%s/%s/%s", group, version, resource),
 		ResourceID: fmt.Sprintf("%s/%s (%s)", resource, version, group),
-	}
 
-	s.renderTemplate(w, "crd_yaml_view.html", data)
+		DescribeURL: "/describe?" + url.Values{"resource": {group + "/" + version + "/" + resource}, "name": {name}}.Encode(),
+	})
 }
 
 func (s *Server) newDynamicClient() (dynamic.Interface, error) {
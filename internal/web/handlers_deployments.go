@@ -1,7 +1,8 @@
 package web
 
 import (
-	"encoding/json"
+	"cmp"
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,8 +10,11 @@ import (
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/yaml"
 )
 
@@ -22,11 +26,34 @@ type DeploymentView struct {
 	Unavailable int32
 	Images      []string
 	Age         string
+	Conditions  []DeploymentConditionView
+	Stalled     bool
+}
+
+type DeploymentConditionView struct {
+	Type    string
+	Status  string
+	Reason  string
+	Message string
+	Updated string
 }
 
 type DeploymentsListPage struct {
 	BasePage
 	Deployments []DeploymentView
+	Query       *ListQuery
+}
+
+var deploymentListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Ready"},
+		{Label: "Replicas", Key: "replicas"},
+		{Label: "Conditions"},
+		{Label: "Images"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
 }
 
 func (s *Server) handleDeploymentsList(w http.ResponseWriter, r *http.Request) {
@@ -35,17 +62,27 @@ func (s *Server) handleDeploymentsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	deployments, err := s.manager.Client().AppsV1().Deployments(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, deploymentListSpec)
+	deployments, err := s.manager.Client().AppsV1().Deployments(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "deployments", "", "/deployments", "deployments") {
+		if s.handleK8sForbidden(w, r, err, "list", "deployments", "", "/deployments", "deployments") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = deployments.Continue
+
+	sortItems(deployments.Items, q, map[string]func(a, b *appsv1.Deployment) int{
+		"replicas": func(a, b *appsv1.Deployment) int { return cmp.Compare(*a.Spec.Replicas, *b.Spec.Replicas) },
+	})
 
+	now := time.Now()
 	var views []DeploymentView
 	for _, d := range deployments.Items {
+		if !q.Keep(d.Name, deploymentListStatus(&d, now)) {
+			continue
+		}
 		var images []string
 		for _, c := range d.Spec.Template.Spec.Containers {
 			images = append(images, c.Image)
@@ -59,17 +96,34 @@ func (s *Server) handleDeploymentsList(w http.ResponseWriter, r *http.Request) {
 			Unavailable: d.Status.UnavailableReplicas,
 			Images:      images,
 			Age:         formatAge(d.CreationTimestamp.Time),
+			Conditions:  deploymentConditions(&d),
+			Stalled:     rolloutStalled(&d, now),
 		})
 	}
 
 	data := DeploymentsListPage{
-		BasePage:    BasePage{Namespace: s.manager.Namespace(), Title: "Deployments", Active: "deployments"},
+		BasePage:    BasePage{Namespace: s.namespace(r), Title: "Deployments", Active: "deployments"},
 		Deployments: views,
+		Query:       q,
 	}
 
 	s.renderTemplate(w, "deployments_list.html", data)
 }
 
+// deploymentListStatus summarises a deployment for the list status filter.
+func deploymentListStatus(d *appsv1.Deployment, now time.Time) string {
+	switch {
+	case rolloutStalled(d, now):
+		return "Stalled"
+	case *d.Spec.Replicas == 0:
+		return "Scaled Down"
+	case d.Status.AvailableReplicas >= *d.Spec.Replicas:
+		return "Available"
+	default:
+		return "Unavailable"
+	}
+}
+
 func (s *Server) handleDeploymentRestart(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -83,34 +137,23 @@ func (s *Server) handleDeploymentRestart(w http.ResponseWriter, r *http.Request)
 	}
 	name := parts[2]
 
-	patchData := map[string]interface{}{
-		"spec": map[string]interface{}{
-			"template": map[string]interface{}{
-				"metadata": map[string]interface{}{
-					"annotations": map[string]interface{}{
-						"kubectl.kubernetes.io/restartedAt": time.Now().Format(time.RFC3339),
-					},
-				},
-			},
-		},
-	}
-
-	payload, err := json.Marshal(patchData)
+	payload, err := rolloutRestartPatch()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	_, err = s.manager.Client().AppsV1().Deployments(s.manager.Namespace()).Patch(r.Context(), name, types.MergePatchType, payload, metav1.PatchOptions{})
+	_, err = s.manager.Client().AppsV1().Deployments(s.namespace(r)).Patch(r.Context(), name, types.MergePatchType, payload, metav1.PatchOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "patch", "deployments", name, "/deployments", "deployments") {
+		if s.handleK8sForbidden(w, r, err, "patch", "deployments", name, "/deployments", "deployments") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.audit(requestActor(r), s.namespace(r), "deployment", name, "restarted")
 
-	http.Redirect(w, r, "/deployments", http.StatusSeeOther)
+	s.redirectBack(w, r, "/deployments")
 }
 
 func (s *Server) handleDeploymentScale(w http.ResponseWriter, r *http.Request) {
@@ -126,36 +169,35 @@ func (s *Server) handleDeploymentScale(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	replicasStr := r.FormValue("replicas")
-	replicas, err := strconv.ParseInt(replicasStr, 10, 32)
-	if err != nil {
-		http.Error(w, "Invalid replicas", http.StatusBadRequest)
-		return
-	}
-	r32 := int32(replicas)
-
 	// We need to get the deployment first to avoid overwriting other fields if we used Update,
 	// but here we can use Patch or just Get/Update. Get/Update is safer for simple logic.
-	d, err := s.manager.Client().AppsV1().Deployments(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	d, err := s.manager.Client().AppsV1().Deployments(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "deployments", name, "/deployments", "deployments") {
+		if s.handleK8sForbidden(w, r, err, "get", "deployments", name, "/deployments", "deployments") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	r32, ok := s.validateScale(w, r, "Deployment", name, d.Spec.Paused, ptr.Deref(d.Spec.Replicas, 1), &d.Spec.Template.Spec, "/deployments", "deployments")
+	if !ok {
+		return
+	}
+
+	from := ptr.Deref(d.Spec.Replicas, 1)
 	d.Spec.Replicas = &r32
-	_, err = s.manager.Client().AppsV1().Deployments(s.manager.Namespace()).Update(r.Context(), d, metav1.UpdateOptions{})
+	_, err = s.manager.Client().AppsV1().Deployments(s.namespace(r)).Update(r.Context(), d, metav1.UpdateOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "update", "deployments", name, "/deployments", "deployments") {
+		if s.handleK8sForbidden(w, r, err, "update", "deployments", name, "/deployments", "deployments") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.audit(requestActor(r), s.namespace(r), "deployment", name, fmt.Sprintf("scaled %d→%d", from, r32))
 
-	http.Redirect(w, r, "/deployments", http.StatusSeeOther)
+	s.redirectBack(w, r, "/deployments")
 }
 
 func (s *Server) handleDeploymentEditGET(w http.ResponseWriter, r *http.Request) {
@@ -167,9 +209,9 @@ func (s *Server) handleDeploymentEditGET(w http.ResponseWriter, r *http.Request)
 	}
 	name := parts[2]
 
-	d, err := s.manager.Client().AppsV1().Deployments(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	d, err := s.manager.Client().AppsV1().Deployments(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "deployments", name, "/deployments", "deployments") {
+		if s.handleK8sForbidden(w, r, err, "get", "deployments", name, "/deployments", "deployments") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -185,14 +227,11 @@ func (s *Server) handleDeploymentEditGET(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	data := struct {
-		BasePage
-		Name string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "Edit Deployment: " + name, Active: "deployments"},
+	data := YAMLEditPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Edit Deployment: " + name, Active: "deployments"},
 		Name:     name,
 		YAML:     string(y),
+		ReturnTo: returnTo(r, "/deployments"),
 	}
 
 	s.renderTemplate(w, "deployments_edit.html", data)
@@ -215,19 +254,30 @@ func (s *Server) handleDeploymentEditPOST(w http.ResponseWriter, r *http.Request
 	}
 
 	// Force namespace and name to match URL to prevent confusion
-	d.Namespace = s.manager.Namespace()
+	d.Namespace = s.namespace(r)
 	d.Name = name
 
-	_, err := s.manager.Client().AppsV1().Deployments(s.manager.Namespace()).Update(r.Context(), &d, metav1.UpdateOptions{})
+	page := YAMLEditPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Edit Deployment: " + name, Active: "deployments"},
+		Name:     name,
+		YAML:     yamlContent,
+		ReturnTo: returnTo(r, "/deployments"),
+	}
+	if !s.validateLint(w, r, &d, appsv1.SchemeGroupVersion.WithKind("Deployment"), "deployments_edit.html", page) {
+		return
+	}
+
+	_, err := s.manager.Client().AppsV1().Deployments(s.namespace(r)).Update(r.Context(), &d, metav1.UpdateOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "update", "deployments", name, "/deployments", "deployments") {
+		if s.handleK8sForbidden(w, r, err, "update", "deployments", name, "/deployments", "deployments") {
 			return
 		}
 		http.Error(w, "Update failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.audit(requestActor(r), s.namespace(r), "deployment", name, "edited")
 
-	http.Redirect(w, r, "/deployments", http.StatusSeeOther)
+	s.redirectBack(w, r, "/deployments")
 }
 
 func (s *Server) handleDeploymentYAML(w http.ResponseWriter, r *http.Request) {
@@ -238,33 +288,225 @@ func (s *Server) handleDeploymentYAML(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	d, err := s.manager.Client().AppsV1().Deployments(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	d, err := s.manager.Client().AppsV1().Deployments(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "deployments", name, "/deployments", "deployments") {
+		if s.handleK8sForbidden(w, r, err, "get", "deployments", name, "/deployments", "deployments") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	d.ManagedFields = nil
-	y, err := yaml.Marshal(d)
+	s.renderYAML(w, r, "yaml_view.html", d, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "deployments"},
+		Name:     name,
+		Kind:     "deployments",
+	})
+}
+
+type PodErrorView struct {
+	Pod       string
+	Container string
+	Reason    string
+	Message   string
+	Restarts  int32
+}
+
+type DeploymentDetailPage struct {
+	BasePage
+	Name            string
+	Replicas        int32
+	Updated         int32
+	Ready           int32
+	Available       int32
+	Strategy        string
+	ProgressTimeout int32
+	Images          []string
+	Age             string
+	Conditions      []DeploymentConditionView
+	Stalled         bool
+	ReplicaSet      string
+	PodErrors       []PodErrorView
+	PodErrorWarning string
+	Links           ExternalLinks
+	// Selector is the Deployment's label selector, for the live pod list.
+	Selector string
+}
+
+func (s *Server) handleDeploymentDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/deployments/")
+
+	d, err := s.manager.Client().AppsV1().Deployments(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "get", "deployments", name, "/deployments", "deployments") {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	data := struct {
-		BasePage
-		Name string
-		Kind string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "deployments"},
-		Name:     name,
-		Kind:     "deployments",
-		YAML:     string(y),
+	var images []string
+	for _, c := range d.Spec.Template.Spec.Containers {
+		images = append(images, c.Image)
 	}
 
-	s.renderTemplate(w, "yaml_view.html", data)
+	var replicas int32 = 1
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	var progressTimeout int32 = 600
+	if d.Spec.ProgressDeadlineSeconds != nil {
+		progressTimeout = *d.Spec.ProgressDeadlineSeconds
+	}
+
+	data := DeploymentDetailPage{
+		BasePage:        BasePage{Namespace: s.namespace(r), Title: "Deployment: " + name, Active: "deployments"},
+		Name:            d.Name,
+		Replicas:        replicas,
+		Updated:         d.Status.UpdatedReplicas,
+		Ready:           d.Status.ReadyReplicas,
+		Available:       d.Status.AvailableReplicas,
+		Strategy:        string(d.Spec.Strategy.Type),
+		ProgressTimeout: progressTimeout,
+		Images:          images,
+		Age:             formatAge(d.CreationTimestamp.Time),
+		Conditions:      deploymentConditions(d),
+		Stalled:         rolloutStalled(d, time.Now()),
+		Links:           annotationLinks(d.Annotations),
+	}
+	if sel, err := metav1.LabelSelectorAsSelector(d.Spec.Selector); err == nil && !sel.Empty() {
+		data.Selector = sel.String()
+	}
+
+	// The newest ReplicaSet is the one being rolled out; summarize why its pods are unhealthy.
+	rs, err := s.newestReplicaSet(r.Context(), d)
+	if err != nil {
+		data.PodErrorWarning = "Unable to inspect ReplicaSets: " + err.Error()
+	} else if rs != nil {
+		data.ReplicaSet = rs.Name
+		selector, err := metav1.LabelSelectorAsSelector(rs.Spec.Selector)
+		if err != nil {
+			selector = labels.Nothing()
+		}
+		pods, err := s.manager.Client().CoreV1().Pods(s.namespace(r)).List(r.Context(), metav1.ListOptions{
+			LabelSelector: selector.String(),
+		})
+		if err != nil {
+			data.PodErrorWarning = "Unable to list pods of ReplicaSet " + rs.Name + ": " + err.Error()
+		} else {
+			for _, p := range pods.Items {
+				if metav1.IsControlledBy(&p, rs) {
+					data.PodErrors = append(data.PodErrors, podErrors(p)...)
+				}
+			}
+		}
+	}
+
+	s.renderTemplate(w, "deployments_detail.html", data)
+}
+
+// newestReplicaSet returns the ReplicaSet with the highest revision owned by the deployment.
+func (s *Server) newestReplicaSet(ctx context.Context, d *appsv1.Deployment) (*appsv1.ReplicaSet, error) {
+	selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.manager.Client().AppsV1().ReplicaSets(d.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	var newest *appsv1.ReplicaSet
+	var newestRevision int64 = -1
+	for i := range list.Items {
+		rs := &list.Items[i]
+		if !metav1.IsControlledBy(rs, d) {
+			continue
+		}
+		revision, _ := strconv.ParseInt(rs.Annotations["deployment.kubernetes.io/revision"], 10, 64)
+		if revision > newestRevision {
+			newest, newestRevision = rs, revision
+		}
+	}
+	return newest, nil
+}
+
+func deploymentConditions(d *appsv1.Deployment) []DeploymentConditionView {
+	var views []DeploymentConditionView
+	for _, c := range d.Status.Conditions {
+		views = append(views, DeploymentConditionView{
+			Type:    string(c.Type),
+			Status:  string(c.Status),
+			Reason:  c.Reason,
+			Message: c.Message,
+			Updated: formatAge(c.LastUpdateTime.Time),
+		})
+	}
+	return views
+}
+
+// rolloutStalled reports whether a rollout has exceeded its progress deadline. The
+// deployment controller marks this with ProgressDeadlineExceeded, but that only
+// happens on its next sync, so an incomplete rollout whose Progressing condition has
+// not been updated within the deadline is treated as stalled as well.
+func rolloutStalled(d *appsv1.Deployment, now time.Time) bool {
+	if d.Spec.Paused || d.Spec.ProgressDeadlineSeconds == nil {
+		return false
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type != appsv1.DeploymentProgressing {
+			continue
+		}
+		if c.Reason == "ProgressDeadlineExceeded" {
+			return true
+		}
+		if rolloutComplete(d) {
+			return false
+		}
+		deadline := time.Duration(*d.Spec.ProgressDeadlineSeconds) * time.Second
+		return !c.LastUpdateTime.IsZero() && now.Sub(c.LastUpdateTime.Time) > deadline
+	}
+	return false
+}
+
+func rolloutComplete(d *appsv1.Deployment) bool {
+	var replicas int32 = 1
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.Replicas == replicas &&
+		d.Status.AvailableReplicas == replicas
+}
+
+// podErrors summarizes why a pod is not healthy: scheduling failures, containers
+// stuck waiting and containers that last exited with an error.
+func podErrors(p corev1.Pod) []PodErrorView {
+	var errs []PodErrorView
+	for _, c := range p.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse {
+			errs = append(errs, PodErrorView{Pod: p.Name, Reason: c.Reason, Message: c.Message})
+		}
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if waiting := cs.State.Waiting; waiting != nil && waiting.Reason != "ContainerCreating" && waiting.Reason != "PodInitializing" {
+			errs = append(errs, PodErrorView{Pod: p.Name, Container: cs.Name, Reason: waiting.Reason, Message: waiting.Message, Restarts: cs.RestartCount})
+			continue
+		}
+		if term := cs.LastTerminationState.Terminated; term != nil && term.ExitCode != 0 && !cs.Ready {
+			message := term.Message
+			if message == "" {
+				message = fmt.Sprintf("exited with code %d", term.ExitCode)
+			}
+			errs = append(errs, PodErrorView{Pod: p.Name, Container: cs.Name, Reason: term.Reason, Message: message, Restarts: cs.RestartCount})
+		}
+	}
+	return errs
 }
@@ -0,0 +1,327 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/yaml"
+)
+
+// dryRunFieldManager is the field manager recorded for server-side apply
+// dry runs; nothing is persisted under it.
+const dryRunFieldManager = "k8s-ui-dry-run"
+
+// DryRunChange is a single field difference between the submitted manifest
+// and the object returned by the API server.
+type DryRunChange struct {
+	Path   string
+	Change string // "added", "changed" or "removed"
+	Before string
+	After  string
+}
+
+type DryRunPage struct {
+	BasePage
+	Action    string
+	Manifest  string
+	Error     string
+	Kind      string
+	Name      string
+	Result    string
+	Changes   []DryRunChange
+	Submitted bool
+	// Warnings are the warnings admission returned with the dry run, such
+	// as Pod Security violations in warn mode.
+	Warnings []string
+	// Precheck is set for new objects that create pods; PrecheckNote says
+	// why it was not.
+	Precheck     *WorkloadPrecheck
+	PrecheckNote string
+	// LintChecked is set when lint rules are configured; LintErrors are
+	// the findings that would block saving the manifest in an editor.
+	LintChecked  bool
+	LintErrors   []ScaleIssue
+	LintWarnings []ScaleIssue
+}
+
+// warningCollector keeps the warnings the API server sends with a response.
+type warningCollector struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (c *warningCollector) HandleWarningHeader(code int, agent string, text string) {
+	if code != 299 || text == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, text)
+}
+
+func (s *Server) handleDryRun(w http.ResponseWriter, r *http.Request) {
+	data := DryRunPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Dry Run", Active: "resources"},
+		Action:   r.URL.RequestURI(),
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.renderTemplate(w, "dryrun.html", data)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data.Manifest = r.FormValue("manifest")
+	data.Submitted = true
+
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(data.Manifest), &obj.Object); err != nil {
+		data.Error = "Invalid YAML: " + err.Error()
+		s.renderTemplate(w, "dryrun.html", data)
+		return
+	}
+	if len(obj.Object) == 0 {
+		data.Error = "The manifest is empty."
+		s.renderTemplate(w, "dryrun.html", data)
+		return
+	}
+	gvk := obj.GroupVersionKind()
+	if gvk.Kind == "" || gvk.Version == "" {
+		data.Error = "The manifest must set apiVersion and kind."
+		s.renderTemplate(w, "dryrun.html", data)
+		return
+	}
+	if obj.GetName() == "" && obj.GetGenerateName() == "" {
+		data.Error = "The manifest must set metadata.name or metadata.generateName."
+		s.renderTemplate(w, "dryrun.html", data)
+		return
+	}
+	data.Kind = gvk.Kind
+	data.Name = obj.GetName()
+
+	if len(s.config.LintRules) > 0 {
+		var err error
+		data.LintErrors, data.LintWarnings, err = lintManifest(s.config.LintRules, obj)
+		if err != nil {
+			data.Error = "Invalid manifest: " + err.Error()
+			s.renderTemplate(w, "dryrun.html", data)
+			return
+		}
+		data.LintChecked = true
+	}
+
+	cfg, err := s.manager.RESTConfig()
+	if err != nil {
+		http.Error(w, "failed to get Kubernetes config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	disco, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		http.Error(w, "failed to create discovery client: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	groups, err := restmapper.GetAPIGroupResources(disco)
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		if s.handleK8sForbidden(w, r, err, "discover", "API resources", "", "/tools/dry-run", "resources") {
+			return
+		}
+		data.Error = "API discovery failed: " + err.Error()
+		s.renderTemplate(w, "dryrun.html", data)
+		return
+	}
+	mapping, err := restmapper.NewDiscoveryRESTMapper(groups).RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		data.Error = fmt.Sprintf("The API server does not serve %s in %s.", gvk.Kind, gvk.GroupVersion())
+		s.renderTemplate(w, "dryrun.html", data)
+		return
+	}
+
+	namespace := ""
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		namespace = s.namespace(r)
+		if ns := obj.GetNamespace(); ns != "" && ns != namespace {
+			data.Error = fmt.Sprintf("The manifest is for namespace %s, but the current namespace is %s.", ns, namespace)
+			s.renderTemplate(w, "dryrun.html", data)
+			return
+		}
+		obj.SetNamespace(namespace)
+	}
+
+	warnings := &warningCollector{}
+	cfg = rest.CopyConfig(cfg)
+	cfg.WarningHandler = warnings
+	dc, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		http.Error(w, "failed to create dynamic client: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	client := dc.Resource(mapping.Resource).Namespace(namespace)
+
+	// The scheduling pre-check only makes sense for objects that do not
+	// exist yet; for existing ones the quota already covers their pods.
+	var existsErr error
+	if obj.GetName() != "" {
+		_, existsErr = client.Get(r.Context(), obj.GetName(), metav1.GetOptions{})
+	}
+
+	// Server-side apply covers both new and existing objects; generateName
+	// only works with create.
+	var result *unstructured.Unstructured
+	if obj.GetName() != "" {
+		result, err = client.Apply(r.Context(), obj.GetName(), obj, metav1.ApplyOptions{
+			FieldManager: dryRunFieldManager,
+			Force:        true,
+			DryRun:       []string{metav1.DryRunAll},
+		})
+	} else {
+		result, err = client.Create(r.Context(), obj, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	}
+	data.Warnings = warnings.messages
+	if err != nil {
+		// Pod Security, quotas and policy webhooks reject with Forbidden
+		// too; those are answers about the manifest, not permissions.
+		if isAdmissionRejection(err) {
+			data.Error = "Rejected by admission: " + err.Error()
+			s.renderTemplate(w, "dryrun.html", data)
+			return
+		}
+		if s.handleK8sForbidden(w, r, err, "create", mapping.Resource.Resource, obj.GetName(), "/tools/dry-run", "resources") {
+			return
+		}
+		data.Error = "Dry run rejected: " + err.Error()
+		s.renderTemplate(w, "dryrun.html", data)
+		return
+	}
+
+	result.SetManagedFields(nil)
+	if data.Name == "" {
+		data.Name = result.GetName()
+	}
+	y, err := yaml.Marshal(result.Object)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data.Result = string(y)
+	data.Changes = diffObjects(obj.Object, result.Object)
+
+	if _, _, ok, _ := podTemplateOf(result); ok {
+		switch {
+		case obj.GetName() != "" && existsErr == nil:
+			data.PrecheckNote = fmt.Sprintf("%s %s already exists, so the scheduling pre-check was skipped.", data.Kind, data.Name)
+		case obj.GetName() != "" && !apierrors.IsNotFound(existsErr):
+			data.PrecheckNote = "Scheduling pre-check skipped: " + existsErr.Error()
+		default:
+			check, err := s.precheckWorkload(r.Context(), namespace, result)
+			if err != nil {
+				data.PrecheckNote = "Scheduling pre-check failed: " + err.Error()
+			} else {
+				data.Precheck = &check
+			}
+		}
+	}
+
+	s.renderTemplate(w, "dryrun.html", data)
+}
+
+// dryRunIgnoredPaths are filled in by the API server for every object and
+// say nothing about what admission changed.
+var dryRunIgnoredPaths = []string{
+	"metadata.uid",
+	"metadata.resourceVersion",
+	"metadata.creationTimestamp",
+	"metadata.generation",
+	"metadata.managedFields",
+	"status",
+}
+
+// diffObjects lists the leaf fields that differ between the submitted and
+// returned objects, sorted by path.
+func diffObjects(before, after map[string]interface{}) []DryRunChange {
+	old := map[string]string{}
+	flattenFields("", before, old)
+	updated := map[string]string{}
+	flattenFields("", after, updated)
+
+	var changes []DryRunChange
+	for path, v := range updated {
+		if ignoredDryRunPath(path) {
+			continue
+		}
+		prev, ok := old[path]
+		switch {
+		case !ok:
+			changes = append(changes, DryRunChange{Path: path, Change: "added", After: v})
+		case prev != v:
+			changes = append(changes, DryRunChange{Path: path, Change: "changed", Before: prev, After: v})
+		}
+	}
+	for path, v := range old {
+		if _, ok := updated[path]; !ok && !ignoredDryRunPath(path) {
+			changes = append(changes, DryRunChange{Path: path, Change: "removed", Before: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func ignoredDryRunPath(path string) bool {
+	for _, p := range dryRunIgnoredPaths {
+		if path == p || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenFields records every leaf of v under a dotted path such as
+// spec.containers[0].image. Empty maps and lists are kept as leaves so
+// that defaulted empty fields still show up.
+func flattenFields(prefix string, v interface{}, out map[string]string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 && prefix != "" {
+			out[prefix] = "{}"
+			return
+		}
+		for k, child := range t {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			flattenFields(path, child, out)
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			out[prefix] = "[]"
+			return
+		}
+		for i, child := range t {
+			flattenFields(prefix+"["+strconv.Itoa(i)+"]", child, out)
+		}
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			out[prefix] = fmt.Sprint(t)
+			return
+		}
+		out[prefix] = string(b)
+	}
+}
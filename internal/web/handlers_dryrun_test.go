@@ -0,0 +1,66 @@
+package web
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestDiffObjects(t *testing.T) {
+	var submitted, returned map[string]interface{}
+	if err := yaml.Unmarshal([]byte(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  labels:
+    app: web
+    drop: me
+spec:
+  containers:
+  - name: app
+    image: nginx
+`), &submitted); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal([]byte(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  uid: 1234
+  resourceVersion: "1"
+  labels:
+    app: web
+  annotations:
+    sidecar.istio.io/status: injected
+spec:
+  containers:
+  - name: app
+    image: docker.io/library/nginx:latest
+    ports: []
+  - name: istio-proxy
+    image: proxy
+status:
+  phase: Pending
+`), &returned); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []DryRunChange{
+		{Path: "metadata.annotations.sidecar.istio.io/status", Change: "added", After: `"injected"`},
+		{Path: "metadata.labels.drop", Change: "removed", Before: `"me"`},
+		{Path: "spec.containers[0].image", Change: "changed", Before: `"nginx"`, After: `"docker.io/library/nginx:latest"`},
+		{Path: "spec.containers[0].ports", Change: "added", After: "[]"},
+		{Path: "spec.containers[1].image", Change: "added", After: `"proxy"`},
+		{Path: "spec.containers[1].name", Change: "added", After: `"istio-proxy"`},
+	}
+	if got := diffObjects(submitted, returned); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffObjects() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := diffObjects(submitted, submitted); len(got) != 0 {
+		t.Errorf("diffObjects() of identical objects = %+v", got)
+	}
+}
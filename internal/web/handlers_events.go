@@ -1,10 +1,16 @@
 package web
 
 import (
+	"context"
 	"net/http"
 	"sort"
+	"strings"
+	"time"
 
+	"github.com/rakeshavasarala/k8s-ui/internal/kube"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 )
 
 type EventView struct {
@@ -13,11 +19,47 @@ type EventView struct {
 	Message string
 	Object  string
 	Age     string
+	Time    string
+}
+
+// EventRange is a preset time range offered on the events page.
+type EventRange struct {
+	Key   string
+	Label string
 }
 
 type EventsListPage struct {
 	BasePage
-	Events []EventView
+	Events   []EventView
+	Query    *ListQuery
+	Ranges   []EventRange
+	Since    string
+	Absolute bool
+	History  string
+}
+
+// eventRanges are the time ranges the events page can be limited to.
+var eventRanges = []EventRange{
+	{Key: "15m", Label: "15m"},
+	{Key: "1h", Label: "1h"},
+	{Key: "6h", Label: "6h"},
+	{Key: "24h", Label: "24h"},
+	{Key: "168h", Label: "7d"},
+}
+
+// eventTimeLayout is used when events are shown with absolute timestamps.
+const eventTimeLayout = "2006-01-02 15:04:05 MST"
+
+// eventListSpec lists the most recent events first.
+var eventListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Type", Key: "type"},
+		{Label: "Reason", Key: "reason"},
+		{Label: "Object", Key: "object"},
+		{Label: "Message"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "age",
 }
 
 func (s *Server) handleEventsList(w http.ResponseWriter, r *http.Request) {
@@ -26,35 +68,127 @@ func (s *Server) handleEventsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	events, err := s.manager.Client().CoreV1().Events(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, eventListSpec)
+	var since time.Duration
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		for _, er := range eventRanges {
+			if er.Key == raw {
+				since, _ = time.ParseDuration(raw)
+				q.SetParam("since", raw)
+			}
+		}
+	}
+	absolute := r.URL.Query().Get("time") == "absolute"
+	if absolute {
+		q.SetParam("time", "absolute")
+		q.Columns[len(q.Columns)-1].Label = "Last Seen"
+	}
+
+	// With a history the live list is merged with recorded events, so the
+	// API server's paging cannot be used.
+	opts := q.ListOptions()
+	if s.history != nil {
+		q.Local = true
+		opts = metav1.ListOptions{}
+	}
+	events, err := s.manager.Client().CoreV1().Events(s.namespace(r)).List(r.Context(), opts)
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "events", "", "/events", "events") {
+		if s.handleK8sForbidden(w, r, err, "list", "events", "", "/events", "events") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = events.Continue
 
-	// Sort by LastTimestamp descending
-	sort.Slice(events.Items, func(i, j int) bool {
-		return events.Items[i].LastTimestamp.Time.After(events.Items[j].LastTimestamp.Time)
+	items := events.Items
+	var history string
+	if s.history != nil {
+		for i := range items {
+			s.history.Record(&items[i])
+		}
+		items = s.history.Events(s.namespace(r))
+		history = formatDuration(s.history.Retention())
+	}
+	if since > 0 {
+		cutoff := time.Now().Add(-since)
+		recent := items[:0]
+		for _, e := range items {
+			if !eventTime(e).Before(cutoff) {
+				recent = append(recent, e)
+			}
+		}
+		items = recent
+	}
+
+	sortItems(items, q, map[string]func(a, b *corev1.Event) int{
+		"type":   func(a, b *corev1.Event) int { return strings.Compare(a.Type, b.Type) },
+		"reason": func(a, b *corev1.Event) int { return strings.Compare(a.Reason, b.Reason) },
+		"object": func(a, b *corev1.Event) int {
+			return strings.Compare(a.InvolvedObject.Kind+"/"+a.InvolvedObject.Name, b.InvolvedObject.Kind+"/"+b.InvolvedObject.Name)
+		},
+		"age": func(a, b *corev1.Event) int { return eventTime(*b).Compare(eventTime(*a)) },
 	})
 
 	var views []EventView
-	for _, e := range events.Items {
+	for _, e := range items {
+		if !q.Keep(e.InvolvedObject.Name, e.Type) {
+			continue
+		}
+		at := eventTime(e)
 		views = append(views, EventView{
 			Type:    e.Type,
 			Reason:  e.Reason,
 			Message: e.Message,
 			Object:  e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name,
-			Age:     formatAge(e.LastTimestamp.Time),
+			Age:     formatAge(at),
+			Time:    at.Local().Format(eventTimeLayout),
 		})
 	}
 
 	data := EventsListPage{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "Events", Active: "events"},
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Events", Active: "events"},
 		Events:   views,
+		Query:    q,
+		Ranges:   eventRanges,
+		Since:    q.Param("since"),
+		Absolute: absolute,
+		History:  history,
 	}
 
 	s.renderTemplate(w, "events_list.html", data)
 }
+
+// objectEvents returns the events recorded for a single object, newest first.
+func (s *Server) objectEvents(ctx context.Context, namespace, kind, name string) ([]corev1.Event, error) {
+	selector := fields.Set{
+		"involvedObject.kind": kind,
+		"involvedObject.name": name,
+	}.AsSelector().String()
+
+	events, err := s.manager.Client().CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(events.Items, func(i, j int) bool {
+		return eventTime(events.Items[i]).After(eventTime(events.Items[j]))
+	})
+	return events.Items, nil
+}
+
+// eventTime returns the most recent time an event was observed.
+func eventTime(e corev1.Event) time.Time {
+	return kube.EventTime(e)
+}
+
+// eventCount returns how many times an event occurred, accounting for event series.
+func eventCount(e corev1.Event) int32 {
+	if e.Series != nil && e.Series.Count > 0 {
+		return e.Series.Count
+	}
+	if e.Count > 0 {
+		return e.Count
+	}
+	return 1
+}
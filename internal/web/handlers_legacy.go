@@ -0,0 +1,120 @@
+package web
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+)
+
+type ReplicationControllerView struct {
+	Name     string
+	Replicas string // ready/desired
+	Selector string
+	PodsURL  string
+	Images   []string
+	Age      string
+}
+
+type ReplicationControllersListPage struct {
+	BasePage
+	ReplicationControllers []ReplicationControllerView
+	Query                  *ListQuery
+}
+
+var replicationControllerListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Replicas", Key: "replicas"},
+		{Label: "Selector"},
+		{Label: "Images"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
+}
+
+// handleReplicationControllersList is only routed when
+// Config.ReplicationControllers is set, for older clusters that still run them.
+func (s *Server) handleReplicationControllersList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := s.listQuery(r, replicationControllerListSpec)
+	rcs, err := s.manager.Client().CoreV1().ReplicationControllers(s.namespace(r)).List(r.Context(), q.ListOptions())
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "list", "replicationcontrollers", "", "/replicationcontrollers", "replicationcontrollers") {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	q.Next = rcs.Continue
+
+	sortItems(rcs.Items, q, map[string]func(a, b *corev1.ReplicationController) int{
+		"replicas": func(a, b *corev1.ReplicationController) int {
+			return cmp.Compare(ptr.Deref(a.Spec.Replicas, 1), ptr.Deref(b.Spec.Replicas, 1))
+		},
+	})
+
+	var views []ReplicationControllerView
+	for _, rc := range rcs.Items {
+		if !q.Keep(rc.Name, "") {
+			continue
+		}
+		var images []string
+		if rc.Spec.Template != nil {
+			for _, c := range rc.Spec.Template.Spec.Containers {
+				images = append(images, c.Image)
+			}
+		}
+		selector := labels.SelectorFromSet(rc.Spec.Selector).String()
+		views = append(views, ReplicationControllerView{
+			Name:     rc.Name,
+			Replicas: fmt.Sprintf("%d/%d", rc.Status.ReadyReplicas, ptr.Deref(rc.Spec.Replicas, 1)),
+			Selector: selector,
+			PodsURL:  "/pods?" + url.Values{"selector": {selector}}.Encode(),
+			Images:   images,
+			Age:      formatAge(rc.CreationTimestamp.Time),
+		})
+	}
+
+	data := ReplicationControllersListPage{
+		BasePage:               BasePage{Namespace: s.namespace(r), Title: "ReplicationControllers", Active: "replicationcontrollers"},
+		ReplicationControllers: views,
+		Query:                  q,
+	}
+
+	s.renderTemplate(w, "replicationcontrollers_list.html", data)
+}
+
+func (s *Server) handleReplicationControllerYAML(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 3 {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+	name := parts[2]
+
+	rc, err := s.manager.Client().CoreV1().ReplicationControllers(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "get", "replicationcontrollers", name, "/replicationcontrollers", "replicationcontrollers") {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.renderYAML(w, r, "yaml_view.html", rc, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "replicationcontrollers"},
+		Name:     name,
+		Kind:     "replicationcontrollers",
+	})
+}
@@ -5,8 +5,11 @@ import (
 	"net/http"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/yaml"
+	"k8s.io/utils/ptr"
 )
 
 type ServicePortView struct {
@@ -28,6 +31,19 @@ type ServiceView struct {
 type ServicesListPage struct {
 	BasePage
 	Services []ServiceView
+	Query    *ListQuery
+}
+
+var serviceListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Type", Key: "type"},
+		{Label: "Cluster IP"},
+		{Label: "External IP"},
+		{Label: "Ports"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
 }
 
 func (s *Server) handleServicesList(w http.ResponseWriter, r *http.Request) {
@@ -36,17 +52,26 @@ func (s *Server) handleServicesList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	services, err := s.manager.Client().CoreV1().Services(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, serviceListSpec)
+	services, err := s.manager.Client().CoreV1().Services(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "services", "", "/services", "services") {
+		if s.handleK8sForbidden(w, r, err, "list", "services", "", "/services", "services") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = services.Continue
+
+	sortItems(services.Items, q, map[string]func(a, b *corev1.Service) int{
+		"type": func(a, b *corev1.Service) int { return strings.Compare(string(a.Spec.Type), string(b.Spec.Type)) },
+	})
 
 	var views []ServiceView
 	for _, svc := range services.Items {
+		if !q.Keep(svc.Name, "") {
+			continue
+		}
 		var ports []ServicePortView
 		for _, p := range svc.Spec.Ports {
 			ports = append(ports, ServicePortView{
@@ -99,8 +124,9 @@ func (s *Server) handleServicesList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := ServicesListPage{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "Services", Active: "services"},
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Services", Active: "services"},
 		Services: views,
+		Query:    q,
 	}
 
 	s.renderTemplate(w, "services_list.html", data)
@@ -114,35 +140,152 @@ func (s *Server) handleServiceYAML(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	svc, err := s.manager.Client().CoreV1().Services(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	svc, err := s.manager.Client().CoreV1().Services(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "services", name, "/services", "services") {
+		if s.handleK8sForbidden(w, r, err, "get", "services", name, "/services", "services") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	svc.ManagedFields = nil
-	y, err := yaml.Marshal(svc)
+	s.renderYAML(w, r, "yaml_view.html", svc, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "services"},
+		Name:     name,
+		Kind:     "services",
+	})
+}
+
+type LoadBalancerIngressView struct {
+	IP       string
+	Hostname string
+	IPMode   string
+	Ports    []string
+}
+
+type ServiceDetailPage struct {
+	BasePage
+	Name                  string
+	Type                  string
+	ClusterIPs            []string
+	ExternalIPs           []string
+	Ports                 []ServicePortView
+	Selector              map[string]string
+	ExternalTrafficPolicy string
+	LoadBalancerClass     string
+	SessionAffinity       string
+	Age                   string
+	IsLoadBalancer        bool
+	LBStatus              string
+	LBMessage             string
+	LBIngress             []LoadBalancerIngressView
+	Conditions            []metav1.Condition
+	Events                []EventView
+	Links                 ExternalLinks
+}
+
+func (s *Server) handleServiceDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/services/")
+
+	svc, err := s.manager.Client().CoreV1().Services(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "get", "services", name, "/services", "services") {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	data := struct {
-		BasePage
-		Name string
-		Kind string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "services"},
-		Name:     name,
-		Kind:     "services",
-		YAML:     string(y),
+	var ports []ServicePortView
+	for _, p := range svc.Spec.Ports {
+		ports = append(ports, ServicePortView{
+			Name:       p.Name,
+			Port:       p.Port,
+			TargetPort: p.TargetPort.String(),
+			Protocol:   string(p.Protocol),
+		})
+	}
+
+	data := ServiceDetailPage{
+		BasePage:              BasePage{Namespace: s.namespace(r), Title: "Service: " + name, Active: "services"},
+		Name:                  svc.Name,
+		Type:                  string(svc.Spec.Type),
+		ClusterIPs:            svc.Spec.ClusterIPs,
+		ExternalIPs:           svc.Spec.ExternalIPs,
+		Ports:                 ports,
+		Selector:              svc.Spec.Selector,
+		ExternalTrafficPolicy: string(svc.Spec.ExternalTrafficPolicy),
+		SessionAffinity:       string(svc.Spec.SessionAffinity),
+		Age:                   formatAge(svc.CreationTimestamp.Time),
+		IsLoadBalancer:        svc.Spec.Type == corev1.ServiceTypeLoadBalancer,
+		Conditions:            svc.Status.Conditions,
+		Links:                 annotationLinks(svc.Annotations),
+	}
+	if svc.Spec.LoadBalancerClass != nil {
+		data.LoadBalancerClass = *svc.Spec.LoadBalancerClass
+	}
+
+	for _, ing := range svc.Status.LoadBalancer.Ingress {
+		view := LoadBalancerIngressView{IP: ing.IP, Hostname: ing.Hostname}
+		if ing.IPMode != nil {
+			view.IPMode = string(*ing.IPMode)
+		}
+		for _, p := range ing.Ports {
+			port := fmt.Sprintf("%d/%s", p.Port, p.Protocol)
+			if p.Error != nil {
+				port += " (" + *p.Error + ")"
+			}
+			view.Ports = append(view.Ports, port)
+		}
+		data.LBIngress = append(data.LBIngress, view)
+	}
+
+	events, err := s.objectEvents(r.Context(), s.namespace(r), "Service", name)
+	if err != nil && !apierrors.IsForbidden(err) {
+		data.Warning = "Unable to load events: " + err.Error()
+	}
+	for _, e := range events {
+		data.Events = append(data.Events, EventView{
+			Type:    e.Type,
+			Reason:  e.Reason,
+			Message: e.Message,
+			Object:  e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name,
+			Age:     formatAge(eventTime(e)),
+		})
+	}
+
+	if data.IsLoadBalancer {
+		data.LBStatus, data.LBMessage = loadBalancerStatus(svc, events)
+	}
+
+	s.renderTemplate(w, "services_detail.html", data)
+}
+
+// loadBalancerStatus summarizes cloud load balancer provisioning for a
+// LoadBalancer service from its status and the service controller's events.
+func loadBalancerStatus(svc *corev1.Service, events []corev1.Event) (string, string) {
+	if len(svc.Status.LoadBalancer.Ingress) > 0 {
+		return "Provisioned", ""
+	}
+
+	// events are sorted newest first, so the first provisioning event is the latest state.
+	for _, e := range events {
+		switch e.Reason {
+		case "SyncLoadBalancerFailed", "CreatingLoadBalancerFailed", "UpdateLoadBalancerFailed":
+			return "Failed", e.Message
+		case "EnsuringLoadBalancer":
+			return "Provisioning", "The cloud provider is creating the load balancer."
+		}
 	}
 
-	s.renderTemplate(w, "yaml_view.html", data)
+	if svc.Spec.LoadBalancerClass != nil {
+		return "Pending", fmt.Sprintf("No events yet. Check that a controller for load balancer class %q is running.", *svc.Spec.LoadBalancerClass)
+	}
+	return "Pending", "No load balancer events were recorded. The cluster may not have a cloud controller or load balancer implementation (such as MetalLB) installed."
 }
 
 type IngressRuleView struct {
@@ -161,6 +304,18 @@ type IngressView struct {
 type IngressesListPage struct {
 	BasePage
 	Ingresses []IngressView
+	Query     *ListQuery
+}
+
+var ingressListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Class", Key: "class"},
+		{Label: "Hosts"},
+		{Label: "Paths"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
 }
 
 func (s *Server) handleIngressList(w http.ResponseWriter, r *http.Request) {
@@ -169,17 +324,28 @@ func (s *Server) handleIngressList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ingresses, err := s.manager.Client().NetworkingV1().Ingresses(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, ingressListSpec)
+	ingresses, err := s.manager.Client().NetworkingV1().Ingresses(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "ingresses", "", "/ingresses", "ingresses") {
+		if s.handleK8sForbidden(w, r, err, "list", "ingresses", "", "/ingresses", "ingresses") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = ingresses.Continue
+
+	sortItems(ingresses.Items, q, map[string]func(a, b *networkingv1.Ingress) int{
+		"class": func(a, b *networkingv1.Ingress) int {
+			return strings.Compare(ptr.Deref(a.Spec.IngressClassName, ""), ptr.Deref(b.Spec.IngressClassName, ""))
+		},
+	})
 
 	var views []IngressView
 	for _, ing := range ingresses.Items {
+		if !q.Keep(ing.Name, "") {
+			continue
+		}
 		// Build map of TLS hosts
 		tlsHosts := make(map[string]bool)
 		for _, tls := range ing.Spec.TLS {
@@ -236,8 +402,9 @@ func (s *Server) handleIngressList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := IngressesListPage{
-		BasePage:  BasePage{Namespace: s.manager.Namespace(), Title: "Ingresses", Active: "ingresses"},
+		BasePage:  BasePage{Namespace: s.namespace(r), Title: "Ingresses", Active: "ingresses"},
 		Ingresses: views,
+		Query:     q,
 	}
 
 	s.renderTemplate(w, "ingresses_list.html", data)
@@ -251,33 +418,18 @@ func (s *Server) handleIngressYAML(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	ing, err := s.manager.Client().NetworkingV1().Ingresses(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	ing, err := s.manager.Client().NetworkingV1().Ingresses(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "ingresses", name, "/ingresses", "ingresses") {
+		if s.handleK8sForbidden(w, r, err, "get", "ingresses", name, "/ingresses", "ingresses") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	ing.ManagedFields = nil
-	y, err := yaml.Marshal(ing)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	data := struct {
-		BasePage
-		Name string
-		Kind string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "ingresses"},
+	s.renderYAML(w, r, "yaml_view.html", ing, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "ingresses"},
 		Name:     name,
 		Kind:     "ingresses",
-		YAML:     string(y),
-	}
-
-	s.renderTemplate(w, "yaml_view.html", data)
+	})
 }
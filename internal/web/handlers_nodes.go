@@ -0,0 +1,525 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rakeshavasarala/k8s-ui/internal/kube"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// mirrorPodAnnotation marks the API copy of a static pod; drain skips these.
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// DrainPod is a pod on the node and what a drain would do with it.
+type DrainPod struct {
+	Namespace string
+	Name      string
+	Owner     string
+	Notes     []string
+}
+
+// DrainReport groups the pods on a node the way "kubectl drain" would
+// treat them.
+type DrainReport struct {
+	// Evictable pods would be evicted and recreated by their controller.
+	Evictable []DrainPod
+	// Blocked pods cannot be evicted yet because a PodDisruptionBudget
+	// allows no more disruptions.
+	Blocked []DrainPod
+	// Unmanaged pods have no controller and would be lost.
+	Unmanaged []DrainPod
+	// Skipped pods are left on the node (DaemonSet and static pods).
+	Skipped []DrainPod
+}
+
+type NodeDrainPage struct {
+	BasePage
+	Node         string
+	Cordoned     bool
+	Report       DrainReport
+	Scope        string
+	DrainWarning string
+}
+
+// NodeConditionView is a current node condition with an indication of
+// whether its status is the healthy one.
+type NodeConditionView struct {
+	Type       string
+	Status     string
+	Healthy    bool
+	Reason     string
+	Message    string
+	Transition string
+}
+
+// NodeTimelineEntry is a node condition change or kubelet lifecycle event
+// reconstructed from a node event.
+type NodeTimelineEntry struct {
+	Time      time.Time
+	Age       string
+	Condition string
+	State     string
+	Healthy   bool
+	Reason    string
+	Message   string
+	Count     int32
+	Source    string
+}
+
+// NodeConditionSummary counts how often a condition turned unhealthy in the
+// events still retained by the API server.
+type NodeConditionSummary struct {
+	Condition string
+	Unhealthy int
+	Recovered int
+	Last      string
+}
+
+type NodeDetailPage struct {
+	BasePage
+	Name          string
+	Ready         string
+	Cordoned      bool
+	Roles         string
+	KubeletVer    string
+	OSImage       string
+	Platform      string
+	InternalIP    string
+	Age           string
+	Conditions    []NodeConditionView
+	Timeline      []NodeTimelineEntry
+	Summary       []NodeConditionSummary
+	EventsWarning string
+	Links         ExternalLinks
+
+	Resources        []NodeResourceView
+	ExtendedPods     []ExtendedResourcePod
+	ResourcesWarning string
+}
+
+// nodeEventConditions maps the reasons the kubelet and node controller use
+// for node events to the condition they report and its new status.
+var nodeEventConditions = map[string]struct {
+	condition string
+	state     string
+	healthy   bool
+}{
+	"NodeReady":                 {"Ready", "True", true},
+	"NodeNotReady":              {"Ready", "False", false},
+	"NodeHasSufficientMemory":   {"MemoryPressure", "False", true},
+	"NodeHasInsufficientMemory": {"MemoryPressure", "True", false},
+	"NodeHasNoDiskPressure":     {"DiskPressure", "False", true},
+	"NodeHasDiskPressure":       {"DiskPressure", "True", false},
+	"NodeHasSufficientPID":      {"PIDPressure", "False", true},
+	"NodeHasInsufficientPID":    {"PIDPressure", "True", false},
+	"NodeSchedulable":           {"Schedulable", "True", true},
+	"NodeNotSchedulable":        {"Schedulable", "False", false},
+	"EvictionThresholdMet":      {"Eviction", "Threshold met", false},
+	"Rebooted":                  {"Kubelet", "Rebooted", false},
+	"Starting":                  {"Kubelet", "Starting", true},
+}
+
+// nodeConditionOrder is the display order of the timeline summary.
+var nodeConditionOrder = []string{"Ready", "MemoryPressure", "DiskPressure", "PIDPressure", "Schedulable", "Eviction", "Kubelet"}
+
+func (s *Server) handleNodeDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/nodes/")
+
+	node, err := s.manager.Client().CoreV1().Nodes().Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		if s.handleK8sUnauthorized(w, r, err, "/pods", "pods") {
+			return
+		}
+		if apierrors.IsForbidden(err) {
+			s.renderPermissionDenied(w, r, "Access denied for nodes",
+				fmt.Sprintf("You are not allowed to get nodes/%s. Nodes are cluster-scoped and need a ClusterRole.", name), "/pods", "pods")
+			return
+		}
+		if apierrors.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("Node %s not found", name), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Node events are usually recorded in the default namespace, but read
+	// all namespaces when allowed so none are missed.
+	var eventsWarning string
+	events, err := s.objectEvents(r.Context(), metav1.NamespaceAll, "Node", name)
+	if apierrors.IsForbidden(err) {
+		events, err = s.objectEvents(r.Context(), metav1.NamespaceDefault, "Node", name)
+	}
+	if err != nil {
+		eventsWarning = "Node events could not be listed, so the condition timeline is unavailable: " + err.Error()
+	}
+	timeline, summary := nodeConditionTimeline(events)
+
+	data := NodeDetailPage{
+		BasePage:      BasePage{Namespace: s.namespace(r), Title: "Node: " + name, Active: "pods"},
+		Name:          node.Name,
+		Ready:         "Unknown",
+		Cordoned:      node.Spec.Unschedulable,
+		Roles:         nodeRoles(node.Labels),
+		KubeletVer:    node.Status.NodeInfo.KubeletVersion,
+		OSImage:       node.Status.NodeInfo.OSImage,
+		Platform:      nodePlatform(node),
+		Age:           formatAge(node.CreationTimestamp.Time),
+		Timeline:      timeline,
+		Summary:       summary,
+		EventsWarning: eventsWarning,
+		Links:         annotationLinks(node.Annotations),
+	}
+	pods, _, warning, err := s.nodePods(r.Context(), node.Name, s.namespace(r))
+	if err != nil {
+		warning = "Pods on this node could not be listed, so requests are not shown: " + err.Error()
+	} else if warning != "" {
+		warning = "Requests only count the pods in namespaces that could be read. " + warning
+	}
+	data.Resources, data.ExtendedPods = nodeResources(node, pods)
+	data.ResourcesWarning = warning
+	if err != nil {
+		for i := range data.Resources {
+			data.Resources[i].Requested = "-"
+			data.Resources[i].Bar = PercentBar{Class: "status-neutral", Label: "-"}
+		}
+	}
+
+	for _, a := range node.Status.Addresses {
+		if a.Type == corev1.NodeInternalIP {
+			data.InternalIP = a.Address
+			break
+		}
+	}
+	for _, c := range node.Status.Conditions {
+		healthy := c.Status == corev1.ConditionFalse
+		if c.Type == corev1.NodeReady {
+			healthy = c.Status == corev1.ConditionTrue
+			data.Ready = string(c.Status)
+		}
+		data.Conditions = append(data.Conditions, NodeConditionView{
+			Type:       string(c.Type),
+			Status:     string(c.Status),
+			Healthy:    healthy,
+			Reason:     c.Reason,
+			Message:    c.Message,
+			Transition: formatAge(c.LastTransitionTime.Time),
+		})
+	}
+
+	s.renderTemplate(w, "nodes_detail.html", data)
+}
+
+// nodeRoles returns the roles from node-role.kubernetes.io/<role> labels.
+func nodeRoles(nodeLabels map[string]string) string {
+	var roles []string
+	for k := range nodeLabels {
+		if role, ok := strings.CutPrefix(k, "node-role.kubernetes.io/"); ok && role != "" {
+			roles = append(roles, role)
+		}
+	}
+	if len(roles) == 0 {
+		return "-"
+	}
+	sort.Strings(roles)
+	return strings.Join(roles, ", ")
+}
+
+// nodeConditionTimeline turns node events into condition changes, newest
+// first, and counts the unhealthy transitions per condition. Events with
+// other reasons are left out. An event that was repeated counts once per
+// occurrence, since each repeat is a separate flap.
+func nodeConditionTimeline(events []corev1.Event) ([]NodeTimelineEntry, []NodeConditionSummary) {
+	var timeline []NodeTimelineEntry
+	counts := map[string]*NodeConditionSummary{}
+	for _, e := range events {
+		c, ok := nodeEventConditions[e.Reason]
+		if !ok {
+			continue
+		}
+		count := eventCount(e)
+		at := eventTime(e)
+		timeline = append(timeline, NodeTimelineEntry{
+			Time:      at,
+			Age:       formatAge(at),
+			Condition: c.condition,
+			State:     c.state,
+			Healthy:   c.healthy,
+			Reason:    e.Reason,
+			Message:   e.Message,
+			Count:     count,
+			Source:    e.Source.Component,
+		})
+
+		sum := counts[c.condition]
+		if sum == nil {
+			sum = &NodeConditionSummary{Condition: c.condition}
+			counts[c.condition] = sum
+		}
+		if c.healthy {
+			sum.Recovered += int(count)
+		} else {
+			sum.Unhealthy += int(count)
+		}
+	}
+	sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].Time.After(timeline[j].Time) })
+
+	var summary []NodeConditionSummary
+	for _, cond := range nodeConditionOrder {
+		sum := counts[cond]
+		if sum == nil {
+			continue
+		}
+		for _, t := range timeline {
+			if t.Condition == cond {
+				sum.Last = t.State
+				break
+			}
+		}
+		summary = append(summary, *sum)
+	}
+	return timeline, summary
+}
+
+func (s *Server) handleNodeDrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 3 || parts[2] == "" {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+	name := parts[2]
+	client := s.manager.Client()
+
+	var cordoned bool
+	// Namespace-scoped identities usually cannot read nodes; the pod and PDB
+	// lists are what matter, so only a missing node is fatal.
+	node, err := client.CoreV1().Nodes().Get(r.Context(), name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		cordoned = node.Spec.Unschedulable
+	case apierrors.IsNotFound(err):
+		http.Error(w, fmt.Sprintf("Node %s not found", name), http.StatusNotFound)
+		return
+	case s.handleK8sUnauthorized(w, r, err, "/pods", "pods"):
+		return
+	}
+
+	pods, pdbs, scope, warning, err := s.nodeDrainInputs(r.Context(), name, s.namespace(r))
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "list", "pods", "", "/pods", "pods") {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := NodeDrainPage{
+		BasePage:     BasePage{Namespace: s.namespace(r), Title: "Drain simulation: " + name, Active: "pods"},
+		Node:         name,
+		Cordoned:     cordoned,
+		Report:       simulateDrain(pods, pdbs),
+		Scope:        scope,
+		DrainWarning: warning,
+	}
+	s.renderTemplate(w, "nodes_drain.html", data)
+}
+
+// nodePods lists the pods scheduled on the node. With POD_NAMESPACES only
+// the allowed namespaces are read; otherwise all namespaces are tried,
+// falling back to the current namespace when the identity cannot list
+// cluster-wide. scope describes what was read, and warning is set when
+// pods in other namespaces may be missing.
+func (s *Server) nodePods(ctx context.Context, node, namespace string) (pods []corev1.Pod, scope, warning string, err error) {
+	client := s.manager.Client()
+	podOpts := metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("spec.nodeName", node).String()}
+
+	namespaces := s.manager.AllowedNamespaces()
+	if len(namespaces) == 0 {
+		list, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, podOpts)
+		switch {
+		case err == nil:
+			pods = list.Items
+			scope = "all namespaces"
+		case apierrors.IsForbidden(err):
+			namespaces = []string{namespace}
+		default:
+			return nil, "", "", err
+		}
+	}
+	if len(namespaces) > 0 {
+		scope = "namespace " + strings.Join(namespaces, ", ")
+		if len(namespaces) > 1 {
+			scope = "namespaces " + strings.Join(namespaces, ", ")
+		}
+		warning = fmt.Sprintf("Only pods in %s were checked; pods in other namespaces on this node are not shown.", scope)
+
+		found := make([][]corev1.Pod, len(namespaces))
+		tasks := make([]kube.Task, len(namespaces))
+		for i, ns := range namespaces {
+			tasks[i] = kube.Task{Name: ns, Run: func(ctx context.Context) error {
+				list, err := client.CoreV1().Pods(ns).List(ctx, podOpts)
+				if err != nil {
+					return err
+				}
+				found[i] = list.Items
+				return nil
+			}}
+		}
+		if failed := kube.FanOut(ctx, 0, tasks); len(failed) > 0 {
+			return nil, "", "", failed[0].Err
+		}
+		for _, list := range found {
+			pods = append(pods, list...)
+		}
+	}
+
+	// Field selectors are advisory for some clients, so filter again.
+	onNode := pods[:0]
+	for _, p := range pods {
+		if p.Spec.NodeName == node {
+			onNode = append(onNode, p)
+		}
+	}
+	return onNode, scope, warning, nil
+}
+
+// nodeDrainInputs lists the pods scheduled on the node, as nodePods does,
+// and the PDBs that may cover them.
+func (s *Server) nodeDrainInputs(ctx context.Context, node, namespace string) (pods []corev1.Pod, pdbs []policyv1.PodDisruptionBudget, scope, warning string, err error) {
+	pods, scope, warning, err = s.nodePods(ctx, node, namespace)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+	podNamespaces := map[string]struct{}{}
+	for _, p := range pods {
+		podNamespaces[p.Namespace] = struct{}{}
+	}
+
+	// PDBs only matter in namespaces that have pods on the node.
+	var unreadable []string
+	for ns := range podNamespaces {
+		list, err := s.manager.Client().PolicyV1().PodDisruptionBudgets(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			unreadable = append(unreadable, ns)
+			continue
+		}
+		pdbs = append(pdbs, list.Items...)
+	}
+	if len(unreadable) > 0 {
+		sort.Strings(unreadable)
+		if warning != "" {
+			warning += " "
+		}
+		warning += "PodDisruptionBudgets could not be read in " + strings.Join(unreadable, ", ") + ", so pods there may be blocked without being reported."
+	}
+	return pods, pdbs, scope, warning, nil
+}
+
+// simulateDrain classifies pods as "kubectl drain --ignore-daemonsets" would
+// handle them. Evictions are modelled one at a time, so each pod covered by a
+// PDB uses up one of its allowed disruptions.
+func simulateDrain(pods []corev1.Pod, pdbs []policyv1.PodDisruptionBudget) DrainReport {
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+
+	remaining := make([]int32, len(pdbs))
+	selectors := make([]labels.Selector, len(pdbs))
+	for i, pdb := range pdbs {
+		remaining[i] = pdb.Status.DisruptionsAllowed
+		sel, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			sel = labels.Nothing()
+		}
+		selectors[i] = sel
+	}
+
+	var report DrainReport
+	for _, p := range pods {
+		dp := DrainPod{Namespace: p.Namespace, Name: p.Name}
+		owner := metav1.GetControllerOf(&p)
+		if owner != nil {
+			dp.Owner = owner.Kind + "/" + owner.Name
+		}
+
+		if _, ok := p.Annotations[mirrorPodAnnotation]; ok {
+			dp.Notes = append(dp.Notes, "Static pod; managed by the kubelet")
+			report.Skipped = append(report.Skipped, dp)
+			continue
+		}
+		if owner != nil && owner.Kind == "DaemonSet" {
+			dp.Notes = append(dp.Notes, "DaemonSet pod; stays on the node")
+			report.Skipped = append(report.Skipped, dp)
+			continue
+		}
+		if p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			dp.Notes = append(dp.Notes, "Already finished; deleted without disruption")
+			report.Evictable = append(report.Evictable, dp)
+			continue
+		}
+		for _, v := range p.Spec.Volumes {
+			if v.EmptyDir != nil {
+				dp.Notes = append(dp.Notes, "emptyDir data in volume "+v.Name+" would be lost")
+			}
+		}
+
+		var matched []int
+		for i := range pdbs {
+			if pdbs[i].Namespace == p.Namespace && selectors[i].Matches(labels.Set(p.Labels)) {
+				matched = append(matched, i)
+			}
+		}
+		blocked := false
+		switch {
+		case len(matched) > 1:
+			var names []string
+			for _, i := range matched {
+				names = append(names, pdbs[i].Name)
+			}
+			dp.Notes = append(dp.Notes, "Covered by more than one PodDisruptionBudget ("+strings.Join(names, ", ")+"); the API server refuses to evict it")
+			blocked = true
+		case len(matched) == 1:
+			i := matched[0]
+			if remaining[i] > 0 {
+				remaining[i]--
+				dp.Notes = append(dp.Notes, fmt.Sprintf("Allowed by PodDisruptionBudget %s", pdbs[i].Name))
+			} else {
+				dp.Notes = append(dp.Notes, fmt.Sprintf("PodDisruptionBudget %s allows no more disruptions", pdbs[i].Name))
+				blocked = true
+			}
+		}
+
+		switch {
+		case owner == nil:
+			dp.Notes = append(dp.Notes, "No controller; the pod would not be recreated")
+			report.Unmanaged = append(report.Unmanaged, dp)
+		case blocked:
+			report.Blocked = append(report.Blocked, dp)
+		default:
+			report.Evictable = append(report.Evictable, dp)
+		}
+	}
+	return report
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSimulateDrain(t *testing.T) {
+	yes := true
+	pod := func(name, ownerKind string, labels map[string]string) corev1.Pod {
+		p := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels}}
+		if ownerKind != "" {
+			p.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: name + "-owner", Controller: &yes}}
+		}
+		return p
+	}
+	pdb := func(name string, allowed int32, app string) policyv1.PodDisruptionBudget {
+		return policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+
+	static := pod("etcd", "Node", nil)
+	static.Annotations = map[string]string{mirrorPodAnnotation: "x"}
+	done := pod("job-1", "Job", map[string]string{"app": "db"})
+	done.Status.Phase = corev1.PodSucceeded
+
+	pods := []corev1.Pod{
+		pod("web-a", "ReplicaSet", map[string]string{"app": "web"}),
+		pod("web-b", "ReplicaSet", map[string]string{"app": "web"}),
+		pod("db-0", "StatefulSet", map[string]string{"app": "db"}),
+		pod("debug", "", nil),
+		pod("fluentd", "DaemonSet", nil),
+		static,
+		done,
+	}
+	pdbs := []policyv1.PodDisruptionBudget{pdb("web", 1, "web"), pdb("db", 0, "db")}
+
+	report := simulateDrain(pods, pdbs)
+
+	names := func(pods []DrainPod) []string {
+		var out []string
+		for _, p := range pods {
+			out = append(out, p.Name)
+		}
+		return out
+	}
+	check := func(what string, got []DrainPod, want ...string) {
+		t.Helper()
+		g := names(got)
+		if len(g) != len(want) {
+			t.Errorf("%s = %v, want %v", what, g, want)
+			return
+		}
+		for i := range want {
+			if g[i] != want[i] {
+				t.Errorf("%s = %v, want %v", what, g, want)
+				return
+			}
+		}
+	}
+
+	// The web PDB allows one disruption, so only the first web pod fits.
+	check("Evictable", report.Evictable, "job-1", "web-a")
+	check("Blocked", report.Blocked, "db-0", "web-b")
+	check("Unmanaged", report.Unmanaged, "debug")
+	check("Skipped", report.Skipped, "etcd", "fluentd")
+}
+
+func TestNodeConditionTimeline(t *testing.T) {
+	now := time.Now()
+	event := func(reason string, ago time.Duration, count int32) corev1.Event {
+		return corev1.Event{Reason: reason, Count: count, LastTimestamp: metav1.NewTime(now.Add(-ago))}
+	}
+	events := []corev1.Event{
+		event("NodeNotReady", 30*time.Minute, 3),
+		event("NodeReady", 20*time.Minute, 2),
+		event("NodeHasInsufficientMemory", 10*time.Minute, 1),
+		event("ImageGCFailed", 5*time.Minute, 1),
+		event("NodeHasSufficientMemory", time.Minute, 0),
+	}
+
+	timeline, summary := nodeConditionTimeline(events)
+	if len(timeline) != 4 {
+		t.Fatalf("timeline has %d entries, want 4: %+v", len(timeline), timeline)
+	}
+	if timeline[0].Reason != "NodeHasSufficientMemory" || !timeline[0].Healthy || timeline[0].Count != 1 {
+		t.Errorf("newest entry = %+v", timeline[0])
+	}
+	if timeline[3].Condition != "Ready" || timeline[3].State != "False" || timeline[3].Healthy {
+		t.Errorf("oldest entry = %+v", timeline[3])
+	}
+
+	want := []NodeConditionSummary{
+		{Condition: "Ready", Unhealthy: 3, Recovered: 2, Last: "True"},
+		{Condition: "MemoryPressure", Unhealthy: 1, Recovered: 1, Last: "False"},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+}
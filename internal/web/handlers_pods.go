@@ -1,21 +1,22 @@
 package web
 
 import (
-	"bufio"
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/remotecommand"
-	"sigs.k8s.io/yaml"
 )
 
 type PodView struct {
@@ -29,7 +30,20 @@ type PodView struct {
 
 type PodsListPage struct {
 	BasePage
-	Pods []PodView
+	Pods  []PodView
+	Query *ListQuery
+}
+
+var podListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Ready"},
+		{Label: "Status", Key: "status"},
+		{Label: "Restarts", Key: "restarts"},
+		{Label: "Age", Key: "age"},
+		{Label: "Node", Key: "node"},
+	},
+	DefaultSort: "name",
 }
 
 func (s *Server) handlePodsList(w http.ResponseWriter, r *http.Request) {
@@ -38,17 +52,28 @@ func (s *Server) handlePodsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pods, err := s.manager.Client().CoreV1().Pods(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, podListSpec)
+	pods, err := s.manager.Client().CoreV1().Pods(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "pods", "", "/pods", "pods") {
+		if s.handleK8sForbidden(w, r, err, "list", "pods", "", "/pods", "pods") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = pods.Continue
+
+	sortItems(pods.Items, q, map[string]func(a, b *corev1.Pod) int{
+		"status":   func(a, b *corev1.Pod) int { return strings.Compare(string(a.Status.Phase), string(b.Status.Phase)) },
+		"restarts": func(a, b *corev1.Pod) int { return cmp.Compare(totalRestarts(*a), totalRestarts(*b)) },
+		"node":     func(a, b *corev1.Pod) int { return strings.Compare(a.Spec.NodeName, b.Spec.NodeName) },
+	})
 
 	var views []PodView
 	for _, p := range pods.Items {
+		if !q.Keep(p.Name, string(p.Status.Phase)) {
+			continue
+		}
 		views = append(views, PodView{
 			Name:     p.Name,
 			Ready:    readyContainers(p),
@@ -60,8 +85,9 @@ func (s *Server) handlePodsList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := PodsListPage{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "Pods", Active: "pods"},
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Pods", Active: "pods"},
 		Pods:     views,
+		Query:    q,
 	}
 
 	s.renderTemplate(w, "pods_list.html", data)
@@ -72,32 +98,93 @@ type PodContainerView struct {
 	Image    string
 	Ready    bool
 	Restarts int32
+	Probes   []ProbeView
+}
+
+// ProbeView describes a configured container probe and how often it has failed recently.
+type ProbeView struct {
+	Kind       string // Liveness, Readiness or Startup
+	Handler    string
+	Thresholds string
+	Failures   int32
+}
+
+// ProbeEventView is a recent probe failure reported by the kubelet.
+type ProbeEventView struct {
+	Container string
+	Message   string
+	Count     int32
+	Age       string
+}
+
+// HostPortView is a container port bound on the node.
+type HostPortView struct {
+	Container string
+	HostPort  int32
+	Port      int32
+	Protocol  string
+	HostIP    string
 }
 
 type PodDetailPage struct {
 	BasePage
-	Name       string
-	Status     string
-	Node       string
-	IP         string
-	Age        string
-	Labels     map[string]string
-	Containers []PodContainerView
-	Conditions []corev1.PodCondition
+	Name        string
+	Status      string
+	Node        string
+	IP          string
+	Age         string
+	Labels      map[string]string
+	Containers  []PodContainerView
+	Conditions  []corev1.PodCondition
+	ProbeEvents []ProbeEventView
+
+	PodIPs         []string
+	HostIP         string
+	HostNetwork    bool
+	HostPorts      []HostPortView
+	DNSPolicy      string
+	DNSConfig      *corev1.PodDNSConfig
+	Hostname       string
+	ServiceAccount string
+	Projections    []ProjectedVolumeView
+	Links          ExternalLinks
+
+	// SchedulingConflicts explain why a pending pod fits no node.
+	SchedulingConflicts []SchedulingConflict
+	// NodeFits say which nodes the pending pod's taint and node affinity
+	// rules allow.
+	NodeFits []NodeFitSummary
+
+	VolumeEvents []VolumeEventView
+	Claims       []PodClaimView
+	ImagePulls   []ImagePullView
+	// PlatformMismatches are containers whose image has no build for the
+	// node's architecture.
+	PlatformMismatches []PlatformMismatchView
+
+	// Usage is the containers' current usage against their limits, when
+	// metrics-server has it; UsageAge is how old the sample is.
+	Usage    []ContainerUsageView
+	UsageAge string
 }
 
 func (s *Server) handlePodDetail(w http.ResponseWriter, r *http.Request) {
 	name := strings.TrimPrefix(r.URL.Path, "/pods/")
 
-	pod, err := s.manager.Client().CoreV1().Pods(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	pod, err := s.manager.Client().CoreV1().Pods(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "pods", name, "/pods", "pods") {
+		if s.handleK8sForbidden(w, r, err, "get", "pods", name, "/pods", "pods") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// Probe failures are reported as "Unhealthy" events; they are optional context,
+	// so a failure to list events should not break the page.
+	events, _ := s.objectEvents(r.Context(), s.namespace(r), "Pod", name)
+	probeEvents, probeFailures := probeFailureEvents(events)
+
 	var containers []PodContainerView
 	for _, c := range pod.Spec.Containers {
 		var restarts int32
@@ -114,24 +201,213 @@ func (s *Server) handlePodDetail(w http.ResponseWriter, r *http.Request) {
 			Image:    c.Image,
 			Ready:    ready,
 			Restarts: restarts,
+			Probes:   containerProbes(c, probeFailures),
 		})
 	}
 
 	data := PodDetailPage{
-		BasePage:   BasePage{Namespace: s.manager.Namespace(), Title: "Pod: " + name, Active: "pods"},
-		Name:       pod.Name,
-		Status:     string(pod.Status.Phase),
-		Node:       pod.Spec.NodeName,
-		IP:         pod.Status.PodIP,
-		Age:        formatAge(pod.CreationTimestamp.Time),
-		Labels:     pod.Labels,
-		Containers: containers,
-		Conditions: pod.Status.Conditions,
+		BasePage:    BasePage{Namespace: s.namespace(r), Title: "Pod: " + name, Active: "pods"},
+		Name:        pod.Name,
+		Status:      string(pod.Status.Phase),
+		Node:        pod.Spec.NodeName,
+		IP:          pod.Status.PodIP,
+		Age:         formatAge(pod.CreationTimestamp.Time),
+		Labels:      pod.Labels,
+		Containers:  containers,
+		Conditions:  pod.Status.Conditions,
+		ProbeEvents: probeEvents,
+
+		HostIP:         pod.Status.HostIP,
+		HostNetwork:    pod.Spec.HostNetwork,
+		HostPorts:      podHostPorts(&pod.Spec),
+		DNSPolicy:      string(pod.Spec.DNSPolicy),
+		DNSConfig:      pod.Spec.DNSConfig,
+		Hostname:       podHostname(pod),
+		ServiceAccount: serviceAccountName(pod),
+		Projections:    projectedVolumes(pod),
+		Links:          annotationLinks(pod.Annotations),
+
+		VolumeEvents: volumeFailureEvents(events),
+		Claims:       s.podClaimViews(r.Context(), pod),
+		ImagePulls:   s.podImagePullViews(r.Context(), pod, events),
+
+		PlatformMismatches: s.podPlatformMismatches(r.Context(), pod),
+	}
+	for _, ip := range pod.Status.PodIPs {
+		data.PodIPs = append(data.PodIPs, ip.IP)
+	}
+	if len(data.PodIPs) == 0 && pod.Status.PodIP != "" {
+		data.PodIPs = []string{pod.Status.PodIP}
+	}
+	if data.DNSPolicy == "" {
+		data.DNSPolicy = string(corev1.DNSClusterFirst)
+	}
+	if pod.Status.Phase == corev1.PodRunning {
+		if usage, sampled, err := s.podUsage(r.Context(), pod); err == nil {
+			data.Usage = usage
+			data.UsageAge = formatAge(sampled)
+		}
+	}
+	if pod.Status.Phase == corev1.PodPending && pod.Spec.NodeName == "" {
+		data.SchedulingConflicts, data.NodeFits = s.podSchedulingConflicts(r.Context(), pod)
 	}
 
 	s.renderTemplate(w, "pods_detail.html", data)
 }
 
+// podHostPorts returns the container ports that are bound on the node, including
+// init containers that run as sidecars.
+func podHostPorts(spec *corev1.PodSpec) []HostPortView {
+	var ports []HostPortView
+	collect := func(containers []corev1.Container) {
+		for _, c := range containers {
+			for _, p := range c.Ports {
+				// With hostNetwork every container port is a host port.
+				if p.HostPort == 0 && !spec.HostNetwork {
+					continue
+				}
+				hostPort := p.HostPort
+				if hostPort == 0 {
+					hostPort = p.ContainerPort
+				}
+				protocol := string(p.Protocol)
+				if protocol == "" {
+					protocol = string(corev1.ProtocolTCP)
+				}
+				ports = append(ports, HostPortView{
+					Container: c.Name,
+					HostPort:  hostPort,
+					Port:      p.ContainerPort,
+					Protocol:  protocol,
+					HostIP:    p.HostIP,
+				})
+			}
+		}
+	}
+	collect(spec.InitContainers)
+	collect(spec.Containers)
+	return ports
+}
+
+// podHostname returns the pod's hostname, with the subdomain it resolves
+// under when one is set.
+func podHostname(pod *corev1.Pod) string {
+	hostname := pod.Spec.Hostname
+	if hostname == "" {
+		hostname = pod.Name
+	}
+	if pod.Spec.Subdomain != "" {
+		return hostname + "." + pod.Spec.Subdomain + "." + pod.Namespace + ".svc"
+	}
+	return hostname
+}
+
+// containerProbes describes the liveness, readiness and startup probes of a container.
+// failures is keyed by container name and probe kind, as returned by probeFailureEvents.
+func containerProbes(c corev1.Container, failures map[string]int32) []ProbeView {
+	probes := []struct {
+		kind  string
+		probe *corev1.Probe
+	}{
+		{"Startup", c.StartupProbe},
+		{"Liveness", c.LivenessProbe},
+		{"Readiness", c.ReadinessProbe},
+	}
+
+	var views []ProbeView
+	for _, p := range probes {
+		if p.probe == nil {
+			continue
+		}
+		views = append(views, ProbeView{
+			Kind:       p.kind,
+			Handler:    probeHandler(p.probe),
+			Thresholds: probeThresholds(p.probe),
+			Failures:   failures[c.Name+"/"+p.kind],
+		})
+	}
+	return views
+}
+
+func probeHandler(p *corev1.Probe) string {
+	switch {
+	case p.HTTPGet != nil:
+		scheme := strings.ToLower(string(p.HTTPGet.Scheme))
+		if scheme == "" {
+			scheme = "http"
+		}
+		host := p.HTTPGet.Host
+		return fmt.Sprintf("%s-get %s://%s:%s%s", scheme, scheme, host, p.HTTPGet.Port.String(), p.HTTPGet.Path)
+	case p.TCPSocket != nil:
+		return fmt.Sprintf("tcp-socket :%s", p.TCPSocket.Port.String())
+	case p.GRPC != nil:
+		if p.GRPC.Service != nil && *p.GRPC.Service != "" {
+			return fmt.Sprintf("grpc :%d service=%s", p.GRPC.Port, *p.GRPC.Service)
+		}
+		return fmt.Sprintf("grpc :%d", p.GRPC.Port)
+	case p.Exec != nil:
+		return "exec " + strings.Join(p.Exec.Command, " ")
+	}
+	return "-"
+}
+
+// probeThresholds formats probe timings using the same defaults the kubelet applies.
+func probeThresholds(p *corev1.Probe) string {
+	timeout, period, success, failure := p.TimeoutSeconds, p.PeriodSeconds, p.SuccessThreshold, p.FailureThreshold
+	if timeout == 0 {
+		timeout = 1
+	}
+	if period == 0 {
+		period = 10
+	}
+	if success == 0 {
+		success = 1
+	}
+	if failure == 0 {
+		failure = 3
+	}
+	return fmt.Sprintf("delay=%ds timeout=%ds period=%ds #success=%d #failure=%d",
+		p.InitialDelaySeconds, timeout, period, success, failure)
+}
+
+// probeFailureEvents extracts probe failures from a pod's events. It returns the
+// events for display and the failure counts keyed by "container/ProbeKind".
+func probeFailureEvents(events []corev1.Event) ([]ProbeEventView, map[string]int32) {
+	var views []ProbeEventView
+	failures := make(map[string]int32)
+	for _, e := range events {
+		if e.Reason != "Unhealthy" {
+			continue
+		}
+		container := containerFromFieldPath(e.InvolvedObject.FieldPath)
+		count := eventCount(e)
+		for _, kind := range []string{"Startup", "Liveness", "Readiness"} {
+			if strings.HasPrefix(e.Message, kind+" probe") {
+				failures[container+"/"+kind] += count
+				break
+			}
+		}
+		views = append(views, ProbeEventView{
+			Container: container,
+			Message:   e.Message,
+			Count:     count,
+			Age:       formatAge(eventTime(e)),
+		})
+	}
+	return views, failures
+}
+
+// containerFromFieldPath extracts the container name from an event field path
+// such as "spec.containers{app}".
+func containerFromFieldPath(fieldPath string) string {
+	start := strings.Index(fieldPath, "{")
+	end := strings.LastIndex(fieldPath, "}")
+	if start < 0 || end <= start {
+		return ""
+	}
+	return fieldPath[start+1 : end]
+}
+
 func (s *Server) handlePodRestart(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -145,22 +421,50 @@ func (s *Server) handlePodRestart(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	err := s.manager.Client().CoreV1().Pods(s.manager.Namespace()).Delete(r.Context(), name, metav1.DeleteOptions{})
+	err := s.manager.Client().CoreV1().Pods(s.namespace(r)).Delete(r.Context(), name, metav1.DeleteOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "delete", "pods", name, "/pods", "pods") {
+		if s.handleK8sForbidden(w, r, err, "delete", "pods", name, "/pods", "pods") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.audit(requestActor(r), s.namespace(r), "pod", name, "deleted")
 
-	http.Redirect(w, r, "/pods", http.StatusSeeOther)
+	s.redirectBack(w, r, "/pods", "/pods/"+name)
 }
 
 func (s *Server) handlePodDelete(w http.ResponseWriter, r *http.Request) {
 	s.handlePodRestart(w, r) // Same logic
 }
 
+// LogContainerOption is a container that can be selected in the log viewer.
+type LogContainerOption struct {
+	Name string
+	Init bool
+}
+
+// logContainerOptions lists the pod's init containers followed by its app containers.
+func logContainerOptions(pod *corev1.Pod) []LogContainerOption {
+	options := make([]LogContainerOption, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	for _, c := range pod.Spec.InitContainers {
+		options = append(options, LogContainerOption{Name: c.Name, Init: true})
+	}
+	for _, c := range pod.Spec.Containers {
+		options = append(options, LogContainerOption{Name: c.Name})
+	}
+	return options
+}
+
+func hasLogContainer(options []LogContainerOption, name string) bool {
+	for _, o := range options {
+		if o.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Server) handlePodLogs(w http.ResponseWriter, r *http.Request) {
 	// /pods/{name}/logs
 	parts := strings.Split(r.URL.Path, "/")
@@ -171,53 +475,59 @@ func (s *Server) handlePodLogs(w http.ResponseWriter, r *http.Request) {
 	name := parts[2]
 
 	// Get pod to fetch container list
-	pod, err := s.manager.Client().CoreV1().Pods(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	pod, err := s.manager.Client().CoreV1().Pods(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "pods", name, "/pods", "pods") {
+		if s.handleK8sForbidden(w, r, err, "get", "pods", name, "/pods", "pods") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Build container list
-	var containerNames []string
-	for _, c := range pod.Spec.Containers {
-		containerNames = append(containerNames, c.Name)
-	}
-	if len(containerNames) == 0 {
+	// Build container list, including init containers so they can be selected too
+	containers := logContainerOptions(pod)
+	if len(pod.Spec.Containers) == 0 {
 		http.Error(w, "No containers found in pod", http.StatusBadRequest)
 		return
 	}
 
 	container := r.URL.Query().Get("container")
-	// Default to first container if not specified
+	// Default to first app container if not specified
 	if container == "" {
-		container = containerNames[0]
+		container = pod.Spec.Containers[0].Name
 	}
-
-	tailLinesStr := r.URL.Query().Get("tailLines")
-	followStr := r.URL.Query().Get("follow")
-
-	tailLines := int64(200)
-	if tailLinesStr != "" {
-		if v, err := strconv.ParseInt(tailLinesStr, 10, 64); err == nil {
-			tailLines = v
-		}
+	if !hasLogContainer(containers, container) {
+		http.Error(w, fmt.Sprintf("Container %q not found in pod %s", container, name), http.StatusBadRequest)
+		return
 	}
 
+	followStr := r.URL.Query().Get("follow")
 	follow := followStr == "1" || followStr == "true"
 
+	// A rendered page holds at most maxLines; following streams instead, so
+	// only the initial tail needs the cap there too. The namespace's limit
+	// applies to both.
+	maxLines, maxBytes := s.logLimits()
+	limit := s.namespaceLogLimit(s.namespace(r))
+	fetch := logFetch(limit, maxLines, r.URL.Query().Get("tailLines"), r.URL.Query().Get("since"))
+	tailLines := fetch.TailLines
+
 	opts := &corev1.PodLogOptions{
-		Container: container,
-		TailLines: &tailLines,
-		Follow:    follow,
+		Container:    container,
+		TailLines:    &tailLines,
+		SinceSeconds: sinceSeconds(fetch.Since),
+		Follow:       follow,
 	}
 
-	req := s.manager.Client().CoreV1().Pods(s.manager.Namespace()).GetLogs(name, opts)
+	if follow {
+		var end func()
+		r, end = s.trackSession(r, "log stream", artifactName("pod", name, container))
+		defer end()
+	}
+	req := s.manager.Client().CoreV1().Pods(s.namespace(r)).GetLogs(name, opts)
 	stream, err := req.Stream(r.Context())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "pods/log", name, "/pods", "pods") {
+		if s.handleK8sForbidden(w, r, err, "get", "pods/log", name, "/pods", "pods") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -226,52 +536,82 @@ func (s *Server) handlePodLogs(w http.ResponseWriter, r *http.Request) {
 	defer stream.Close()
 
 	if follow {
-		w.Header().Set("Content-Type", "text/plain")
-		w.Header().Set("Transfer-Encoding", "chunked")
-
-		flusher, ok := w.(http.Flusher)
-		if !ok {
+		st, err := newResponseStream(w, r, "text/plain; charset=utf-8")
+		if err != nil {
 			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
 			return
 		}
 
-		reader := bufio.NewReader(stream)
-		for {
-			line, err := reader.ReadString('\n')
-			if err != nil {
-				if err != io.EOF {
-					fmt.Fprintf(w, "Error reading stream: %v\n", err)
-				}
-				break
-			}
-			fmt.Fprint(w, line)
-			flusher.Flush()
+		if err := st.CopyLines(stream); err != nil && r.Context().Err() == nil {
+			fmt.Fprintf(st, "Error reading stream: %v\n", err)
 		}
 	} else {
-		// Non-follow: read all and render template
-		buf := new(strings.Builder)
-		_, err := io.Copy(buf, stream)
+		// Non-follow: read the tail, within the byte cap, and render template
+		logs, bytesCapped, err := readLogTail(stream, maxBytes)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		truncated := ""
+		switch {
+		case bytesCapped:
+			truncated = fmt.Sprintf("Showing the last %s of the log; older lines were cut.", formatBytes(maxBytes))
+		case fetch.LinesCapped && int64(strings.Count(logs, "\n")) >= tailLines:
+			truncated = fmt.Sprintf("Showing the last %d lines of the log; older lines were cut.", tailLines)
+		}
+		limitNote := ""
+		if fetch.SinceCapped || (fetch.LinesCapped && limit.MaxTailLines == tailLines) {
+			limitNote = logLimitNote(s.namespace(r), limit)
+		}
+
+		// Offer the parsed view whenever the output has any JSON lines.
+		lines, structured := parseLogs(logs)
+		total := len(lines)
+		parsed := structured > 0 && r.URL.Query().Get("view") == "parsed"
+		level := r.URL.Query().Get("level")
+		if logLevelRank(level) < 0 {
+			level = ""
+		}
+		if parsed {
+			lines = filterLogLines(lines, level)
+		}
 
 		data := struct {
 			BasePage
-			Name       string
-			Container  string
-			Containers []string
-			Logs       string
-			TailLines  int64
-			Follow     bool
+			Name            string
+			Container       string
+			Containers      []LogContainerOption
+			Logs            string
+			Truncated       string
+			LimitNote       string
+			DownloadCapped  bool
+			TailLines       int64
+			Since           string
+			Follow          bool
+			StructuredLines int
+			TotalLines      int
+			Parsed          bool
+			Level           string
+			Levels          []string
+			Lines           []LogLine
 		}{
-			BasePage:   BasePage{Namespace: s.manager.Namespace(), Title: "Logs: " + name, Active: "pods"},
-			Name:       name,
-			Container:  container,
-			Containers: containerNames,
-			Logs:       buf.String(),
-			TailLines:  tailLines,
-			Follow:     false,
+			BasePage:        BasePage{Namespace: s.namespace(r), Title: "Logs: " + name, Active: "pods"},
+			Name:            name,
+			Container:       container,
+			Containers:      containers,
+			Logs:            logs,
+			Truncated:       truncated,
+			LimitNote:       limitNote,
+			DownloadCapped:  limit.MaxTailLines > 0 || limit.MaxSince > 0,
+			TailLines:       tailLines,
+			Since:           shortDuration(fetch.Since),
+			Follow:          false,
+			StructuredLines: structured,
+			TotalLines:      total,
+			Parsed:          parsed,
+			Level:           level,
+			Levels:          logLevels,
+			Lines:           lines,
 		}
 		s.renderTemplate(w, "pods_logs.html", data)
 	}
@@ -285,35 +625,20 @@ func (s *Server) handlePodYAML(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	pod, err := s.manager.Client().CoreV1().Pods(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	pod, err := s.manager.Client().CoreV1().Pods(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "pods", name, "/pods", "pods") {
+		if s.handleK8sForbidden(w, r, err, "get", "pods", name, "/pods", "pods") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	pod.ManagedFields = nil
-	y, err := yaml.Marshal(pod)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	data := struct {
-		BasePage
-		Name string
-		Kind string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "pods"},
+	s.renderYAML(w, r, "yaml_view.html", pod, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "pods"},
 		Name:     name,
 		Kind:     "pods",
-		YAML:     string(y),
-	}
-
-	s.renderTemplate(w, "yaml_view.html", data)
+	})
 }
 
 // handlePodLogsDownload downloads pod logs as a file
@@ -326,9 +651,9 @@ func (s *Server) handlePodLogsDownload(w http.ResponseWriter, r *http.Request) {
 	name := parts[2]
 
 	// Get pod to fetch container list
-	pod, err := s.manager.Client().CoreV1().Pods(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	pod, err := s.manager.Client().CoreV1().Pods(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "pods", name, "/pods", "pods") {
+		if s.handleK8sForbidden(w, r, err, "get", "pods", name, "/pods", "pods") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -344,15 +669,22 @@ func (s *Server) handlePodLogsDownload(w http.ResponseWriter, r *http.Request) {
 	// Check for previous logs
 	previous := r.URL.Query().Get("previous") == "true"
 
+	// The download is the full log, unless the namespace caps how much
+	// any log request may read.
+	limit := s.namespaceLogLimit(s.namespace(r))
 	opts := &corev1.PodLogOptions{
-		Container: container,
-		Previous:  previous,
+		Container:    container,
+		Previous:     previous,
+		SinceSeconds: sinceSeconds(limit.MaxSince),
+	}
+	if limit.MaxTailLines > 0 {
+		opts.TailLines = &limit.MaxTailLines
 	}
 
-	req := s.manager.Client().CoreV1().Pods(s.manager.Namespace()).GetLogs(name, opts)
+	req := s.manager.Client().CoreV1().Pods(s.namespace(r)).GetLogs(name, opts)
 	stream, err := req.Stream(r.Context())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "pods/log", name, "/pods", "pods") {
+		if s.handleK8sForbidden(w, r, err, "get", "pods/log", name, "/pods", "pods") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -386,15 +718,29 @@ func (s *Server) handlePodExec(w http.ResponseWriter, r *http.Request) {
 	name := parts[2]
 
 	// Get pod to fetch container list
-	pod, err := s.manager.Client().CoreV1().Pods(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	pod, err := s.manager.Client().CoreV1().Pods(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "pods", name, "/pods", "pods") {
+		if s.handleK8sForbidden(w, r, err, "get", "pods", name, "/pods", "pods") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if !s.execConfirmed(r) {
+		var fields []ProductionField
+		for key, values := range r.URL.Query() {
+			if key == productionConfirmField {
+				continue
+			}
+			for _, v := range values {
+				fields = append(fields, ProductionField{Name: key, Value: v})
+			}
+		}
+		s.renderProductionConfirm(w, r, s.namespace(r), http.MethodGet, r.URL.Path, fields, r.URL.Query().Has(productionConfirmField))
+		return
+	}
+
 	var containerNames []string
 	for _, c := range pod.Spec.Containers {
 		containerNames = append(containerNames, c.Name)
@@ -410,11 +756,13 @@ func (s *Server) handlePodExec(w http.ResponseWriter, r *http.Request) {
 		Name       string
 		Container  string
 		Containers []string
+		Confirm    string
 	}{
-		BasePage:   BasePage{Namespace: s.manager.Namespace(), Title: "Exec: " + name, Active: "pods"},
+		BasePage:   BasePage{Namespace: s.namespace(r), Title: "Exec: " + name, Active: "pods"},
 		Name:       name,
 		Container:  container,
 		Containers: containerNames,
+		Confirm:    r.URL.Query().Get(productionConfirmField),
 	}
 
 	s.renderTemplate(w, "pods_exec.html", data)
@@ -451,6 +799,11 @@ func (s *Server) handlePodExecWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !s.execConfirmed(r) {
+		http.Error(w, fmt.Sprintf("Namespace %s is marked as production: confirm the terminal on the exec page first", s.namespace(r)), http.StatusPreconditionRequired)
+		return
+	}
+
 	// Upgrade to WebSocket
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -474,6 +827,30 @@ func (s *Server) handlePodExecWS(w http.ResponseWriter, r *http.Request) {
 		return conn.WriteJSON(msg)
 	}
 
+	if !s.execSessions.acquire(s.config.MaxExecSessions) {
+		_ = writeJSON(TerminalMessage{Type: "output", Data: fmt.Sprintf(
+			"Too many terminal sessions are open (limit %d, MAX_EXEC_SESSIONS). Close another terminal and reconnect.\r\n", s.config.MaxExecSessions)})
+		closeTerminal(conn)
+		return
+	}
+	defer s.execSessions.release()
+	r, end := s.trackSession(r, "exec terminal", artifactName("pod", name, container))
+	defer end()
+
+	// Abandoned terminals are closed once no input has arrived for the
+	// configured idle timeout. Heartbeats and output do not count.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	var idleTimer *time.Timer
+	var idled atomic.Bool
+	if s.config.ExecIdleTimeout > 0 {
+		idleTimer = time.AfterFunc(s.config.ExecIdleTimeout, func() {
+			idled.Store(true)
+			cancel()
+		})
+		defer idleTimer.Stop()
+	}
+
 	// Set initial read deadline and pong handler
 	conn.SetReadDeadline(time.Now().Add(pongWait))
 	conn.SetPongHandler(func(string) error {
@@ -492,7 +869,7 @@ func (s *Server) handlePodExecWS(w http.ResponseWriter, r *http.Request) {
 	req := s.manager.Client().CoreV1().RESTClient().Post().
 		Resource("pods").
 		Name(name).
-		Namespace(s.manager.Namespace()).
+		Namespace(s.namespace(r)).
 		SubResource("exec").
 		Param("container", container).
 		Param("stdin", "true").
@@ -570,6 +947,9 @@ func (s *Server) handlePodExecWS(w http.ResponseWriter, r *http.Request) {
 
 			switch msg.Type {
 			case "input":
+				if idleTimer != nil {
+					idleTimer.Reset(s.config.ExecIdleTimeout)
+				}
 				_, _ = stdinWriter.Write([]byte(msg.Data))
 			case "resize":
 				select {
@@ -600,7 +980,7 @@ func (s *Server) handlePodExecWS(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	// Run the exec
-	err = exec.StreamWithContext(r.Context(), remotecommand.StreamOptions{
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
 		Stdin:             stdinReader,
 		Stdout:            stdoutWriter,
 		Stderr:            stdoutWriter,
@@ -613,9 +993,18 @@ func (s *Server) handlePodExecWS(w http.ResponseWriter, r *http.Request) {
 	stdoutWriter.Close()
 	close(sizeChan)
 
-	if err != nil {
+	switch cause := context.Cause(ctx); {
+	case errors.Is(cause, errArtifactExpired), errors.Is(cause, errArtifactEnded):
+		_ = writeJSON(TerminalMessage{Type: "output", Data: fmt.Sprintf(
+			"\r\n\r\nSession closed: %v.", cause)})
+		closeTerminal(conn)
+	case idled.Load():
+		_ = writeJSON(TerminalMessage{Type: "output", Data: fmt.Sprintf(
+			"\r\n\r\nSession closed after %s without input (EXEC_IDLE_TIMEOUT).", s.config.ExecIdleTimeout)})
+		closeTerminal(conn)
+	case err != nil:
 		_ = writeJSON(TerminalMessage{Type: "output", Data: "\r\n\r\nSession ended: " + err.Error()})
-	} else {
+	default:
 		_ = writeJSON(TerminalMessage{Type: "output", Data: "\r\n\r\nSession ended."})
 	}
 
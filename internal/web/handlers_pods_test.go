@@ -0,0 +1,29 @@
+package web
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodHostPorts(t *testing.T) {
+	spec := &corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name: "app",
+			Ports: []corev1.ContainerPort{
+				{ContainerPort: 8080},
+				{ContainerPort: 53, HostPort: 5353, Protocol: corev1.ProtocolUDP, HostIP: "127.0.0.1"},
+			},
+		}},
+	}
+	got := podHostPorts(spec)
+	if len(got) != 1 || got[0] != (HostPortView{Container: "app", HostPort: 5353, Port: 53, Protocol: "UDP", HostIP: "127.0.0.1"}) {
+		t.Errorf("podHostPorts() = %+v", got)
+	}
+
+	spec.HostNetwork = true
+	got = podHostPorts(spec)
+	if len(got) != 2 || got[0] != (HostPortView{Container: "app", HostPort: 8080, Port: 8080, Protocol: "TCP"}) {
+		t.Errorf("podHostPorts() with hostNetwork = %+v", got)
+	}
+}
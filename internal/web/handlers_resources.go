@@ -35,13 +35,33 @@ func (s *Server) handleResourcesIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	groups := baseResourceGroups()
+	if s.config.ReplicationControllers {
+		groups[0].Items = append(groups[0].Items, ResourceItem{
+			Label: "ReplicationControllers", Subtitle: "core/v1", URL: "/replicationcontrollers", Search: "replicationcontrollers rc core v1 workloads legacy",
+		})
+	}
+	for i := range groups {
+		if groups[i].Name != "Tools" {
+			continue
+		}
+		if s.config.ClusterHealth {
+			groups[i].Items = append(groups[i].Items, ResourceItem{
+				Label: "Cluster Health", Subtitle: "DNS, metrics-server, CNI and control plane leases", URL: "/cluster/health", Search: "cluster health kube-system coredns dns metrics-server cni daemonsets controller-manager scheduler leases tools",
+			})
+		}
+		if s.config.SessionsPage {
+			groups[i].Items = append(groups[i].Items, ResourceItem{
+				Label: "Server Sessions", Subtitle: "Terminals, streams and downloads k8s-ui holds open", URL: "/tools/sessions", Search: "server sessions terminals exec streams downloads reaper orphaned tools",
+			})
+		}
+	}
 	crdItems, warning := s.discoverCRDResourceItems(r)
 	if len(crdItems) > 0 {
 		groups = append(groups, ResourceGroup{Name: "Custom Resources", Items: crdItems})
 	}
 
 	data := ResourcesIndexPage{
-		BasePage:         BasePage{Namespace: s.manager.Namespace(), Title: "Resources", Active: "resources"},
+		BasePage:         BasePage{Namespace: s.namespace(r), Title: "Resources", Active: "resources"},
 		Groups:           groups,
 		DiscoveryWarning: warning,
 	}
@@ -87,6 +107,16 @@ func baseResourceGroups() []ResourceGroup {
 				{Label: "Events", Subtitle: "core/v1", URL: "/events", Search: "events core v1 observability"},
 			},
 		},
+		{
+			Name: "Tools",
+			Items: []ResourceItem{
+				{Label: "Dry Run", Subtitle: "Preview admission webhook mutations", URL: "/tools/dry-run", Search: "dry run dryrun admission mutating webhook tools"},
+				{Label: "Image Drift", Subtitle: "Workload images versus the builds pods run", URL: "/tools/image-drift", Search: "image drift tag digest latest stale imageid tools"},
+				{Label: "Node Fit", Subtitle: "Why a pod may or may not run on a node", URL: "/tools/node-fit", Search: "node fit taints tolerations nodeselector affinity pending scheduling tools"},
+				{Label: "Recently Deleted", Subtitle: "Undo deletes of Deployments, Services and ConfigMaps", URL: "/deleted", Search: "recently deleted undo restore trash tools"},
+				{Label: "Cluster Versions", Subtitle: "API server, kubelet skew and removed APIs", URL: "/cluster/versions", Search: "cluster versions kubelet skew upgrade deprecated removed apis tools"},
+			},
+		},
 	}
 }
 
@@ -4,8 +4,9 @@ import (
 	"net/http"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/yaml"
+	"k8s.io/utils/ptr"
 )
 
 type PVCView struct {
@@ -20,7 +21,21 @@ type PVCView struct {
 
 type PVCsListPage struct {
 	BasePage
-	PVCs []PVCView
+	PVCs  []PVCView
+	Query *ListQuery
+}
+
+var pvcListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Status", Key: "status"},
+		{Label: "Volume"},
+		{Label: "Capacity"},
+		{Label: "Access Modes"},
+		{Label: "Storage Class", Key: "storageClass"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
 }
 
 func (s *Server) handlePVCsList(w http.ResponseWriter, r *http.Request) {
@@ -29,20 +44,34 @@ func (s *Server) handlePVCsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pvcs, err := s.manager.Client().CoreV1().PersistentVolumeClaims(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, pvcListSpec)
+	pvcs, err := s.manager.Client().CoreV1().PersistentVolumeClaims(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "persistentvolumeclaims", "", "/pvcs", "pvcs") {
+		if s.handleK8sForbidden(w, r, err, "list", "persistentvolumeclaims", "", "/pvcs", "pvcs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = pvcs.Continue
+
+	sortItems(pvcs.Items, q, map[string]func(a, b *corev1.PersistentVolumeClaim) int{
+		"status": func(a, b *corev1.PersistentVolumeClaim) int {
+			return strings.Compare(string(a.Status.Phase), string(b.Status.Phase))
+		},
+		"storageClass": func(a, b *corev1.PersistentVolumeClaim) int {
+			return strings.Compare(ptr.Deref(a.Spec.StorageClassName, ""), ptr.Deref(b.Spec.StorageClassName, ""))
+		},
+	})
 
 	var views []PVCView
 	for _, pvc := range pvcs.Items {
+		if !q.Keep(pvc.Name, string(pvc.Status.Phase)) {
+			continue
+		}
 		capacity := "-"
-		if q, ok := pvc.Status.Capacity["storage"]; ok {
-			capacity = q.String()
+		if qty, ok := pvc.Status.Capacity["storage"]; ok {
+			capacity = qty.String()
 		}
 
 		var modes []string
@@ -67,8 +96,9 @@ func (s *Server) handlePVCsList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := PVCsListPage{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "PVCs", Active: "pvcs"},
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "PVCs", Active: "pvcs"},
 		PVCs:     views,
+		Query:    q,
 	}
 
 	s.renderTemplate(w, "pvcs_list.html", data)
@@ -82,33 +112,18 @@ func (s *Server) handlePVCYAML(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	pvc, err := s.manager.Client().CoreV1().PersistentVolumeClaims(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	pvc, err := s.manager.Client().CoreV1().PersistentVolumeClaims(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "persistentvolumeclaims", name, "/pvcs", "pvcs") {
+		if s.handleK8sForbidden(w, r, err, "get", "persistentvolumeclaims", name, "/pvcs", "pvcs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	pvc.ManagedFields = nil
-	y, err := yaml.Marshal(pvc)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	data := struct {
-		BasePage
-		Name string
-		Kind string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "pvcs"},
+	s.renderYAML(w, r, "yaml_view.html", pvc, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "pvcs"},
 		Name:     name,
 		Kind:     "pvcs",
-		YAML:     string(y),
-	}
-
-	s.renderTemplate(w, "yaml_view.html", data)
+	})
 }
@@ -1,17 +1,24 @@
 package web
 
 import (
-	"encoding/json"
+	"cmp"
 	"fmt"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/robfig/cron/v3"
+	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/yaml"
+	"k8s.io/utils/ptr"
 )
 
 type StatefulSetView struct {
@@ -25,6 +32,17 @@ type StatefulSetView struct {
 type StatefulSetsListPage struct {
 	BasePage
 	StatefulSets []StatefulSetView
+	Query        *ListQuery
+}
+
+var statefulSetListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Replicas", Key: "replicas"},
+		{Label: "Images"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
 }
 
 func (s *Server) handleStatefulSetsList(w http.ResponseWriter, r *http.Request) {
@@ -33,17 +51,26 @@ func (s *Server) handleStatefulSetsList(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	ss, err := s.manager.Client().AppsV1().StatefulSets(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, statefulSetListSpec)
+	ss, err := s.manager.Client().AppsV1().StatefulSets(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "statefulsets", "", "/statefulsets", "statefulsets") {
+		if s.handleK8sForbidden(w, r, err, "list", "statefulsets", "", "/statefulsets", "statefulsets") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = ss.Continue
+
+	sortItems(ss.Items, q, map[string]func(a, b *appsv1.StatefulSet) int{
+		"replicas": func(a, b *appsv1.StatefulSet) int { return cmp.Compare(*a.Spec.Replicas, *b.Spec.Replicas) },
+	})
 
 	var views []StatefulSetView
 	for _, item := range ss.Items {
+		if !q.Keep(item.Name, "") {
+			continue
+		}
 		var images []string
 		for _, c := range item.Spec.Template.Spec.Containers {
 			images = append(images, c.Image)
@@ -58,8 +85,9 @@ func (s *Server) handleStatefulSetsList(w http.ResponseWriter, r *http.Request)
 	}
 
 	data := StatefulSetsListPage{
-		BasePage:     BasePage{Namespace: s.manager.Namespace(), Title: "StatefulSets", Active: "statefulsets"},
+		BasePage:     BasePage{Namespace: s.namespace(r), Title: "StatefulSets", Active: "statefulsets"},
 		StatefulSets: views,
+		Query:        q,
 	}
 
 	s.renderTemplate(w, "statefulsets_list.html", data)
@@ -75,7 +103,19 @@ type JobView struct {
 
 type JobsListPage struct {
 	BasePage
-	Jobs []JobView
+	Jobs  []JobView
+	Query *ListQuery
+}
+
+var jobListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Completions"},
+		{Label: "Duration"},
+		{Label: "Status", Key: "status"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
 }
 
 func (s *Server) handleJobsList(w http.ResponseWriter, r *http.Request) {
@@ -84,22 +124,26 @@ func (s *Server) handleJobsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobs, err := s.manager.Client().BatchV1().Jobs(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, jobListSpec)
+	jobs, err := s.manager.Client().BatchV1().Jobs(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "jobs", "", "/jobs", "jobs") {
+		if s.handleK8sForbidden(w, r, err, "list", "jobs", "", "/jobs", "jobs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = jobs.Continue
+
+	sortItems(jobs.Items, q, map[string]func(a, b *batchv1.Job) int{
+		"status": func(a, b *batchv1.Job) int { return strings.Compare(jobStatus(a), jobStatus(b)) },
+	})
 
 	var views []JobView
 	for _, j := range jobs.Items {
-		status := "Running"
-		if j.Status.Succeeded > 0 {
-			status = "Completed"
-		} else if j.Status.Failed > 0 {
-			status = "Failed"
+		status := jobStatus(&j)
+		if !q.Keep(j.Name, status) {
+			continue
 		}
 
 		duration := "-"
@@ -129,25 +173,359 @@ func (s *Server) handleJobsList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := JobsListPage{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "Jobs", Active: "jobs"},
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "Jobs", Active: "jobs"},
 		Jobs:     views,
+		Query:    q,
 	}
 
 	s.renderTemplate(w, "jobs_list.html", data)
 }
 
+type JobConditionView struct {
+	Type    string
+	Status  string
+	Reason  string
+	Message string
+	Age     string
+}
+
+type JobPodView struct {
+	Name      string
+	Phase     string
+	Container string
+	ExitCode  string
+	Reason    string
+	Message   string
+	Restarts  int32
+}
+
+// JobExitCodeView counts container terminations with a given exit code.
+type JobExitCodeView struct {
+	ExitCode   int32
+	Count      int
+	Containers []string
+}
+
+type JobFailureSummary struct {
+	Reason        string
+	Message       string
+	ExitCodes     []JobExitCodeView
+	OOMKills      int
+	BackoffEvents int32
+}
+
+type JobDetailPage struct {
+	BasePage
+	Name         string
+	Status       string
+	Completions  string
+	Parallelism  int32
+	BackoffLimit int32
+	ActivePods   int32
+	Succeeded    int32
+	Failed       int32
+	Duration     string
+	Age          string
+	Conditions   []JobConditionView
+	Failure      *JobFailureSummary
+	Pods         []JobPodView
+	Events       []EventView
+	PodWarning   string
+	Links        ExternalLinks
+}
+
+func (s *Server) handleJobDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/jobs/")
+
+	j, err := s.manager.Client().BatchV1().Jobs(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "get", "jobs", name, "/jobs", "jobs") {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	desired := int32(1)
+	if j.Spec.Completions != nil {
+		desired = *j.Spec.Completions
+	}
+	parallelism := int32(1)
+	if j.Spec.Parallelism != nil {
+		parallelism = *j.Spec.Parallelism
+	}
+	backoffLimit := int32(6)
+	if j.Spec.BackoffLimit != nil {
+		backoffLimit = *j.Spec.BackoffLimit
+	}
+
+	data := JobDetailPage{
+		BasePage:     BasePage{Namespace: s.namespace(r), Title: "Job: " + name, Active: "jobs"},
+		Name:         j.Name,
+		Status:       jobStatus(j),
+		Completions:  fmt.Sprintf("%d/%d", j.Status.Succeeded, desired),
+		Parallelism:  parallelism,
+		BackoffLimit: backoffLimit,
+		ActivePods:   j.Status.Active,
+		Succeeded:    j.Status.Succeeded,
+		Failed:       j.Status.Failed,
+		Duration:     jobDuration(j),
+		Age:          formatAge(j.CreationTimestamp.Time),
+		Links:        annotationLinks(j.Annotations),
+	}
+	for _, c := range j.Status.Conditions {
+		data.Conditions = append(data.Conditions, JobConditionView{
+			Type:    string(c.Type),
+			Status:  string(c.Status),
+			Reason:  c.Reason,
+			Message: c.Message,
+			Age:     formatAge(c.LastTransitionTime.Time),
+		})
+	}
+
+	var pods []corev1.Pod
+	selector, err := metav1.LabelSelectorAsSelector(j.Spec.Selector)
+	if err == nil {
+		var list *corev1.PodList
+		list, err = s.manager.Client().CoreV1().Pods(s.namespace(r)).List(r.Context(), metav1.ListOptions{LabelSelector: selector.String()})
+		if err == nil {
+			pods = list.Items
+		}
+	}
+	if err != nil {
+		data.PodWarning = "Unable to list pods for this job: " + err.Error()
+	}
+
+	// Pods of a failed Job are often already gone, but their events linger for a
+	// while. The Job's own events name every pod it created, so look at the
+	// events of those pods as well as of the pods still listed.
+	var events []corev1.Event
+	if list, err := s.objectEvents(r.Context(), s.namespace(r), "Job", name); err == nil {
+		var jobEvents []corev1.Event
+		for _, e := range list {
+			if e.InvolvedObject.Kind == "Job" && e.InvolvedObject.Name == name {
+				jobEvents = append(jobEvents, e)
+			}
+		}
+		events = append(events, jobEvents...)
+		podNames := jobPodNames(pods, jobEvents)
+		if len(podNames) > 0 {
+			selector := fields.OneTermEqualSelector("involvedObject.kind", "Pod").String()
+			if list, err := s.manager.Client().CoreV1().Events(s.namespace(r)).List(r.Context(), metav1.ListOptions{FieldSelector: selector}); err == nil {
+				for _, e := range list.Items {
+					if e.InvolvedObject.Kind == "Pod" && podNames[e.InvolvedObject.Name] {
+						events = append(events, e)
+					}
+				}
+			}
+		}
+		sort.Slice(events, func(a, b int) bool {
+			return eventTime(events[a]).After(eventTime(events[b]))
+		})
+	}
+
+	for _, p := range pods {
+		data.Pods = append(data.Pods, jobPodViews(p)...)
+	}
+	for _, e := range events {
+		if e.Type != corev1.EventTypeWarning {
+			continue
+		}
+		data.Events = append(data.Events, EventView{
+			Type:    e.Type,
+			Reason:  e.Reason,
+			Message: e.Message,
+			Object:  e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name,
+			Age:     formatAge(eventTime(e)),
+		})
+	}
+	if j.Status.Failed > 0 || jobStatus(j) == "Failed" {
+		data.Failure = jobFailureSummary(j, pods, events)
+	}
+
+	s.renderTemplate(w, "jobs_detail.html", data)
+}
+
+func jobStatus(j *batchv1.Job) string {
+	for _, c := range j.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return "Completed"
+		case batchv1.JobFailed:
+			return "Failed"
+		case batchv1.JobSuspended:
+			return "Suspended"
+		}
+	}
+	if j.Status.Succeeded > 0 {
+		return "Completed"
+	} else if j.Status.Failed > 0 {
+		return "Failed"
+	}
+	return "Running"
+}
+
+func jobDuration(j *batchv1.Job) string {
+	if j.Status.StartTime == nil {
+		return "-"
+	}
+	end := time.Now()
+	if j.Status.CompletionTime != nil {
+		end = j.Status.CompletionTime.Time
+	}
+	return end.Sub(j.Status.StartTime.Time).Round(time.Second).String()
+}
+
+// jobPodViews returns one row per container of a Job pod, using the current or
+// last termination state so failed attempts remain visible.
+func jobPodViews(p corev1.Pod) []JobPodView {
+	var views []JobPodView
+	statuses := append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		view := JobPodView{Name: p.Name, Phase: string(p.Status.Phase), Container: cs.Name, ExitCode: "-", Restarts: cs.RestartCount}
+		if term := terminatedState(cs); term != nil {
+			view.ExitCode = strconv.Itoa(int(term.ExitCode))
+			view.Reason = term.Reason
+			view.Message = term.Message
+		} else if cs.State.Waiting != nil {
+			view.Reason = cs.State.Waiting.Reason
+			view.Message = cs.State.Waiting.Message
+		}
+		views = append(views, view)
+	}
+	if len(views) == 0 {
+		views = append(views, JobPodView{Name: p.Name, Phase: string(p.Status.Phase), ExitCode: "-", Reason: p.Status.Reason, Message: p.Status.Message})
+	}
+	return views
+}
+
+func terminatedState(cs corev1.ContainerStatus) *corev1.ContainerStateTerminated {
+	if cs.State.Terminated != nil {
+		return cs.State.Terminated
+	}
+	return cs.LastTerminationState.Terminated
+}
+
+// jobFailureSummary aggregates why a Job's pods failed: the Job's failure
+// condition, container exit codes, OOM kills and back-off events.
+func jobFailureSummary(j *batchv1.Job, pods []corev1.Pod, events []corev1.Event) *JobFailureSummary {
+	summary := &JobFailureSummary{}
+	for _, c := range j.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			summary.Reason = c.Reason
+			summary.Message = c.Message
+		}
+	}
+
+	// OOM kills are counted per pod from both the containers' last
+	// termination and the OOMKilling events, taking the larger: an event
+	// counts every kill, while a pod only shows its last one, and pods
+	// whose events are gone still show theirs.
+	oomStates := make(map[string]int)
+	oomEvents := make(map[string]int)
+	codes := make(map[int32]*JobExitCodeView)
+	for _, p := range pods {
+		for _, cs := range append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...) {
+			term := terminatedState(cs)
+			if term == nil || term.ExitCode == 0 {
+				continue
+			}
+			if term.Reason == "OOMKilled" {
+				oomStates[p.Name]++
+			}
+			view, ok := codes[term.ExitCode]
+			if !ok {
+				view = &JobExitCodeView{ExitCode: term.ExitCode}
+				codes[term.ExitCode] = view
+			}
+			view.Count++
+			if !slices.Contains(view.Containers, cs.Name) {
+				view.Containers = append(view.Containers, cs.Name)
+			}
+		}
+	}
+	for _, view := range codes {
+		summary.ExitCodes = append(summary.ExitCodes, *view)
+	}
+	sort.Slice(summary.ExitCodes, func(a, b int) bool {
+		return summary.ExitCodes[a].Count > summary.ExitCodes[b].Count
+	})
+
+	for _, e := range events {
+		switch e.Reason {
+		case "BackOff", "BackoffLimitExceeded":
+			summary.BackoffEvents += eventCount(e)
+		case "OOMKilling":
+			oomEvents[e.InvolvedObject.Name] += int(eventCount(e))
+		}
+	}
+	for pod, n := range oomStates {
+		summary.OOMKills += max(n, oomEvents[pod])
+	}
+	for pod, n := range oomEvents {
+		if _, ok := oomStates[pod]; !ok {
+			summary.OOMKills += n
+		}
+	}
+	return summary
+}
+
+// jobPodNames returns the names of the pods of a Job: those listed by its
+// selector and those its events say it created, which may be gone.
+func jobPodNames(pods []corev1.Pod, jobEvents []corev1.Event) map[string]bool {
+	names := make(map[string]bool)
+	for _, p := range pods {
+		names[p.Name] = true
+	}
+	for _, e := range jobEvents {
+		if e.Reason != "SuccessfulCreate" {
+			continue
+		}
+		if pod, ok := strings.CutPrefix(e.Message, "Created pod: "); ok {
+			names[strings.TrimSpace(pod)] = true
+		}
+	}
+	return names
+}
+
 type CronJobView struct {
-	Name             string
-	Schedule         string
-	Suspend          bool
-	Active           int
-	LastScheduleTime string
-	Age              string
+	Name              string
+	Schedule          string
+	TimeZone          string
+	ConcurrencyPolicy string
+	Suspend           bool
+	Active            int
+	LastScheduleTime  string
+	NextRun           string
+	Age               string
 }
 
 type CronJobsListPage struct {
 	BasePage
 	CronJobs []CronJobView
+	Query    *ListQuery
+}
+
+var cronJobListSpec = ListSpec{
+	Columns: []Column{
+		{Label: "Name", Key: "name"},
+		{Label: "Schedule", Key: "schedule"},
+		{Label: "Time Zone"},
+		{Label: "Concurrency"},
+		{Label: "Suspend"},
+		{Label: "Active"},
+		{Label: "Last Schedule", Key: "lastSchedule"},
+		{Label: "Next Run"},
+		{Label: "Age", Key: "age"},
+	},
+	DefaultSort: "name",
 }
 
 func (s *Server) handleCronJobsList(w http.ResponseWriter, r *http.Request) {
@@ -156,17 +534,29 @@ func (s *Server) handleCronJobsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cjs, err := s.manager.Client().BatchV1().CronJobs(s.manager.Namespace()).List(r.Context(), metav1.ListOptions{})
+	q := s.listQuery(r, cronJobListSpec)
+	cjs, err := s.manager.Client().BatchV1().CronJobs(s.namespace(r)).List(r.Context(), q.ListOptions())
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "list", "cronjobs", "", "/cronjobs", "cronjobs") {
+		if s.handleK8sForbidden(w, r, err, "list", "cronjobs", "", "/cronjobs", "cronjobs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	q.Next = cjs.Continue
+
+	sortItems(cjs.Items, q, map[string]func(a, b *batchv1.CronJob) int{
+		"schedule": func(a, b *batchv1.CronJob) int { return strings.Compare(a.Spec.Schedule, b.Spec.Schedule) },
+		"lastSchedule": func(a, b *batchv1.CronJob) int {
+			return compareRecent(a.Status.LastScheduleTime, b.Status.LastScheduleTime)
+		},
+	})
 
 	var views []CronJobView
 	for _, cj := range cjs.Items {
+		if !q.Keep(cj.Name, cronJobListStatus(&cj)) {
+			continue
+		}
 		lastSchedule := "-"
 		if cj.Status.LastScheduleTime != nil {
 			lastSchedule = formatAge(cj.Status.LastScheduleTime.Time) + " ago"
@@ -177,64 +567,216 @@ func (s *Server) handleCronJobsList(w http.ResponseWriter, r *http.Request) {
 			suspend = *cj.Spec.Suspend
 		}
 
+		nextRun := "-"
+		if runs, err := cronNextRuns(cj.Spec.Schedule, cj.Spec.TimeZone, time.Now(), 1); err != nil {
+			nextRun = "invalid schedule"
+		} else if !suspend && len(runs) > 0 {
+			nextRun = "in " + formatDuration(time.Until(runs[0]))
+		}
+
 		views = append(views, CronJobView{
-			Name:             cj.Name,
-			Schedule:         cj.Spec.Schedule,
-			Suspend:          suspend,
-			Active:           len(cj.Status.Active),
-			LastScheduleTime: lastSchedule,
-			Age:              formatAge(cj.CreationTimestamp.Time),
+			Name:              cj.Name,
+			Schedule:          cj.Spec.Schedule,
+			TimeZone:          cronTimeZone(cj.Spec.TimeZone),
+			ConcurrencyPolicy: string(cj.Spec.ConcurrencyPolicy),
+			Suspend:           suspend,
+			Active:            len(cj.Status.Active),
+			LastScheduleTime:  lastSchedule,
+			NextRun:           nextRun,
+			Age:               formatAge(cj.CreationTimestamp.Time),
 		})
 	}
 
 	data := CronJobsListPage{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "CronJobs", Active: "cronjobs"},
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "CronJobs", Active: "cronjobs"},
 		CronJobs: views,
+		Query:    q,
 	}
 
 	s.renderTemplate(w, "cronjobs_list.html", data)
 }
 
-func (s *Server) handleStatefulSetYAML(w http.ResponseWriter, r *http.Request) {
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 {
-		http.Error(w, "Invalid path", http.StatusBadRequest)
+// cronJobListStatus summarises a cronjob for the list status filter.
+func cronJobListStatus(cj *batchv1.CronJob) string {
+	switch {
+	case cj.Spec.Suspend != nil && *cj.Spec.Suspend:
+		return "Suspended"
+	case len(cj.Status.Active) > 0:
+		return "Running"
+	default:
+		return "Scheduled"
+	}
+}
+
+type CronJobDetailPage struct {
+	BasePage
+	Name                    string
+	Schedule                string
+	TimeZone                string
+	ConcurrencyPolicy       string
+	StartingDeadlineSeconds string
+	Suspend                 bool
+	ActiveJobs              []string
+	LastScheduleTime        string
+	LastSuccessfulTime      string
+	Age                     string
+	NextRuns                []string
+	ScheduleError           string
+	Notes                   []string
+	Links                   ExternalLinks
+}
+
+// cronJobNextRunCount is how many upcoming runs are shown on the CronJob detail page.
+const cronJobNextRunCount = 5
+
+func (s *Server) handleCronJobDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	name := parts[2]
+	name := strings.TrimPrefix(r.URL.Path, "/cronjobs/")
 
-	ss, err := s.manager.Client().AppsV1().StatefulSets(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	cj, err := s.manager.Client().BatchV1().CronJobs(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "statefulsets", name, "/statefulsets", "statefulsets") {
+		if s.handleK8sForbidden(w, r, err, "get", "cronjobs", name, "/cronjobs", "cronjobs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	ss.ManagedFields = nil
-	y, err := yaml.Marshal(ss)
+	now := time.Now()
+	suspend := cj.Spec.Suspend != nil && *cj.Spec.Suspend
+
+	concurrency := string(cj.Spec.ConcurrencyPolicy)
+	if concurrency == "" {
+		concurrency = string(batchv1.AllowConcurrent)
+	}
+
+	data := CronJobDetailPage{
+		BasePage:                BasePage{Namespace: s.namespace(r), Title: "CronJob: " + name, Active: "cronjobs"},
+		Name:                    cj.Name,
+		Schedule:                cj.Spec.Schedule,
+		TimeZone:                cronTimeZone(cj.Spec.TimeZone),
+		ConcurrencyPolicy:       concurrency,
+		StartingDeadlineSeconds: "-",
+		Suspend:                 suspend,
+		LastScheduleTime:        "-",
+		LastSuccessfulTime:      "-",
+		Age:                     formatAge(cj.CreationTimestamp.Time),
+		Links:                   annotationLinks(cj.Annotations),
+	}
+	if cj.Spec.StartingDeadlineSeconds != nil {
+		data.StartingDeadlineSeconds = fmt.Sprintf("%ds", *cj.Spec.StartingDeadlineSeconds)
+	}
+	if cj.Status.LastScheduleTime != nil {
+		data.LastScheduleTime = formatAge(cj.Status.LastScheduleTime.Time) + " ago"
+	}
+	if cj.Status.LastSuccessfulTime != nil {
+		data.LastSuccessfulTime = formatAge(cj.Status.LastSuccessfulTime.Time) + " ago"
+	}
+	for _, ref := range cj.Status.Active {
+		data.ActiveJobs = append(data.ActiveJobs, ref.Name)
+	}
+
+	runs, err := cronNextRuns(cj.Spec.Schedule, cj.Spec.TimeZone, now, cronJobNextRunCount)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		data.ScheduleError = err.Error()
+	}
+	for _, run := range runs {
+		data.NextRuns = append(data.NextRuns, fmt.Sprintf("%s (in %s)", run.Format("2006-01-02 15:04:05 MST"), formatDuration(run.Sub(now))))
 	}
+	data.Notes = cronJobNotes(cj, now)
 
-	data := struct {
-		BasePage
-		Name string
-		Kind string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "statefulsets"},
-		Name:     name,
-		Kind:     "statefulsets",
-		YAML:     string(y),
+	s.renderTemplate(w, "cronjobs_detail.html", data)
+}
+
+// cronSchedule parses a CronJob schedule the same way the CronJob controller does,
+// applying spec.timeZone when set.
+func cronSchedule(schedule string, timeZone *string) (cron.Schedule, error) {
+	if timeZone != nil && *timeZone != "" {
+		if _, err := time.LoadLocation(*timeZone); err != nil {
+			return nil, fmt.Errorf("unknown time zone %q: %w", *timeZone, err)
+		}
+		if strings.Contains(schedule, "TZ") {
+			return nil, fmt.Errorf("schedule must not contain TZ or CRON_TZ when spec.timeZone is set")
+		}
+		schedule = "CRON_TZ=" + *timeZone + " " + schedule
+	}
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", schedule, err)
 	}
+	return sched, nil
+}
 
-	s.renderTemplate(w, "yaml_view.html", data)
+// cronNextRuns returns the next n times the schedule fires after from, expressed in
+// the CronJob's time zone when one is set.
+func cronNextRuns(schedule string, timeZone *string, from time.Time, n int) ([]time.Time, error) {
+	sched, err := cronSchedule(schedule, timeZone)
+	if err != nil {
+		return nil, err
+	}
+	if timeZone != nil && *timeZone != "" {
+		loc, _ := time.LoadLocation(*timeZone) // validated by cronSchedule
+		from = from.In(loc)
+	}
+	runs := make([]time.Time, 0, n)
+	next := from
+	for i := 0; i < n; i++ {
+		next = sched.Next(next)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next)
+	}
+	return runs, nil
 }
 
-func (s *Server) handleJobYAML(w http.ResponseWriter, r *http.Request) {
+func cronTimeZone(timeZone *string) string {
+	if timeZone == nil || *timeZone == "" {
+		return "controller local time"
+	}
+	return *timeZone
+}
+
+// cronJobNotes explains common reasons a CronJob did not run when expected.
+func cronJobNotes(cj *batchv1.CronJob, now time.Time) []string {
+	var notes []string
+	if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
+		notes = append(notes, "The CronJob is suspended; no new Jobs will be scheduled until it is resumed.")
+	}
+	if cj.Spec.ConcurrencyPolicy == batchv1.ForbidConcurrent && len(cj.Status.Active) > 0 {
+		notes = append(notes, "concurrencyPolicy is Forbid and a Job is still active, so the next scheduled run will be skipped.")
+	}
+	if cj.Spec.ConcurrencyPolicy == batchv1.ReplaceConcurrent && len(cj.Status.Active) > 0 {
+		notes = append(notes, "concurrencyPolicy is Replace; the active Job will be deleted when the next run starts.")
+	}
+	if cj.Spec.StartingDeadlineSeconds != nil && *cj.Spec.StartingDeadlineSeconds < 10 {
+		notes = append(notes, "startingDeadlineSeconds is below 10s; the controller syncs every 10s and may miss runs.")
+	}
+	if cj.Spec.TimeZone == nil || *cj.Spec.TimeZone == "" {
+		notes = append(notes, "No spec.timeZone is set; the schedule is interpreted in the kube-controller-manager's local time zone (shown here as this server's).")
+	}
+
+	sched, err := cronSchedule(cj.Spec.Schedule, cj.Spec.TimeZone)
+	if err != nil || cj.Status.LastScheduleTime == nil {
+		return notes
+	}
+	// If the schedule should have fired between the last run and now (allowing for
+	// the starting deadline), the controller skipped or missed it.
+	expected := sched.Next(cj.Status.LastScheduleTime.Time)
+	grace := time.Minute
+	if cj.Spec.StartingDeadlineSeconds != nil {
+		grace = time.Duration(*cj.Spec.StartingDeadlineSeconds) * time.Second
+	}
+	if !expected.IsZero() && expected.Add(grace).Before(now) && (cj.Spec.Suspend == nil || !*cj.Spec.Suspend) {
+		notes = append(notes, fmt.Sprintf("A run was expected at %s but has not been scheduled.", expected.Format("2006-01-02 15:04:05 MST")))
+	}
+	return notes
+}
+
+func (s *Server) handleStatefulSetYAML(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(r.URL.Path, "/")
 	if len(parts) < 3 {
 		http.Error(w, "Invalid path", http.StatusBadRequest)
@@ -242,35 +784,44 @@ func (s *Server) handleJobYAML(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	j, err := s.manager.Client().BatchV1().Jobs(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	ss, err := s.manager.Client().AppsV1().StatefulSets(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "jobs", name, "/jobs", "jobs") {
+		if s.handleK8sForbidden(w, r, err, "get", "statefulsets", name, "/statefulsets", "statefulsets") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	j.ManagedFields = nil
-	y, err := yaml.Marshal(j)
+	s.renderYAML(w, r, "yaml_view.html", ss, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "statefulsets"},
+		Name:     name,
+		Kind:     "statefulsets",
+	})
+}
+
+func (s *Server) handleJobYAML(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 3 {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+	name := parts[2]
+
+	j, err := s.manager.Client().BatchV1().Jobs(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "get", "jobs", name, "/jobs", "jobs") {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	data := struct {
-		BasePage
-		Name string
-		Kind string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "jobs"},
+	s.renderYAML(w, r, "yaml_view.html", j, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "jobs"},
 		Name:     name,
 		Kind:     "jobs",
-		YAML:     string(y),
-	}
-
-	s.renderTemplate(w, "yaml_view.html", data)
+	})
 }
 
 func (s *Server) handleCronJobYAML(w http.ResponseWriter, r *http.Request) {
@@ -281,35 +832,20 @@ func (s *Server) handleCronJobYAML(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	cj, err := s.manager.Client().BatchV1().CronJobs(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	cj, err := s.manager.Client().BatchV1().CronJobs(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "cronjobs", name, "/cronjobs", "cronjobs") {
+		if s.handleK8sForbidden(w, r, err, "get", "cronjobs", name, "/cronjobs", "cronjobs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	cj.ManagedFields = nil
-	y, err := yaml.Marshal(cj)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	data := struct {
-		BasePage
-		Name string
-		Kind string
-		YAML string
-	}{
-		BasePage: BasePage{Namespace: s.manager.Namespace(), Title: "YAML: " + name, Active: "cronjobs"},
+	s.renderYAML(w, r, "yaml_view.html", cj, YAMLPage{
+		BasePage: BasePage{Namespace: s.namespace(r), Title: "YAML: " + name, Active: "cronjobs"},
 		Name:     name,
 		Kind:     "cronjobs",
-		YAML:     string(y),
-	}
-
-	s.renderTemplate(w, "yaml_view.html", data)
+	})
 }
 
 // StatefulSet Scale
@@ -326,34 +862,33 @@ func (s *Server) handleStatefulSetScale(w http.ResponseWriter, r *http.Request)
 	}
 	name := parts[2]
 
-	replicasStr := r.FormValue("replicas")
-	replicas, err := strconv.ParseInt(replicasStr, 10, 32)
-	if err != nil {
-		http.Error(w, "Invalid replicas", http.StatusBadRequest)
-		return
-	}
-	r32 := int32(replicas)
-
-	ss, err := s.manager.Client().AppsV1().StatefulSets(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	ss, err := s.manager.Client().AppsV1().StatefulSets(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "statefulsets", name, "/statefulsets", "statefulsets") {
+		if s.handleK8sForbidden(w, r, err, "get", "statefulsets", name, "/statefulsets", "statefulsets") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	r32, ok := s.validateScale(w, r, "StatefulSet", name, false, ptr.Deref(ss.Spec.Replicas, 1), &ss.Spec.Template.Spec, "/statefulsets", "statefulsets")
+	if !ok {
+		return
+	}
+
+	from := ptr.Deref(ss.Spec.Replicas, 1)
 	ss.Spec.Replicas = &r32
-	_, err = s.manager.Client().AppsV1().StatefulSets(s.manager.Namespace()).Update(r.Context(), ss, metav1.UpdateOptions{})
+	_, err = s.manager.Client().AppsV1().StatefulSets(s.namespace(r)).Update(r.Context(), ss, metav1.UpdateOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "update", "statefulsets", name, "/statefulsets", "statefulsets") {
+		if s.handleK8sForbidden(w, r, err, "update", "statefulsets", name, "/statefulsets", "statefulsets") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.audit(requestActor(r), s.namespace(r), "statefulset", name, fmt.Sprintf("scaled %d→%d", from, r32))
 
-	http.Redirect(w, r, "/statefulsets", http.StatusSeeOther)
+	s.redirectBack(w, r, "/statefulsets")
 }
 
 // StatefulSet Restart
@@ -370,34 +905,23 @@ func (s *Server) handleStatefulSetRestart(w http.ResponseWriter, r *http.Request
 	}
 	name := parts[2]
 
-	patchData := map[string]interface{}{
-		"spec": map[string]interface{}{
-			"template": map[string]interface{}{
-				"metadata": map[string]interface{}{
-					"annotations": map[string]interface{}{
-						"kubectl.kubernetes.io/restartedAt": time.Now().Format(time.RFC3339),
-					},
-				},
-			},
-		},
-	}
-
-	payload, err := json.Marshal(patchData)
+	payload, err := rolloutRestartPatch()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	_, err = s.manager.Client().AppsV1().StatefulSets(s.manager.Namespace()).Patch(r.Context(), name, types.MergePatchType, payload, metav1.PatchOptions{})
+	_, err = s.manager.Client().AppsV1().StatefulSets(s.namespace(r)).Patch(r.Context(), name, types.MergePatchType, payload, metav1.PatchOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "patch", "statefulsets", name, "/statefulsets", "statefulsets") {
+		if s.handleK8sForbidden(w, r, err, "patch", "statefulsets", name, "/statefulsets", "statefulsets") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.audit(requestActor(r), s.namespace(r), "statefulset", name, "restarted")
 
-	http.Redirect(w, r, "/statefulsets", http.StatusSeeOther)
+	s.redirectBack(w, r, "/statefulsets")
 }
 
 // CronJob Suspend/Resume
@@ -414,9 +938,9 @@ func (s *Server) handleCronJobSuspend(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	cj, err := s.manager.Client().BatchV1().CronJobs(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	cj, err := s.manager.Client().BatchV1().CronJobs(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "cronjobs", name, "/cronjobs", "cronjobs") {
+		if s.handleK8sForbidden(w, r, err, "get", "cronjobs", name, "/cronjobs", "cronjobs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -430,16 +954,21 @@ func (s *Server) handleCronJobSuspend(w http.ResponseWriter, r *http.Request) {
 	}
 	cj.Spec.Suspend = &suspend
 
-	_, err = s.manager.Client().BatchV1().CronJobs(s.manager.Namespace()).Update(r.Context(), cj, metav1.UpdateOptions{})
+	_, err = s.manager.Client().BatchV1().CronJobs(s.namespace(r)).Update(r.Context(), cj, metav1.UpdateOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "update", "cronjobs", name, "/cronjobs", "cronjobs") {
+		if s.handleK8sForbidden(w, r, err, "update", "cronjobs", name, "/cronjobs", "cronjobs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	action := "resumed"
+	if suspend {
+		action = "suspended"
+	}
+	s.audit(requestActor(r), s.namespace(r), "cronjob", name, action)
 
-	http.Redirect(w, r, "/cronjobs", http.StatusSeeOther)
+	s.redirectBack(w, r, "/cronjobs")
 }
 
 // CronJob Trigger (create a Job from CronJob)
@@ -456,9 +985,9 @@ func (s *Server) handleCronJobTrigger(w http.ResponseWriter, r *http.Request) {
 	}
 	name := parts[2]
 
-	cj, err := s.manager.Client().BatchV1().CronJobs(s.manager.Namespace()).Get(r.Context(), name, metav1.GetOptions{})
+	cj, err := s.manager.Client().BatchV1().CronJobs(s.namespace(r)).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "get", "cronjobs", name, "/cronjobs", "cronjobs") {
+		if s.handleK8sForbidden(w, r, err, "get", "cronjobs", name, "/cronjobs", "cronjobs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -469,7 +998,7 @@ func (s *Server) handleCronJobTrigger(w http.ResponseWriter, r *http.Request) {
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-manual-%d", name, time.Now().Unix()),
-			Namespace: s.manager.Namespace(),
+			Namespace: s.namespace(r),
 			Labels: map[string]string{
 				"job-name":   name,
 				"created-by": "k8s-ui",
@@ -481,16 +1010,24 @@ func (s *Server) handleCronJobTrigger(w http.ResponseWriter, r *http.Request) {
 		Spec: cj.Spec.JobTemplate.Spec,
 	}
 
-	_, err = s.manager.Client().BatchV1().Jobs(s.manager.Namespace()).Create(r.Context(), job, metav1.CreateOptions{})
+	// Quotas count the pods running at once, and the Job itself.
+	delta := scaleUsage(podUsage(&job.Spec.Template.Spec), int64(ptr.Deref(job.Spec.Parallelism, 1)))
+	delta["count/jobs.batch"] = resource.MustParse("1")
+	if !s.validateQuota(w, r, "Trigger", "CronJob", name, delta, "The API server would refuse the Job or its pods, so it would not run.", "/cronjobs", "cronjobs") {
+		return
+	}
+
+	_, err = s.manager.Client().BatchV1().Jobs(s.namespace(r)).Create(r.Context(), job, metav1.CreateOptions{})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "create", "jobs", job.Name, "/cronjobs", "cronjobs") {
+		if s.handleK8sForbidden(w, r, err, "create", "jobs", job.Name, "/cronjobs", "cronjobs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.audit(requestActor(r), s.namespace(r), "cronjob", name, "triggered job "+job.Name)
 
-	http.Redirect(w, r, "/jobs", http.StatusSeeOther)
+	s.redirectBack(w, r, "/jobs")
 }
 
 // Job Delete
@@ -509,16 +1046,17 @@ func (s *Server) handleJobDelete(w http.ResponseWriter, r *http.Request) {
 
 	// Use propagation policy to delete associated pods
 	propagationPolicy := metav1.DeletePropagationBackground
-	err := s.manager.Client().BatchV1().Jobs(s.manager.Namespace()).Delete(r.Context(), name, metav1.DeleteOptions{
+	err := s.manager.Client().BatchV1().Jobs(s.namespace(r)).Delete(r.Context(), name, metav1.DeleteOptions{
 		PropagationPolicy: &propagationPolicy,
 	})
 	if err != nil {
-		if s.handleK8sForbidden(w, err, "delete", "jobs", name, "/jobs", "jobs") {
+		if s.handleK8sForbidden(w, r, err, "delete", "jobs", name, "/jobs", "jobs") {
 			return
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.audit(requestActor(r), s.namespace(r), "job", name, "deleted")
 
-	http.Redirect(w, r, "/jobs", http.StatusSeeOther)
+	s.redirectBack(w, r, "/jobs", "/jobs/"+name)
 }
@@ -0,0 +1,123 @@
+package web
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCronNextRuns(t *testing.T) {
+	from := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	tz := "UTC"
+
+	runs, err := cronNextRuns("0 */6 * * *", &tz, from, 3)
+	if err != nil {
+		t.Fatalf("cronNextRuns failed: %v", err)
+	}
+
+	want := []time.Time{
+		time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+	if len(runs) != len(want) {
+		t.Fatalf("got %d runs, want %d", len(runs), len(want))
+	}
+	for i := range want {
+		if !runs[i].Equal(want[i]) {
+			t.Errorf("run %d = %v, want %v", i, runs[i], want[i])
+		}
+	}
+}
+
+func TestCronNextRunsTimeZone(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tz := "America/New_York"
+
+	runs, err := cronNextRuns("0 9 * * *", &tz, from, 1)
+	if err != nil {
+		t.Fatalf("cronNextRuns failed: %v", err)
+	}
+	// 09:00 EST is 14:00 UTC.
+	if want := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC); !runs[0].Equal(want) {
+		t.Errorf("run = %v, want %v", runs[0].UTC(), want)
+	}
+}
+
+func TestCronNextRunsInvalid(t *testing.T) {
+	if _, err := cronNextRuns("not a schedule", nil, time.Now(), 1); err == nil {
+		t.Error("expected error for invalid schedule")
+	}
+	tz := "Mars/Olympus"
+	if _, err := cronNextRuns("0 * * * *", &tz, time.Now(), 1); err == nil {
+		t.Error("expected error for unknown time zone")
+	}
+}
+
+func terminatedPod(name string, statuses ...corev1.ContainerStatus) corev1.Pod {
+	return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: corev1.PodStatus{ContainerStatuses: statuses}}
+}
+
+func exited(container string, code int32, reason string) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name:  container,
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: code, Reason: reason}},
+	}
+}
+
+func TestJobFailureSummary(t *testing.T) {
+	j := &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+		{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"},
+	}}}
+	restarted := corev1.ContainerStatus{Name: "app", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+	restarted.LastTerminationState.Terminated = &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}
+	pods := []corev1.Pod{
+		terminatedPod("job-a", exited("app", 1, "Error")),
+		terminatedPod("job-b", exited("app", 137, "OOMKilled"), exited("sidecar", 0, "Completed")),
+		terminatedPod("job-c", exited("sidecar", 1, "Error")),
+		terminatedPod("job-d", restarted),
+		terminatedPod("job-e", exited("app", 137, "OOMKilled")),
+	}
+	events := []corev1.Event{
+		{Reason: "BackOff", Count: 4},
+		{Reason: "BackoffLimitExceeded"},
+		// job-b was OOM killed three times, of which its state shows the
+		// last; job-gone is gone and only its event is left; job-e has
+		// only its state.
+		{Reason: "OOMKilling", InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "job-b"}, Series: &corev1.EventSeries{Count: 3}},
+		{Reason: "OOMKilling", InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "job-gone"}},
+		{Reason: "Pulled", Count: 3},
+	}
+
+	got := jobFailureSummary(j, pods, events)
+	want := &JobFailureSummary{
+		Reason:  "BackoffLimitExceeded",
+		Message: "Job has reached the specified backoff limit",
+		ExitCodes: []JobExitCodeView{
+			{ExitCode: 1, Count: 3, Containers: []string{"app", "sidecar"}},
+			{ExitCode: 137, Count: 2, Containers: []string{"app"}},
+		},
+		OOMKills:      5,
+		BackoffEvents: 5,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("jobFailureSummary() = %+v, want %+v", got, want)
+	}
+}
+
+func TestJobPodNames(t *testing.T) {
+	pods := []corev1.Pod{terminatedPod("job-a")}
+	events := []corev1.Event{
+		{Reason: "SuccessfulCreate", Message: "Created pod: job-b"},
+		{Reason: "SuccessfulDelete", Message: "Deleted pod: job-c"},
+	}
+	got := jobPodNames(pods, events)
+	want := map[string]bool{"job-a": true, "job-b": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("jobPodNames() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,241 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/rakeshavasarala/k8s-ui/internal/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// RunningImage is an image build that pods of a workload run: the image
+// their pod spec names and the digest the kubelet resolved it to.
+type RunningImage struct {
+	Image  string
+	Digest string
+	Pods   []string
+}
+
+// ImageDrift compares the image of one container in a workload's template
+// with what its pods actually run.
+type ImageDrift struct {
+	Workload  WorkloadRef
+	Container string
+	Image     string
+	Mutable   bool
+	Running   []RunningImage
+	Problems  []string
+}
+
+// imageReference splits an image reference into its tag and digest, either
+// of which may be empty.
+func imageReference(image string) (tag, digest string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image, digest = image[:i], image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		tag = image[i+1:]
+	}
+	return tag, digest
+}
+
+// repoDigest returns the registry digest in a container status imageID,
+// such as "docker-pullable://nginx@sha256:..." or "docker.io/library/nginx@sha256:...".
+// Images that were loaded onto the node rather than pulled have no registry
+// digest, only a local image ID, and cannot be compared across nodes.
+func repoDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	return ""
+}
+
+// shortDigest abbreviates a sha256 digest the way docker images does.
+func shortDigest(digest string) string {
+	hex := strings.TrimPrefix(digest, "sha256:")
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return hex
+}
+
+// imageDrift reports, for each container of a workload's pod template,
+// the images its running pods use and where they drift from the template:
+//   - pods still running an image from an earlier template, so the workload
+//     says v2 while some pods run v1;
+//   - one image resolving to several digests across pods, which happens when
+//     a mutable tag such as :latest is pushed again and only some nodes pull
+//     the new build;
+//   - a pod whose digest differs from the one the template pins.
+func imageDrift(ref WorkloadRef, spec *corev1.PodSpec, pods []corev1.Pod) []ImageDrift {
+	var rows []ImageDrift
+	for _, c := range spec.Containers {
+		row := ImageDrift{Workload: ref, Container: c.Name, Image: c.Image}
+		tag, pinned := imageReference(c.Image)
+		row.Mutable = pinned == "" && (tag == "" || tag == "latest")
+
+		byBuild := make(map[[2]string]int) // image and digest -> index in Running
+		for _, p := range pods {
+			if p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			image := ""
+			for _, pc := range p.Spec.Containers {
+				if pc.Name == c.Name {
+					image = pc.Image
+				}
+			}
+			for _, cs := range p.Status.ContainerStatuses {
+				if cs.Name != c.Name || cs.ImageID == "" || image == "" {
+					continue
+				}
+				key := [2]string{image, repoDigest(cs.ImageID)}
+				i, ok := byBuild[key]
+				if !ok {
+					i = len(row.Running)
+					byBuild[key] = i
+					row.Running = append(row.Running, RunningImage{Image: key[0], Digest: key[1]})
+				}
+				row.Running[i].Pods = append(row.Running[i].Pods, p.Name)
+			}
+		}
+		sort.Slice(row.Running, func(i, j int) bool {
+			if row.Running[i].Image != row.Running[j].Image {
+				return row.Running[i].Image == c.Image
+			}
+			return len(row.Running[i].Pods) > len(row.Running[j].Pods)
+		})
+
+		var digests []string
+		for _, run := range row.Running {
+			switch {
+			case run.Image != c.Image:
+				row.Problems = append(row.Problems, fmt.Sprintf("%d pod(s) still run %s from an earlier template: %s.",
+					len(run.Pods), run.Image, limitList(run.Pods, 3)))
+			case pinned != "" && run.Digest != "" && run.Digest != pinned:
+				row.Problems = append(row.Problems, fmt.Sprintf("%d pod(s) run digest %s, not the pinned %s.",
+					len(run.Pods), shortDigest(run.Digest), shortDigest(pinned)))
+			case run.Digest != "":
+				digests = append(digests, fmt.Sprintf("%s on %d pod(s)", shortDigest(run.Digest), len(run.Pods)))
+			}
+		}
+		if len(digests) > 1 {
+			row.Problems = append(row.Problems, fmt.Sprintf("%s resolves to %d different builds across pods (%s). The tag was pushed again after some pods pulled it; pods on the older digest run the previous build until they are restarted with a fresh pull.",
+				c.Image, len(digests), strings.Join(digests, ", ")))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ImageDriftPage is the image drift report of a namespace.
+type ImageDriftPage struct {
+	BasePage
+	Rows     []ImageDrift
+	Drifted  int
+	Warnings []string
+}
+
+// handleImageDrift compares the images in the pod templates of the
+// namespace's Deployments, StatefulSets and DaemonSets with the images and
+// digests their pods report in their container statuses. Pods are matched
+// to a workload by its selector, so pods left over from earlier revisions
+// are included.
+func (s *Server) handleImageDrift(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace := s.namespace(r)
+	client := s.manager.Client()
+
+	pods, err := client.CoreV1().Pods(namespace).List(r.Context(), metav1.ListOptions{})
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "list", "pods", "", "/resources", "resources") {
+			return
+		}
+		http.Error(w, "failed to list pods: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type template struct {
+		ref      WorkloadRef
+		selector *metav1.LabelSelector
+		spec     *corev1.PodSpec
+	}
+	found := make([][]template, 3)
+	tasks := []kube.Task{
+		{Name: "deployments", Run: func(ctx context.Context) error {
+			list, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			for i := range list.Items {
+				d := &list.Items[i]
+				found[0] = append(found[0], template{WorkloadRef{Kind: "Deployment", Name: d.Name, URL: "/deployments/" + d.Name}, d.Spec.Selector, &d.Spec.Template.Spec})
+			}
+			return nil
+		}},
+		{Name: "statefulsets", Run: func(ctx context.Context) error {
+			list, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			for i := range list.Items {
+				ss := &list.Items[i]
+				found[1] = append(found[1], template{WorkloadRef{Kind: "StatefulSet", Name: ss.Name, URL: "/statefulsets/" + ss.Name + "/yaml"}, ss.Spec.Selector, &ss.Spec.Template.Spec})
+			}
+			return nil
+		}},
+		{Name: "daemonsets", Run: func(ctx context.Context) error {
+			list, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			for i := range list.Items {
+				ds := &list.Items[i]
+				found[2] = append(found[2], template{WorkloadRef{Kind: "DaemonSet", Name: ds.Name}, ds.Spec.Selector, &ds.Spec.Template.Spec})
+			}
+			return nil
+		}},
+	}
+
+	data := ImageDriftPage{
+		BasePage: BasePage{Namespace: namespace, Title: "Image Drift", Active: "resources"},
+	}
+	for _, f := range kube.FanOut(r.Context(), 0, tasks) {
+		data.Warnings = append(data.Warnings, f.Error())
+	}
+
+	for _, kind := range found {
+		for _, t := range kind {
+			sel, err := metav1.LabelSelectorAsSelector(t.selector)
+			if err != nil || sel.Empty() {
+				continue
+			}
+			var matched []corev1.Pod
+			for _, p := range pods.Items {
+				if sel.Matches(labels.Set(p.Labels)) {
+					matched = append(matched, p)
+				}
+			}
+			data.Rows = append(data.Rows, imageDrift(t.ref, t.spec, matched)...)
+		}
+	}
+	// Drifted containers first, keeping the kind order otherwise.
+	sort.SliceStable(data.Rows, func(i, j int) bool {
+		return len(data.Rows[i].Problems) > 0 && len(data.Rows[j].Problems) == 0
+	})
+	for _, row := range data.Rows {
+		if len(row.Problems) > 0 {
+			data.Drifted++
+		}
+	}
+
+	s.renderTemplate(w, "image_drift.html", data)
+}
@@ -0,0 +1,121 @@
+package web
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestImageReference(t *testing.T) {
+	tests := []struct {
+		image       string
+		tag, digest string
+	}{
+		{"nginx", "", ""},
+		{"nginx:1.27", "1.27", ""},
+		{"registry.local:5000/team/web", "", ""},
+		{"registry.local:5000/team/web:v2", "v2", ""},
+		{"nginx@sha256:abc", "", "sha256:abc"},
+		{"nginx:1.27@sha256:abc", "1.27", "sha256:abc"},
+	}
+
+	for _, tt := range tests {
+		tag, digest := imageReference(tt.image)
+		if tag != tt.tag || digest != tt.digest {
+			t.Errorf("imageReference(%q) = %q, %q, want %q, %q", tt.image, tag, digest, tt.tag, tt.digest)
+		}
+	}
+}
+
+func TestRepoDigest(t *testing.T) {
+	tests := map[string]string{
+		"docker-pullable://nginx@sha256:abc": "sha256:abc",
+		"docker.io/library/nginx@sha256:abc": "sha256:abc",
+		"sha256:0123456789abcdef":            "",
+		"":                                   "",
+	}
+	for id, want := range tests {
+		if got := repoDigest(id); got != want {
+			t.Errorf("repoDigest(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func driftPod(name, image, imageID string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: image}}},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", Image: image, ImageID: imageID}},
+		},
+	}
+}
+
+func TestImageDrift(t *testing.T) {
+	const (
+		digestA = "docker.io/team/web@sha256:aaaaaaaaaaaaaaaa"
+		digestB = "docker.io/team/web@sha256:bbbbbbbbbbbbbbbb"
+	)
+	tests := []struct {
+		name     string
+		image    string
+		pods     []corev1.Pod
+		mutable  bool
+		problems []string
+	}{
+		{
+			name:  "all pods on one build",
+			image: "team/web:v2",
+			pods:  []corev1.Pod{driftPod("a", "team/web:v2", digestA), driftPod("b", "team/web:v2", digestA)},
+		},
+		{
+			name:     "pod left on an earlier template",
+			image:    "team/web:v2",
+			pods:     []corev1.Pod{driftPod("a", "team/web:v2", digestA), driftPod("b", "team/web:v1", digestB)},
+			problems: []string{"still run team/web:v1"},
+		},
+		{
+			name:     "latest resolved to two builds",
+			image:    "team/web:latest",
+			pods:     []corev1.Pod{driftPod("a", "team/web:latest", digestA), driftPod("b", "team/web:latest", digestB)},
+			mutable:  true,
+			problems: []string{"resolves to 2 different builds"},
+		},
+		{
+			name:     "digest differs from the pinned one",
+			image:    "team/web@sha256:aaaaaaaaaaaaaaaa",
+			pods:     []corev1.Pod{driftPod("a", "team/web@sha256:aaaaaaaaaaaaaaaa", digestB)},
+			problems: []string{"not the pinned"},
+		},
+		{
+			name:    "images loaded onto nodes are not compared",
+			image:   "web",
+			pods:    []corev1.Pod{driftPod("a", "web", "sha256:1111"), driftPod("b", "web", "sha256:2222")},
+			mutable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: tt.image}}}
+		rows := imageDrift(WorkloadRef{Kind: "Deployment", Name: "web"}, spec, tt.pods)
+		if len(rows) != 1 {
+			t.Fatalf("%s: got %d rows, want 1", tt.name, len(rows))
+		}
+		row := rows[0]
+		if row.Mutable != tt.mutable {
+			t.Errorf("%s: Mutable = %v, want %v", tt.name, row.Mutable, tt.mutable)
+		}
+		if len(row.Problems) != len(tt.problems) {
+			t.Errorf("%s: Problems = %q, want %d", tt.name, row.Problems, len(tt.problems))
+			continue
+		}
+		for i, want := range tt.problems {
+			if !strings.Contains(row.Problems[i], want) {
+				t.Errorf("%s: Problems[%d] = %q, want it to mention %q", tt.name, i, row.Problems[i], want)
+			}
+		}
+	}
+}
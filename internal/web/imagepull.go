@@ -0,0 +1,223 @@
+package web
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// imagePullReasons are the waiting reasons of a container whose image
+// cannot be pulled.
+var imagePullReasons = map[string]bool{
+	"ErrImagePull":     true,
+	"ImagePullBackOff": true,
+	"InvalidImageName": true,
+}
+
+// dockerHub is the registry of images without a registry host, under the
+// names it is known by in image references and docker config files.
+var dockerHub = []string{"docker.io", "index.docker.io", "registry-1.docker.io"}
+
+// imageRegistry splits an image into the registry it is pulled from and its
+// repository path. The first path component is a registry host when it
+// contains a dot or a port or is localhost; otherwise the image is on
+// Docker Hub.
+func imageRegistry(image string) (registry, repo string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	host, rest, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return host, rest
+	}
+	if !ok {
+		return "docker.io", "library/" + image
+	}
+	return "docker.io", image
+}
+
+// registryMatches reports whether a registry key of a docker config, such as
+// "https://index.docker.io/v1/", "registry.example.com/team" or "*.gcr.io",
+// holds credentials for an image, the way the kubelet looks them up: the
+// host must match, with * matching one domain component, and the image's
+// repository must be under the key's path.
+func registryMatches(key, registry, repo string) bool {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	host, keyPath, _ := strings.Cut(key, "/")
+	if slices.Contains(dockerHub, host) {
+		// The Docker Hub key carries the API version as its path.
+		return slices.Contains(dockerHub, registry)
+	}
+	if !hostMatches(host, registry) {
+		return false
+	}
+	keyPath = strings.Trim(keyPath, "/")
+	return keyPath == "" || repo == keyPath || strings.HasPrefix(repo, keyPath+"/")
+}
+
+func hostMatches(pattern, host string) bool {
+	patternHost, patternPort, _ := strings.Cut(pattern, ":")
+	hostName, hostPort, _ := strings.Cut(host, ":")
+	if patternPort != hostPort {
+		return false
+	}
+	want := strings.Split(patternHost, ".")
+	got := strings.Split(hostName, ".")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if ok, _ := path.Match(want[i], got[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// PullSecretView is an imagePullSecret of a pod and what it holds
+// credentials for.
+type PullSecretView struct {
+	Name       string
+	Found      bool
+	Readable   bool
+	Registries []string
+	Matches    bool // holds credentials for the image's registry
+}
+
+// ImagePullView explains why a container's image cannot be pulled.
+type ImagePullView struct {
+	Container string
+	Image     string
+	Registry  string
+	Reason    string
+	Error     string
+	Secrets   []PullSecretView
+	Findings  []string
+}
+
+// imagePullError returns the latest pull failure the kubelet reported for
+// image, from events sorted newest first, or fallback, the container's
+// waiting message, when there is none.
+func imagePullError(events []corev1.Event, image, fallback string) string {
+	for _, e := range events {
+		if e.Reason == "Failed" && strings.Contains(e.Message, `"`+image+`"`) {
+			return e.Message
+		}
+	}
+	return fallback
+}
+
+// imagePullFindings works through the usual checklist for an image that
+// cannot be pulled: what the registry's error means, and whether a pull
+// secret with credentials for the image's registry is configured.
+func imagePullFindings(v ImagePullView) []string {
+	var findings []string
+	msg := strings.ToLower(v.Error)
+	switch {
+	case v.Reason == "InvalidImageName":
+		findings = append(findings, "The image name is not a valid reference; check it for typos, upper-case letters or a stray scheme.")
+	case strings.Contains(msg, "not found") || strings.Contains(msg, "manifest unknown") || strings.Contains(msg, "no such manifest"):
+		findings = append(findings, "The registry does not have this image or tag. Check the repository name and that the tag was pushed.")
+	case strings.Contains(msg, "unauthorized") || strings.Contains(msg, "authentication required") ||
+		strings.Contains(msg, "denied") || strings.Contains(msg, "forbidden") || strings.Contains(msg, "403"):
+		findings = append(findings, "The registry refused the pull. The image is private and the credentials used have no access to it, or the repository does not exist.")
+	case strings.Contains(msg, "toomanyrequests") || strings.Contains(msg, "rate limit"):
+		findings = append(findings, "The registry is rate limiting pulls. Authenticated pulls usually get a higher limit.")
+	case strings.Contains(msg, "no such host") || strings.Contains(msg, "i/o timeout") ||
+		strings.Contains(msg, "connection refused") || strings.Contains(msg, "tls:") || strings.Contains(msg, "x509"):
+		findings = append(findings, "The node cannot reach "+v.Registry+". Check DNS, proxies, firewalls and the registry's certificate from the node.")
+	}
+
+	if len(v.Secrets) == 0 {
+		findings = append(findings, "No imagePullSecret is configured on the pod or its service account, so "+v.Registry+" is accessed anonymously or with the node's own credentials.")
+		return findings
+	}
+	var covered, unknown bool
+	for _, sec := range v.Secrets {
+		switch {
+		case !sec.Found:
+			findings = append(findings, "The pull secret "+sec.Name+" does not exist in this namespace.")
+		case !sec.Readable:
+			unknown = true
+		case sec.Matches:
+			covered = true
+		}
+	}
+	if !covered && !unknown {
+		findings = append(findings, "None of the pull secrets holds credentials for "+v.Registry+".")
+	}
+	return findings
+}
+
+// podImagePullViews explains the image pull failures of a pod's containers.
+// It is a hint on the pod page, so pull secrets it may not read are listed
+// without their registries.
+func (s *Server) podImagePullViews(ctx context.Context, pod *corev1.Pod, events []corev1.Event) []ImagePullView {
+	images := make(map[string]string)
+	for _, c := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		images[c.Name] = c.Image
+	}
+
+	var views []ImagePullView
+	var secrets []PullSecretView
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		waiting := cs.State.Waiting
+		if waiting == nil || !imagePullReasons[waiting.Reason] {
+			continue
+		}
+		if secrets == nil {
+			secrets = s.pullSecrets(ctx, pod)
+		}
+		image := images[cs.Name]
+		registry, repo := imageRegistry(image)
+		view := ImagePullView{
+			Container: cs.Name,
+			Image:     image,
+			Registry:  registry,
+			Reason:    waiting.Reason,
+			Error:     imagePullError(events, image, waiting.Message),
+		}
+		for _, sec := range secrets {
+			for _, key := range sec.Registries {
+				if registryMatches(key, registry, repo) {
+					sec.Matches = true
+				}
+			}
+			view.Secrets = append(view.Secrets, sec)
+		}
+		view.Findings = imagePullFindings(view)
+		views = append(views, view)
+	}
+	return views
+}
+
+// pullSecrets looks up a pod's imagePullSecrets, which include those of its
+// service account, as they are added to the pod when it is created.
+func (s *Server) pullSecrets(ctx context.Context, pod *corev1.Pod) []PullSecretView {
+	secrets := []PullSecretView{}
+	for _, ref := range pod.Spec.ImagePullSecrets {
+		view := PullSecretView{Name: ref.Name, Found: true}
+		sec, err := s.manager.Client().CoreV1().Secrets(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			view.Found = false
+		case err == nil:
+			view.Readable = true
+			creds, _ := registryCredentials(sec)
+			for _, c := range creds {
+				view.Registries = append(view.Registries, c.Registry)
+			}
+		}
+		secrets = append(secrets, view)
+	}
+	return secrets
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImageRegistry(t *testing.T) {
+	tests := []struct {
+		image, registry, repo string
+	}{
+		{"nginx", "docker.io", "library/nginx"},
+		{"nginx:1.27", "docker.io", "library/nginx"},
+		{"bitnami/redis:7", "docker.io", "bitnami/redis"},
+		{"ghcr.io/acme/api:v2", "ghcr.io", "acme/api"},
+		{"localhost:5000/api@sha256:abc", "localhost:5000", "api"},
+		{"localhost/api", "localhost", "api"},
+		{"123.dkr.ecr.us-east-1.amazonaws.com/api:1", "123.dkr.ecr.us-east-1.amazonaws.com", "api"},
+	}
+	for _, tt := range tests {
+		registry, repo := imageRegistry(tt.image)
+		if registry != tt.registry || repo != tt.repo {
+			t.Errorf("imageRegistry(%q) = %q, %q, want %q, %q", tt.image, registry, repo, tt.registry, tt.repo)
+		}
+	}
+}
+
+func TestRegistryMatches(t *testing.T) {
+	tests := []struct {
+		key, image string
+		want       bool
+	}{
+		{"https://index.docker.io/v1/", "nginx", true},
+		{"docker.io", "bitnami/redis", true},
+		{"https://index.docker.io/v1/", "ghcr.io/acme/api", false},
+		{"ghcr.io", "ghcr.io/acme/api", true},
+		{"https://ghcr.io", "ghcr.io/acme/api", true},
+		{"ghcr.io/acme", "ghcr.io/acme/api", true},
+		{"ghcr.io/other", "ghcr.io/acme/api", false},
+		{"ghcr.io/acme/ap", "ghcr.io/acme/api", false},
+		{"*.gcr.io", "eu.gcr.io/proj/api", true},
+		{"*.gcr.io", "gcr.io/proj/api", false},
+		{"registry.example.com:5000", "registry.example.com/api", false},
+		{"registry.example.com:5000", "registry.example.com:5000/api", true},
+	}
+	for _, tt := range tests {
+		registry, repo := imageRegistry(tt.image)
+		if got := registryMatches(tt.key, registry, repo); got != tt.want {
+			t.Errorf("registryMatches(%q, %q) = %v, want %v", tt.key, tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestImagePullFindings(t *testing.T) {
+	tests := []struct {
+		name string
+		view ImagePullView
+		want []string // a substring of each finding, in order
+	}{
+		{"no secret", ImagePullView{Registry: "ghcr.io", Error: `Failed to pull image "ghcr.io/acme/api": 401 Unauthorized`},
+			[]string{"refused the pull", "No imagePullSecret"}},
+		{"wrong registry", ImagePullView{Registry: "ghcr.io", Error: "manifest unknown", Secrets: []PullSecretView{{Name: "hub", Found: true, Readable: true}}},
+			[]string{"does not have this image", "None of the pull secrets"}},
+		{"matching secret", ImagePullView{Registry: "ghcr.io", Error: "dial tcp: lookup ghcr.io: no such host", Secrets: []PullSecretView{{Name: "gh", Found: true, Readable: true, Matches: true}}},
+			[]string{"cannot reach ghcr.io"}},
+		{"missing secret", ImagePullView{Registry: "ghcr.io", Secrets: []PullSecretView{{Name: "gh"}}},
+			[]string{"gh does not exist", "None of the pull secrets"}},
+		{"unreadable secret", ImagePullView{Registry: "ghcr.io", Secrets: []PullSecretView{{Name: "gh", Found: true}}},
+			nil},
+		{"invalid name", ImagePullView{Reason: "InvalidImageName", Registry: "docker.io", Secrets: []PullSecretView{{Name: "gh", Found: true}}},
+			[]string{"not a valid reference"}},
+	}
+	for _, tt := range tests {
+		got := imagePullFindings(tt.view)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: imagePullFindings() = %q, want %d findings", tt.name, got, len(tt.want))
+			continue
+		}
+		for i := range got {
+			if !strings.Contains(got[i], tt.want[i]) {
+				t.Errorf("%s: finding %d = %q, want it to mention %q", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package web
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// linksAnnotation holds a JSON object of link names to URLs, such as
+// {"Runbook": "https://wiki/runbooks/web", "Dashboard": "https://grafana/d/web"}.
+// Detail pages render the links so teams can attach them to their workloads.
+const linksAnnotation = "k8s-ui/links"
+
+// ExternalLink is one link from the k8s-ui/links annotation.
+type ExternalLink struct {
+	Name string
+	URL  string
+}
+
+// ExternalLinks are the links of a resource, with a message when the
+// annotation could not be used in full.
+type ExternalLinks struct {
+	Items []ExternalLink
+	Error string
+}
+
+// annotationLinks parses the k8s-ui/links annotation. Only absolute http and
+// https URLs are shown; others are reported rather than rendered as links.
+func annotationLinks(annotations map[string]string) ExternalLinks {
+	raw, ok := annotations[linksAnnotation]
+	if !ok {
+		return ExternalLinks{}
+	}
+
+	var m map[string]string
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		return ExternalLinks{Error: "The " + linksAnnotation + " annotation is not a JSON object of names to URLs."}
+	}
+
+	var links ExternalLinks
+	var skipped []string
+	for name, link := range m {
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			skipped = append(skipped, name)
+			continue
+		}
+		links.Items = append(links.Items, ExternalLink{Name: name, URL: u.String()})
+	}
+	sort.Slice(links.Items, func(i, j int) bool { return links.Items[i].Name < links.Items[j].Name })
+	if len(skipped) > 0 {
+		sort.Strings(skipped)
+		links.Error = "Ignored links without an http or https URL: " + strings.Join(skipped, ", ")
+	}
+	return links
+}
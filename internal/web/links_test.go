@@ -0,0 +1,51 @@
+package web
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/ptr"
+)
+
+func TestAnnotationLinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     *string
+		wantLinks []ExternalLink
+		wantError bool
+	}{
+		{name: "no annotation"},
+		{
+			name:  "sorted by name",
+			value: ptr.To(`{"Runbook": "https://wiki.example.com/runbooks/web", "Dashboard": "http://grafana.example.com/d/web?var=1"}`),
+			wantLinks: []ExternalLink{
+				{Name: "Dashboard", URL: "http://grafana.example.com/d/web?var=1"},
+				{Name: "Runbook", URL: "https://wiki.example.com/runbooks/web"},
+			},
+		},
+		{
+			name:      "unsafe and relative URLs are skipped",
+			value:     ptr.To(`{"Logs": "https://logs.example.com", "Bad": "javascript:alert(1)", "Local": "/pods"}`),
+			wantLinks: []ExternalLink{{Name: "Logs", URL: "https://logs.example.com"}},
+			wantError: true,
+		},
+		{name: "not JSON", value: ptr.To(`runbook=https://wiki`), wantError: true},
+		{name: "not an object of strings", value: ptr.To(`["https://wiki"]`), wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotations := map[string]string{"other": "x"}
+			if tt.value != nil {
+				annotations[linksAnnotation] = *tt.value
+			}
+			got := annotationLinks(annotations)
+			if !reflect.DeepEqual(got.Items, tt.wantLinks) {
+				t.Errorf("links = %+v, want %+v", got.Items, tt.wantLinks)
+			}
+			if (got.Error != "") != tt.wantError {
+				t.Errorf("error = %q, want error: %v", got.Error, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Lint rules checked on manifests before they are saved.
+const (
+	LintRequireLimits = "require-limits"
+	LintForbidLatest  = "forbid-latest"
+	LintRequireProbes = "require-probes"
+	LintRequireLabels = "require-labels"
+)
+
+var lintRuleNames = []string{LintRequireLimits, LintForbidLatest, LintRequireProbes, LintRequireLabels}
+
+// What a failed lint rule does: LintWarn asks for confirmation before
+// saving and LintBlock refuses to save.
+const (
+	LintWarn  = "warn"
+	LintBlock = "block"
+)
+
+// LintRule is a policy that manifests edited or applied through k8s-ui must
+// follow. It is checked by k8s-ui only; changes made with kubectl are not
+// affected.
+type LintRule struct {
+	Name   string
+	Action string
+	Labels []string // the labels require-labels asks for
+}
+
+// ParseLintRules reads one rule per line as "rule action [labels]", where
+// labels is the comma-separated list of label keys for require-labels.
+// Blank lines and lines starting with # are skipped.
+func ParseLintRules(r io.Reader) ([]LintRule, error) {
+	var rules []LintRule
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("line %d: want \"rule action [labels]\"", n)
+		}
+		rule := LintRule{Name: fields[0], Action: fields[1]}
+		if !slices.Contains(lintRuleNames, rule.Name) {
+			return nil, fmt.Errorf("line %d: unknown rule %q, want one of %s", n, rule.Name, strings.Join(lintRuleNames, ", "))
+		}
+		if rule.Action != LintWarn && rule.Action != LintBlock {
+			return nil, fmt.Errorf("line %d: unknown action %q, want warn or block", n, rule.Action)
+		}
+		if len(fields) == 3 {
+			if rule.Name != LintRequireLabels {
+				return nil, fmt.Errorf("line %d: %s takes no arguments", n, rule.Name)
+			}
+			for _, key := range strings.Split(fields[2], ",") {
+				if key != "" {
+					rule.Labels = append(rule.Labels, key)
+				}
+			}
+		}
+		if rule.Name == LintRequireLabels && len(rule.Labels) == 0 {
+			return nil, fmt.Errorf("line %d: %s needs a comma-separated list of label keys", n, rule.Name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, sc.Err()
+}
+
+// imageTagIsLatest reports whether an image is pulled by the latest tag,
+// either explicitly or because it has no tag. Images pinned by digest are
+// not.
+func imageTagIsLatest(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+	i := strings.LastIndex(image, ":")
+	if i <= strings.LastIndex(image, "/") {
+		return true
+	}
+	return image[i+1:] == "latest"
+}
+
+// runsToCompletion reports whether the pods of a kind exit when their work
+// is done, so probes do not apply to them.
+func runsToCompletion(gvk schema.GroupVersionKind) bool {
+	return gvk.Group == "batch" && (gvk.Kind == "Job" || gvk.Kind == "CronJob")
+}
+
+// lintManifest checks obj against rules and returns the findings of block
+// rules as errors and those of warn rules as warnings. Container rules apply
+// to the objects that create pods.
+func lintManifest(rules []LintRule, obj *unstructured.Unstructured) (errs, warnings []ScaleIssue, err error) {
+	template, _, hasPods, err := podTemplateOf(obj)
+	if err != nil {
+		return nil, nil, err
+	}
+	containers := append(append([]corev1.Container{}, template.Spec.InitContainers...), template.Spec.Containers...)
+
+	for _, rule := range rules {
+		var found []string
+		switch rule.Name {
+		case LintRequireLimits:
+			for _, c := range containers {
+				var missing []string
+				for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+					if q, ok := c.Resources.Limits[name]; !ok || q.IsZero() {
+						missing = append(missing, string(name))
+					}
+				}
+				if len(missing) > 0 {
+					found = append(found, fmt.Sprintf("Container %s sets no %s limit.", c.Name, strings.Join(missing, " or ")))
+				}
+			}
+		case LintForbidLatest:
+			for _, c := range containers {
+				if imageTagIsLatest(c.Image) {
+					found = append(found, fmt.Sprintf("Container %s uses %s; pin a version tag or digest.", c.Name, c.Image))
+				}
+			}
+		case LintRequireProbes:
+			if !hasPods || runsToCompletion(obj.GroupVersionKind()) {
+				continue
+			}
+			for _, c := range template.Spec.Containers {
+				var missing []string
+				if c.ReadinessProbe == nil {
+					missing = append(missing, "readiness")
+				}
+				if c.LivenessProbe == nil {
+					missing = append(missing, "liveness")
+				}
+				if len(missing) > 0 {
+					found = append(found, fmt.Sprintf("Container %s has no %s probe.", c.Name, strings.Join(missing, " or ")))
+				}
+			}
+		case LintRequireLabels:
+			labels := obj.GetLabels()
+			for _, key := range rule.Labels {
+				if labels[key] == "" {
+					found = append(found, fmt.Sprintf("%s %s has no %s label.", obj.GetKind(), obj.GetName(), key))
+				}
+			}
+		}
+		for _, msg := range found {
+			issue := ScaleIssue{Field: rule.Name, Message: msg}
+			if rule.Action == LintBlock {
+				errs = append(errs, issue)
+			} else {
+				warnings = append(warnings, issue)
+			}
+		}
+	}
+	return errs, warnings, nil
+}
+
+// YAMLEditPage is the raw YAML editor of an object, with the lint findings
+// of the last attempt to save it.
+type YAMLEditPage struct {
+	BasePage
+	Name         string
+	YAML         string
+	LintErrors   []ScaleIssue
+	LintWarnings []ScaleIssue
+	// ProductionConfirm carries an accepted production confirmation
+	// into the "Save Anyway" form.
+	ProductionConfirm string
+	// ReturnTo is the page to go back to after saving or cancelling.
+	ReturnTo string
+}
+
+// validateLint checks an edited object against the lint rules before it is
+// saved. It renders the editor of page again with the findings and returns
+// false when a block rule fails, or when a warn rule fails and the user has
+// not confirmed with force=1.
+func (s *Server) validateLint(w http.ResponseWriter, r *http.Request, obj runtime.Object, gvk schema.GroupVersionKind, tmpl string, page YAMLEditPage) bool {
+	if len(s.config.LintRules) == 0 {
+		return true
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+	errs, warnings, err := lintManifest(s.config.LintRules, u)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if len(errs) == 0 && (len(warnings) == 0 || r.FormValue("force") == "1") {
+		return true
+	}
+
+	page.LintErrors = errs
+	page.LintWarnings = warnings
+	page.ProductionConfirm = r.PostFormValue(productionConfirmField)
+	if len(errs) > 0 {
+		w.WriteHeader(http.StatusForbidden)
+	} else {
+		w.WriteHeader(http.StatusConflict)
+	}
+	s.renderTemplate(w, tmpl, page)
+	return false
+}
@@ -0,0 +1,131 @@
+package web
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestParseLintRules(t *testing.T) {
+	rules, err := ParseLintRules(strings.NewReader(`
+# rule          action  labels
+forbid-latest   block
+require-labels  warn    team,app.kubernetes.io/name
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].Action != LintBlock || len(rules[1].Labels) != 2 || rules[1].Labels[1] != "app.kubernetes.io/name" {
+		t.Errorf("ParseLintRules() = %+v", rules)
+	}
+
+	for _, bad := range []string{
+		"forbid-latest",
+		"require-cpu block",
+		"forbid-latest deny",
+		"forbid-latest block team",
+		"require-labels warn",
+	} {
+		if _, err := ParseLintRules(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseLintRules(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestImageTagIsLatest(t *testing.T) {
+	tests := map[string]bool{
+		"nginx":                            true,
+		"nginx:latest":                     true,
+		"registry.example.com:5000/app":    true,
+		"nginx:1.27":                       false,
+		"registry.example.com:5000/app:v2": false,
+		"nginx@sha256:0123":                false,
+	}
+	for image, want := range tests {
+		if got := imageTagIsLatest(image); got != want {
+			t.Errorf("imageTagIsLatest(%q) = %v, want %v", image, got, want)
+		}
+	}
+}
+
+func TestLintManifest(t *testing.T) {
+	rules := []LintRule{
+		{Name: LintRequireLimits, Action: LintBlock},
+		{Name: LintForbidLatest, Action: LintWarn},
+		{Name: LintRequireProbes, Action: LintWarn},
+		{Name: LintRequireLabels, Action: LintWarn, Labels: []string{"team"}},
+	}
+	tests := []struct {
+		name     string
+		manifest string
+		errors   []string
+		warnings []string
+	}{
+		{
+			name: "deployment",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, labels: {team: shop}}
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: shop/web:latest
+        resources: {limits: {cpu: 500m}}
+        readinessProbe: {httpGet: {path: /, port: 80}}
+`,
+			errors:   []string{"Container app sets no memory limit."},
+			warnings: []string{"Container app uses shop/web:latest; pin a version tag or digest.", "Container app has no liveness probe."},
+		},
+		{
+			name: "job needs no probes",
+			manifest: `
+apiVersion: batch/v1
+kind: Job
+metadata: {name: migrate, labels: {team: shop}}
+spec:
+  template:
+    spec:
+      containers:
+      - {name: migrate, image: "shop/migrate:3", resources: {limits: {cpu: "1", memory: 1Gi}}}
+`,
+		},
+		{
+			name: "configmap",
+			manifest: `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings}
+`,
+			warnings: []string{"ConfigMap settings has no team label."},
+		},
+	}
+	for _, tt := range tests {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(tt.manifest), &obj.Object); err != nil {
+			t.Fatal(err)
+		}
+		errs, warnings, err := lintManifest(rules, obj)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := issueMessages(errs); strings.Join(got, "|") != strings.Join(tt.errors, "|") {
+			t.Errorf("%s: errors = %q, want %q", tt.name, got, tt.errors)
+		}
+		if got := issueMessages(warnings); strings.Join(got, "|") != strings.Join(tt.warnings, "|") {
+			t.Errorf("%s: warnings = %q, want %q", tt.name, got, tt.warnings)
+		}
+	}
+}
+
+func issueMessages(issues []ScaleIssue) []string {
+	var out []string
+	for _, i := range issues {
+		out = append(out, i.Message)
+	}
+	return out
+}
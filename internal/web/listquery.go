@@ -0,0 +1,316 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// listPageSizes are the page sizes offered by the list filter bar. Zero
+// means the whole namespace in one page.
+var listPageSizes = []int64{0, 50, 100, 500}
+
+// Column is one column of a list table. Key is the sort key passed to
+// sortItems; columns without one cannot be sorted.
+type Column struct {
+	Label string
+	Key   string
+}
+
+// ListSpec describes the table of a list page: its columns and the sort
+// used when the URL does not ask for one.
+type ListSpec struct {
+	Columns     []Column
+	DefaultSort string
+	DefaultDesc bool
+}
+
+// ListQuery holds the filter, sort and paging state of a list page. It is
+// parsed from the URL and rendered back into every link and form on the
+// page, so a filtered view can be bookmarked and shared.
+type ListQuery struct {
+	Path      string
+	Namespace string
+	Filter    string // q: case-insensitive substring of the name
+	Status    string // status: exact match on the list's status column
+	Selector  string // selector: label selector evaluated by the API server
+	Sort      string // sort: column key, the spec's default when absent
+	Desc      bool   // dir=desc
+	Limit     int64  // limit: page size, 0 for no paging
+	Continue  string // continue: API token of the current page
+	Next      string // API token of the next page, set by the handler
+	Local     bool   // the list is not from a paged API; hide selector and paging
+	Columns   []Column
+
+	// params are list-specific parameters, such as the events time range,
+	// kept in every link and form alongside the common ones.
+	params   url.Values
+	statuses map[string]struct{}
+	spec     ListSpec
+}
+
+// listQuery parses the list state from the request. A sort key that is not
+// one of the spec's sortable columns falls back to the default sort.
+func (s *Server) listQuery(r *http.Request, spec ListSpec) *ListQuery {
+	v := r.URL.Query()
+	q := &ListQuery{
+		Path:      r.URL.Path,
+		Namespace: s.namespace(r),
+		Filter:    strings.TrimSpace(v.Get("q")),
+		Status:    v.Get("status"),
+		Selector:  strings.TrimSpace(v.Get("selector")),
+		Sort:      v.Get("sort"),
+		Desc:      v.Get("dir") == "desc",
+		Continue:  v.Get("continue"),
+		Columns:   slices.Clone(spec.Columns),
+		spec:      spec,
+	}
+	if !spec.sortable(q.Sort) {
+		q.Sort = spec.DefaultSort
+		if v.Get("dir") == "" {
+			q.Desc = spec.DefaultDesc
+		}
+	}
+	if n, err := strconv.ParseInt(v.Get("limit"), 10, 64); err == nil && n > 0 {
+		q.Limit = n
+	}
+	return q
+}
+
+func (spec ListSpec) sortable(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range spec.Columns {
+		if c.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ListOptions returns the API list options for the selector and page.
+func (q *ListQuery) ListOptions() metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector: q.Selector,
+		Limit:         q.Limit,
+		Continue:      q.Continue,
+	}
+}
+
+// Keep reports whether a row passes the name and status filters. It also
+// records the status so the filter bar can offer it as an option.
+func (q *ListQuery) Keep(name, status string) bool {
+	if status != "" {
+		if q.statuses == nil {
+			q.statuses = make(map[string]struct{})
+		}
+		q.statuses[status] = struct{}{}
+	}
+	if q.Filter != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(q.Filter)) {
+		return false
+	}
+	return q.Status == "" || q.Status == status
+}
+
+// StatusOptions lists the statuses seen by Keep, plus the selected one.
+func (q *ListQuery) StatusOptions() []string {
+	var out []string
+	for st := range q.statuses {
+		out = append(out, st)
+	}
+	if _, ok := q.statuses[q.Status]; q.Status != "" && !ok {
+		out = append(out, q.Status)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SetParam keeps a list-specific parameter in the view's links and forms.
+func (q *ListQuery) SetParam(key, value string) {
+	if q.params == nil {
+		q.params = url.Values{}
+	}
+	q.params.Set(key, value)
+}
+
+// Param returns a list-specific parameter set with SetParam.
+func (q *ListQuery) Param(key string) string {
+	return q.params.Get(key)
+}
+
+// Params lists the list-specific parameters for the filter form.
+func (q *ListQuery) Params() url.Values {
+	return q.params
+}
+
+// ParamURL links to the current view with a list-specific parameter set,
+// or removed when value is empty. Paging restarts from the first page.
+func (q *ListQuery) ParamURL(key, value string) string {
+	v := q.values()
+	v.Del(key)
+	if value != "" {
+		v.Set(key, value)
+	}
+	return q.url(v)
+}
+
+func (q *ListQuery) PageSizes() []int64 {
+	return listPageSizes
+}
+
+// Paged reports whether the rows are one page of the list, so the name and
+// status filters and the sort, which run here, see only that page.
+func (q *ListQuery) Paged() bool {
+	return q.Continue != "" || q.Next != ""
+}
+
+// Filtered reports whether any filter is narrowing the list.
+func (q *ListQuery) Filtered() bool {
+	return q.Filter != "" || q.Status != "" || q.Selector != ""
+}
+
+func (q *ListQuery) values() url.Values {
+	v := url.Values{}
+	set := func(k, val string) {
+		if val != "" {
+			v.Set(k, val)
+		}
+	}
+	set("namespace", q.Namespace)
+	set("q", q.Filter)
+	set("status", q.Status)
+	set("selector", q.Selector)
+	// The default sort is left out so plain links stay short.
+	if q.Sort != q.spec.DefaultSort {
+		set("sort", q.Sort)
+	}
+	if q.Desc != (q.Sort == q.spec.DefaultSort && q.spec.DefaultDesc) {
+		if q.Desc {
+			v.Set("dir", "desc")
+		} else {
+			v.Set("dir", "asc")
+		}
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.FormatInt(q.Limit, 10))
+	}
+	for k, vals := range q.params {
+		v[k] = append([]string(nil), vals...)
+	}
+	return v
+}
+
+func (q *ListQuery) url(v url.Values) string {
+	if len(v) == 0 {
+		return q.Path
+	}
+	return q.Path + "?" + v.Encode()
+}
+
+// URL is the shareable link to the current view.
+func (q *ListQuery) URL() string {
+	v := q.values()
+	if q.Continue != "" {
+		v.Set("continue", q.Continue)
+	}
+	return q.url(v)
+}
+
+// SortURL links to the view sorted by key, toggling the direction when
+// the list is already sorted by it. Paging restarts from the first page.
+func (q *ListQuery) SortURL(key string) string {
+	next := *q
+	next.Sort = key
+	switch {
+	case q.Sort == key:
+		next.Desc = !q.Desc
+	case key == q.spec.DefaultSort:
+		next.Desc = q.spec.DefaultDesc
+	default:
+		next.Desc = false
+	}
+	return q.url(next.values())
+}
+
+// SortMark is the arrow shown next to the active sort column.
+func (q *ListQuery) SortMark(key string) string {
+	if q.Sort != key {
+		return ""
+	}
+	if q.Desc {
+		return " ↓"
+	}
+	return " ↑"
+}
+
+// NextURL links to the next API page, or is empty on the last page.
+func (q *ListQuery) NextURL() string {
+	if q.Next == "" {
+		return ""
+	}
+	v := q.values()
+	v.Set("continue", q.Next)
+	return q.url(v)
+}
+
+// FirstURL links to the first page of the current view.
+func (q *ListQuery) FirstURL() string {
+	return q.url(q.values())
+}
+
+// ClearURL links to the unfiltered list in the same namespace.
+func (q *ListQuery) ClearURL() string {
+	v := url.Values{}
+	v.Set("namespace", q.Namespace)
+	return q.url(v)
+}
+
+// sortItems sorts API items by q.Sort. "name" and "age" are built in;
+// keys adds list-specific columns and may override the built-ins. An
+// unknown or empty key leaves the order unchanged.
+func sortItems[T any, PT interface {
+	*T
+	metav1.Object
+}](items []T, q *ListQuery, keys map[string]func(a, b *T) int) {
+	cmp := keys[q.Sort]
+	if cmp == nil {
+		switch q.Sort {
+		case "name":
+			cmp = func(a, b *T) int { return strings.Compare(PT(a).GetName(), PT(b).GetName()) }
+		case "age":
+			// Newest first, matching how the Age column reads.
+			cmp = func(a, b *T) int {
+				return PT(b).GetCreationTimestamp().Time.Compare(PT(a).GetCreationTimestamp().Time)
+			}
+		default:
+			return
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		c := cmp(&items[i], &items[j])
+		if q.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// compareRecent orders optional timestamps most recent first, with unset
+// timestamps last.
+func compareRecent(a, b *metav1.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return b.Time.Compare(a.Time)
+}
@@ -0,0 +1,137 @@
+package web
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestListQueryURLs(t *testing.T) {
+	q := &ListQuery{Path: "/pods", Namespace: "payments", Status: "Failed", Sort: "age", Limit: 50, Continue: "tok"}
+
+	if got, want := q.URL(), "/pods?continue=tok&limit=50&namespace=payments&sort=age&status=Failed"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+	if got, want := q.SortURL("age"), "/pods?dir=desc&limit=50&namespace=payments&sort=age&status=Failed"; got != want {
+		t.Errorf("SortURL(age) = %q, want %q", got, want)
+	}
+	if got, want := q.SortURL("name"), "/pods?limit=50&namespace=payments&sort=name&status=Failed"; got != want {
+		t.Errorf("SortURL(name) = %q, want %q", got, want)
+	}
+	if got := q.NextURL(); got != "" {
+		t.Errorf("NextURL() = %q on the last page, want empty", got)
+	}
+	if !q.Paged() {
+		t.Error("Paged() = false on the last of several pages, want true")
+	}
+	if (&ListQuery{Limit: 50}).Paged() {
+		t.Error("Paged() = true for a list that fits one page, want false")
+	}
+	if got, want := q.ClearURL(), "/pods?namespace=payments"; got != want {
+		t.Errorf("ClearURL() = %q, want %q", got, want)
+	}
+}
+
+func TestListQueryParams(t *testing.T) {
+	q := &ListQuery{Path: "/events", Namespace: "payments", Sort: "reason"}
+	q.SetParam("since", "1h")
+
+	if got, want := q.URL(), "/events?namespace=payments&since=1h&sort=reason"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+	if got, want := q.ParamURL("time", "absolute"), "/events?namespace=payments&since=1h&sort=reason&time=absolute"; got != want {
+		t.Errorf("ParamURL(time) = %q, want %q", got, want)
+	}
+	if got, want := q.ParamURL("since", ""), "/events?namespace=payments&sort=reason"; got != want {
+		t.Errorf("ParamURL(since, \"\") = %q, want %q", got, want)
+	}
+	if got, want := q.ClearURL(), "/events?namespace=payments"; got != want {
+		t.Errorf("ClearURL() = %q, want %q", got, want)
+	}
+}
+
+func TestListQueryDefaultSort(t *testing.T) {
+	spec := ListSpec{
+		Columns:     []Column{{Label: "Name", Key: "name"}, {Label: "Message"}, {Label: "Age", Key: "age"}},
+		DefaultSort: "age",
+		DefaultDesc: true,
+	}
+	tests := []struct {
+		url      string
+		wantSort string
+		wantDesc bool
+		wantURL  string
+	}{
+		{url: "/events", wantSort: "age", wantDesc: true, wantURL: "/events?namespace=payments"},
+		{url: "/events?dir=asc", wantSort: "age", wantURL: "/events?dir=asc&namespace=payments"},
+		{url: "/events?sort=name", wantSort: "name", wantURL: "/events?namespace=payments&sort=name"},
+		{url: "/events?sort=message", wantSort: "age", wantDesc: true, wantURL: "/events?namespace=payments"},
+		{url: "/events?sort=unknown&dir=asc", wantSort: "age", wantURL: "/events?dir=asc&namespace=payments"},
+	}
+
+	s := &Server{}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			r = r.WithContext(context.WithValue(r.Context(), namespaceOverrideKey{}, "payments"))
+			q := s.listQuery(r, spec)
+			if q.Sort != tt.wantSort || q.Desc != tt.wantDesc {
+				t.Errorf("sort = %q desc %v, want %q desc %v", q.Sort, q.Desc, tt.wantSort, tt.wantDesc)
+			}
+			if got := q.URL(); got != tt.wantURL {
+				t.Errorf("URL() = %q, want %q", got, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestListQuerySortURLDefault(t *testing.T) {
+	spec := ListSpec{Columns: []Column{{Label: "Name", Key: "name"}, {Label: "Age", Key: "age"}}, DefaultSort: "name"}
+	q := &ListQuery{Path: "/pods", Namespace: "payments", Sort: "age", Columns: spec.Columns, spec: spec}
+
+	if got, want := q.SortURL("name"), "/pods?namespace=payments"; got != want {
+		t.Errorf("SortURL(name) = %q, want %q", got, want)
+	}
+	q.Sort = "name"
+	if got, want := q.SortURL("name"), "/pods?dir=desc&namespace=payments"; got != want {
+		t.Errorf("SortURL(name) on the default sort = %q, want %q", got, want)
+	}
+}
+
+func TestListQueryKeep(t *testing.T) {
+	q := &ListQuery{Filter: "Web", Status: "Failed"}
+
+	if !q.Keep("web-1", "Failed") {
+		t.Error("Keep(web-1, Failed) = false, want true")
+	}
+	if q.Keep("web-2", "Running") {
+		t.Error("Keep(web-2, Running) = true, want false")
+	}
+	if q.Keep("api-1", "Failed") {
+		t.Error("Keep(api-1, Failed) = true, want false")
+	}
+	if got := q.StatusOptions(); len(got) != 2 || got[0] != "Failed" || got[1] != "Running" {
+		t.Errorf("StatusOptions() = %v, want [Failed Running]", got)
+	}
+}
+
+func TestSortItems(t *testing.T) {
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+	}
+
+	sortItems(pods, &ListQuery{Sort: "name", Desc: true}, nil)
+	if pods[0].Name != "c" || pods[2].Name != "a" {
+		t.Errorf("descending name sort gave %s,%s,%s", pods[0].Name, pods[1].Name, pods[2].Name)
+	}
+
+	sortItems(pods, &ListQuery{Sort: "unknown"}, nil)
+	if pods[0].Name != "c" {
+		t.Error("unknown sort key reordered items")
+	}
+}
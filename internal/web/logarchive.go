@@ -0,0 +1,262 @@
+package web
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// logArchiveParallelism is how many logs a workload log archive reads from
+// the API server at once.
+const logArchiveParallelism = 8
+
+// logArchiveEntry is one log in a workload log archive.
+type logArchiveEntry struct {
+	Pod       string
+	Container string
+	Previous  bool
+}
+
+// Path is where the log is stored in the archive: a directory per pod with
+// a file per container, and "-previous" for the log of the container's
+// last run.
+func (e logArchiveEntry) Path() string {
+	name := e.Container
+	if e.Previous {
+		name += "-previous"
+	}
+	return e.Pod + "/" + name + ".log"
+}
+
+// logArchiveEntries lists the logs of pods, sorted by pod with the
+// containers of each in the order of the pod spec. With previous, the log
+// of the last run is added for containers that have restarted; the others
+// have none.
+func logArchiveEntries(pods []corev1.Pod, previous bool) []logArchiveEntry {
+	var entries []logArchiveEntry
+	for _, pod := range pods {
+		restarted := make(map[string]bool)
+		for _, cs := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+			restarted[cs.Name] = cs.RestartCount > 0 || cs.LastTerminationState.Terminated != nil
+		}
+		for _, c := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+			entries = append(entries, logArchiveEntry{Pod: pod.Name, Container: c.Name})
+			if previous && restarted[c.Name] {
+				entries = append(entries, logArchiveEntry{Pod: pod.Name, Container: c.Name, Previous: true})
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Pod < entries[j].Pod })
+	return entries
+}
+
+// workloadPods returns the pods of a Deployment or Job in namespace,
+// together with the workload and, for a Deployment, its ReplicaSets, old
+// and new, whose pods they are.
+func (s *Server) workloadPods(ctx context.Context, kind, namespace, name string) ([]runtime.Object, []corev1.Pod, error) {
+	client := s.manager.Client()
+	var objects []runtime.Object
+	var selector *metav1.LabelSelector
+	owners := make(map[string]bool)
+	switch kind {
+	case "deployments":
+		d, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		objects = append(objects, d)
+		selector = d.Spec.Selector
+		sel, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return nil, nil, err
+		}
+		rsList, err := client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: sel.String()})
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range rsList.Items {
+			if metav1.IsControlledBy(&rsList.Items[i], d) {
+				objects = append(objects, &rsList.Items[i])
+				owners[string(rsList.Items[i].UID)] = true
+			}
+		}
+	case "jobs":
+		j, err := client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		objects = append(objects, j)
+		selector = j.Spec.Selector
+		owners[string(j.UID)] = true
+	default:
+		return nil, nil, fmt.Errorf("unknown workload kind %q", kind)
+	}
+
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	list, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: sel.String()})
+	if err != nil {
+		return nil, nil, err
+	}
+	var pods []corev1.Pod
+	for _, p := range list.Items {
+		if ref := metav1.GetControllerOf(&p); ref != nil && owners[string(ref.UID)] {
+			pods = append(pods, p)
+		}
+	}
+	return objects, pods, nil
+}
+
+// readLogs reads the logs of entries from namespace in parallel into dir,
+// one file per entry named by its index, and returns the error of each
+// entry. opts are the log options of every entry; the container and
+// whether to read the previous log are set per entry.
+func (s *Server) readLogs(ctx context.Context, namespace, dir string, entries []logArchiveEntry, opts corev1.PodLogOptions) []error {
+	client := s.manager.Client()
+	errs := make([]error, len(entries))
+	sem := make(chan struct{}, logArchiveParallelism)
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			opts := opts
+			opts.Container = e.Container
+			opts.Previous = e.Previous
+			stream, err := client.CoreV1().Pods(namespace).GetLogs(e.Pod, &opts).Stream(ctx)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer stream.Close()
+			f, err := os.Create(filepath.Join(dir, strconv.Itoa(i)))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer f.Close()
+			_, errs[i] = io.Copy(f, stream)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// zipLogs adds the logs read by readLogs to zw under prefix, and returns
+// the entries that could not be read with their errors. An error is only
+// returned when writing the archive fails.
+func zipLogs(zw *zip.Writer, prefix, dir string, entries []logArchiveEntry, errs []error, modified time.Time) ([]string, error) {
+	var failed []string
+	for i, e := range entries {
+		if errs[i] != nil {
+			failed = append(failed, fmt.Sprintf("%s%s: %v", prefix, e.Path(), errs[i]))
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, strconv.Itoa(i)))
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s%s: %v", prefix, e.Path(), err))
+			continue
+		}
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: prefix + e.Path(), Method: zip.Deflate, Modified: modified})
+		if err == nil {
+			_, err = io.Copy(fw, f)
+		}
+		f.Close()
+		if err != nil {
+			return failed, err
+		}
+	}
+	return failed, nil
+}
+
+// zipFile adds a file with content to zw.
+func zipFile(zw *zip.Writer, name string, content []byte, modified time.Time) error {
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(content)
+	return err
+}
+
+// handleWorkloadLogsArchive downloads the logs of every pod of the
+// Deployment or Job at /<kind>/<name>/logs.zip as a zip archive, with the
+// previous logs of restarted containers when ?previous=true. The logs are
+// read in parallel into temporary files, so a slow container does not hold
+// up the others, and are then written to the archive in order. Logs that
+// cannot be read are listed in errors.txt in the archive. The namespace's
+// log limits apply as they do to single downloads.
+func (s *Server) handleWorkloadLogsArchive(w http.ResponseWriter, r *http.Request, kind string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"+kind+"/"), "/logs.zip")
+	namespace := s.namespace(r)
+
+	_, pods, err := s.workloadPods(r.Context(), kind, namespace, name)
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "list", "pods", "", "/"+kind+"/"+name, kind) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(pods) == 0 {
+		http.Error(w, fmt.Sprintf("%s has no pods in namespace %s", name, namespace), http.StatusNotFound)
+		return
+	}
+	entries := logArchiveEntries(pods, r.URL.Query().Get("previous") == "true")
+
+	r, dir, done, err := s.trackDownload(r, "k8s-ui-logs-", "logs of "+artifactName(strings.TrimSuffix(kind, "s"), name, ""))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer done()
+
+	limit := s.namespaceLogLimit(namespace)
+	opts := corev1.PodLogOptions{SinceSeconds: sinceSeconds(limit.MaxSince)}
+	if limit.MaxTailLines > 0 {
+		opts.TailLines = &limit.MaxTailLines
+	}
+	errs := s.readLogs(r.Context(), namespace, dir, entries, opts)
+	if err := downloadStopped(r); err != nil {
+		http.Error(w, "The download was stopped: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s-%s-logs.zip", strings.TrimSuffix(kind, "s"), name)))
+	zw := zip.NewWriter(w)
+	now := time.Now()
+	failed, err := zipLogs(zw, "", dir, entries, errs, now)
+	if err != nil {
+		// The response has started, so the archive is left truncated.
+		return
+	}
+	if len(failed) > 0 {
+		if zipFile(zw, "errors.txt", []byte(strings.Join(failed, "\n")+"\n"), now) != nil {
+			return
+		}
+	}
+	zw.Close()
+}
@@ -0,0 +1,61 @@
+package web
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLogArchiveEntries(t *testing.T) {
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-b"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-a"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate"}},
+				Containers:     []corev1.Container{{Name: "app"}, {Name: "proxy"}},
+			},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", RestartCount: 2},
+				{Name: "proxy"},
+			}},
+		},
+	}
+
+	got := logArchiveEntries(pods, false)
+	want := []logArchiveEntry{
+		{Pod: "web-a", Container: "migrate"},
+		{Pod: "web-a", Container: "app"},
+		{Pod: "web-a", Container: "proxy"},
+		{Pod: "web-b", Container: "app"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("without previous = %v, want %v", got, want)
+	}
+
+	got = logArchiveEntries(pods, true)
+	want = []logArchiveEntry{
+		{Pod: "web-a", Container: "migrate"},
+		{Pod: "web-a", Container: "app"},
+		{Pod: "web-a", Container: "app", Previous: true},
+		{Pod: "web-a", Container: "proxy"},
+		{Pod: "web-b", Container: "app"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("with previous = %v, want %v", got, want)
+	}
+}
+
+func TestLogArchiveEntryPath(t *testing.T) {
+	if got := (logArchiveEntry{Pod: "web-a", Container: "app"}).Path(); got != "web-a/app.log" {
+		t.Errorf("Path() = %q", got)
+	}
+	if got := (logArchiveEntry{Pod: "web-a", Container: "app", Previous: true}).Path(); got != "web-a/app-previous.log" {
+		t.Errorf("previous Path() = %q", got)
+	}
+}
@@ -0,0 +1,261 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Defaults for Config.LogMaxLines and Config.LogMaxBytes. Rendering much
+// more than this into the log page stalls both the server and the browser.
+const (
+	defaultLogMaxLines = 10000
+	defaultLogMaxBytes = 5 << 20
+)
+
+// logLimits returns the line and byte caps for rendered log pages.
+func (s *Server) logLimits() (maxLines, maxBytes int64) {
+	maxLines, maxBytes = s.config.LogMaxLines, s.config.LogMaxBytes
+	if maxLines <= 0 {
+		maxLines = defaultLogMaxLines
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultLogMaxBytes
+	}
+	return maxLines, maxBytes
+}
+
+// defaultLogTailLines is how many lines the log page shows when neither
+// the request nor the namespace's LogLimit says.
+const defaultLogTailLines = 200
+
+// LogLimit sets how much log is read from the pods of a namespace, to
+// protect the kubelet and k8s-ui from namespaces with very chatty pods.
+// Zero fields fall back to the server-wide behaviour.
+type LogLimit struct {
+	Namespace    string        // "*" applies to namespaces without a limit of their own
+	TailLines    int64         // lines the log page shows by default
+	MaxTailLines int64         // most lines any log request reads
+	Since        time.Duration // how far back the log page reads by default
+	MaxSince     time.Duration // furthest back any log request reads
+}
+
+// ParseLogLimits reads one namespace per line as "namespace key=value...",
+// with the keys tail, max-tail, since and max-since; since and max-since are
+// durations such as 15m. Blank lines and lines starting with # are skipped.
+func ParseLogLimits(r io.Reader) ([]LogLimit, error) {
+	var limits []LogLimit
+	seen := make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: want \"namespace key=value...\"", n)
+		}
+		l := LogLimit{Namespace: fields[0]}
+		if l.Namespace != "*" {
+			if errs := validation.IsDNS1123Label(l.Namespace); len(errs) > 0 {
+				return nil, fmt.Errorf("line %d: invalid namespace %q: %s", n, l.Namespace, strings.Join(errs, "; "))
+			}
+		}
+		if seen[l.Namespace] {
+			return nil, fmt.Errorf("line %d: duplicate namespace %q", n, l.Namespace)
+		}
+		seen[l.Namespace] = true
+		for _, f := range fields[1:] {
+			key, value, ok := strings.Cut(f, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: %q is not key=value", n, f)
+			}
+			var err error
+			switch key {
+			case "tail", "max-tail":
+				var v int64
+				v, err = strconv.ParseInt(value, 10, 64)
+				if err == nil && v <= 0 {
+					err = fmt.Errorf("must be positive")
+				}
+				if key == "tail" {
+					l.TailLines = v
+				} else {
+					l.MaxTailLines = v
+				}
+			case "since", "max-since":
+				var d time.Duration
+				d, err = time.ParseDuration(value)
+				if err == nil && d < time.Second {
+					err = fmt.Errorf("must be at least 1s")
+				}
+				if key == "since" {
+					l.Since = d
+				} else {
+					l.MaxSince = d
+				}
+			default:
+				return nil, fmt.Errorf("line %d: unknown key %q, want tail, max-tail, since or max-since", n, key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s %q: %v", n, key, value, err)
+			}
+		}
+		if l.MaxTailLines > 0 && l.TailLines > l.MaxTailLines {
+			return nil, fmt.Errorf("line %d: tail is above max-tail", n)
+		}
+		if l.MaxSince > 0 && l.Since > l.MaxSince {
+			return nil, fmt.Errorf("line %d: since is above max-since", n)
+		}
+		limits = append(limits, l)
+	}
+	return limits, sc.Err()
+}
+
+// namespaceLogLimit returns the LogLimit of a namespace, the "*" one for
+// namespaces without their own, or none.
+func (s *Server) namespaceLogLimit(namespace string) LogLimit {
+	var fallback LogLimit
+	for _, l := range s.config.LogLimits {
+		if l.Namespace == namespace {
+			return l
+		}
+		if l.Namespace == "*" {
+			fallback = l
+		}
+	}
+	return fallback
+}
+
+// LogFetch is what a log request reads after the namespace's LogLimit is
+// applied.
+type LogFetch struct {
+	TailLines   int64
+	Since       time.Duration // zero reads the whole retained log
+	LinesCapped bool          // tailLines was cut to the page or namespace cap
+	SinceCapped bool          // since was cut to the namespace's max-since
+}
+
+// logFetch works out the tail and since of a log page request from its
+// tailLines and since parameters, the namespace's limit and the page's line
+// cap. since is a duration such as 15m, or a number of seconds. Invalid
+// values fall back to the defaults.
+func logFetch(limit LogLimit, pageMaxLines int64, tailParam, sinceParam string) LogFetch {
+	f := LogFetch{TailLines: defaultLogTailLines, Since: limit.Since}
+	if limit.TailLines > 0 {
+		f.TailLines = limit.TailLines
+	}
+	if tailParam != "" {
+		if v, err := strconv.ParseInt(tailParam, 10, 64); err == nil {
+			f.TailLines = v
+		}
+	}
+	maxLines := pageMaxLines
+	if limit.MaxTailLines > 0 && limit.MaxTailLines < maxLines {
+		maxLines = limit.MaxTailLines
+	}
+	if f.TailLines < 0 || f.TailLines > maxLines {
+		f.TailLines = maxLines
+		f.LinesCapped = true
+	}
+
+	if sinceParam != "" {
+		if secs, err := strconv.ParseInt(sinceParam, 10, 64); err == nil && secs >= 0 {
+			f.Since = time.Duration(secs) * time.Second
+		} else if d, err := time.ParseDuration(sinceParam); err == nil && d >= 0 {
+			f.Since = d
+		}
+	}
+	if limit.MaxSince > 0 && (f.Since == 0 || f.Since > limit.MaxSince) {
+		f.Since = limit.MaxSince
+		f.SinceCapped = true
+	}
+	return f
+}
+
+// sinceSeconds converts a since duration to the log API's sinceSeconds,
+// which is nil to read the whole log.
+func sinceSeconds(d time.Duration) *int64 {
+	if d <= 0 {
+		return nil
+	}
+	secs := int64((d + time.Second - 1) / time.Second)
+	return &secs
+}
+
+// shortDuration renders d for a form field so that time.ParseDuration reads
+// it back, without zero units: "1h" rather than "1h0m0s".
+func shortDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// readLogTail reads src to the end but keeps only its last maxBytes,
+// starting at a line boundary, so a log with very long lines is bounded in
+// memory as well as in the page. It reports whether anything was dropped.
+func readLogTail(src io.Reader, maxBytes int64) (string, bool, error) {
+	// Keep one byte more than the cap to tell whether the cut falls on a
+	// line boundary.
+	keep := maxBytes + 1
+	var buf []byte
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if int64(len(buf)) > 2*keep {
+			buf = append(buf[:0], buf[int64(len(buf))-keep:]...)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", false, err
+		}
+	}
+	if int64(len(buf)) <= maxBytes {
+		return string(buf), false, nil
+	}
+	buf = buf[int64(len(buf))-keep:]
+	if buf[0] == '\n' {
+		return string(buf[1:]), true, nil
+	}
+	// Drop the partial line the cut landed in.
+	i := bytes.IndexByte(buf, '\n')
+	if i < 0 {
+		return "", true, nil
+	}
+	return string(buf[i+1:]), true, nil
+}
+
+// logLimitNote says what a namespace's LogLimit allows, for log pages whose
+// request it cut.
+func logLimitNote(namespace string, limit LogLimit) string {
+	var parts []string
+	if limit.MaxTailLines > 0 {
+		parts = append(parts, fmt.Sprintf("at most %d lines", limit.MaxTailLines))
+	}
+	if limit.MaxSince > 0 {
+		parts = append(parts, "the last "+shortDuration(limit.MaxSince))
+	}
+	scope := "Namespace " + namespace
+	if limit.Namespace == "*" {
+		scope = "This server"
+	}
+	return scope + " limits log requests to " + strings.Join(parts, " and ") + ", including downloads."
+}
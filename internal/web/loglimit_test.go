@@ -0,0 +1,114 @@
+package web
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadLogTail(t *testing.T) {
+	tests := []struct {
+		name          string
+		log           string
+		maxBytes      int64
+		want          string
+		wantTruncated bool
+	}{
+		{"fits", "a\nb\n", 10, "a\nb\n", false},
+		{"exact", "a\nb\n", 4, "a\nb\n", false},
+		{"cut at line start", "first\nsecond\nthird\n", 12, "third\n", true},
+		{"cut mid line", "first\nsecond\nthird\n", 9, "third\n", true},
+		{"cut at line boundary", "first\nsecond\nthird\n", 13, "second\nthird\n", true},
+		{"one long line", strings.Repeat("x", 100), 10, "", true},
+		{"long log", strings.Repeat("0123456789\n", 100000), 25, "0123456789\n0123456789\n", true},
+	}
+
+	for _, tt := range tests {
+		got, truncated, err := readLogTail(strings.NewReader(tt.log), tt.maxBytes)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want || truncated != tt.wantTruncated {
+			t.Errorf("%s: readLogTail() = %q, %v, want %q, %v", tt.name, got, truncated, tt.want, tt.wantTruncated)
+		}
+	}
+}
+
+func TestParseLogLimits(t *testing.T) {
+	limits, err := ParseLogLimits(strings.NewReader(`
+# namespace     limits
+ingress-nginx   tail=100 max-tail=2000 max-since=1h
+*               max-tail=20000
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LogLimit{
+		{Namespace: "ingress-nginx", TailLines: 100, MaxTailLines: 2000, MaxSince: time.Hour},
+		{Namespace: "*", MaxTailLines: 20000},
+	}
+	if len(limits) != len(want) || limits[0] != want[0] || limits[1] != want[1] {
+		t.Errorf("ParseLogLimits() = %+v, want %+v", limits, want)
+	}
+
+	for _, bad := range []string{
+		"ingress-nginx",
+		"Ingress tail=10",
+		"ingress-nginx tail=10\ningress-nginx tail=20",
+		"ingress-nginx tail=ten",
+		"ingress-nginx tail=0",
+		"ingress-nginx since=500ms",
+		"ingress-nginx lines=10",
+		"ingress-nginx tail=500 max-tail=100",
+		"ingress-nginx since=2h max-since=1h",
+	} {
+		if _, err := ParseLogLimits(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseLogLimits(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestLogFetch(t *testing.T) {
+	strict := LogLimit{Namespace: "ingress-nginx", TailLines: 100, MaxTailLines: 2000, Since: 10 * time.Minute, MaxSince: time.Hour}
+	tests := []struct {
+		name        string
+		limit       LogLimit
+		tail, since string
+		want        LogFetch
+	}{
+		{"defaults", LogLimit{}, "", "", LogFetch{TailLines: 200}},
+		{"requested", LogLimit{}, "500", "15m", LogFetch{TailLines: 500, Since: 15 * time.Minute}},
+		{"seconds", LogLimit{}, "", "90", LogFetch{TailLines: 200, Since: 90 * time.Second}},
+		{"page cap", LogLimit{}, "50000", "", LogFetch{TailLines: 10000, LinesCapped: true}},
+		{"all lines", LogLimit{}, "-1", "", LogFetch{TailLines: 10000, LinesCapped: true}},
+		{"namespace defaults", strict, "", "", LogFetch{TailLines: 100, Since: 10 * time.Minute}},
+		{"namespace caps", strict, "5000", "3h", LogFetch{TailLines: 2000, Since: time.Hour, LinesCapped: true, SinceCapped: true}},
+		{"whole log capped", strict, "", "0", LogFetch{TailLines: 100, Since: time.Hour, SinceCapped: true}},
+		{"invalid", strict, "many", "soon", LogFetch{TailLines: 100, Since: 10 * time.Minute}},
+	}
+	for _, tt := range tests {
+		if got := logFetch(tt.limit, 10000, tt.tail, tt.since); got != tt.want {
+			t.Errorf("%s: logFetch() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestShortDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                          "",
+		90 * time.Second:           "1m30s",
+		15 * time.Minute:           "15m",
+		2 * time.Hour:              "2h",
+		time.Hour + 30*time.Minute: "1h30m",
+	}
+	for d, want := range tests {
+		if got := shortDuration(d); got != want {
+			t.Errorf("shortDuration(%v) = %q, want %q", d, got, want)
+		}
+		if d > 0 {
+			if back, err := time.ParseDuration(shortDuration(d)); err != nil || back != d {
+				t.Errorf("shortDuration(%v) does not parse back: %v, %v", d, back, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logLevels are the normalised levels understood by the parsed log view,
+// from least to most severe.
+var logLevels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// Keys checked, in order, for the well-known fields of a structured log
+// line. These cover the defaults of zap, logrus, slog, pino, bunyan and
+// the Elastic Common Schema.
+var (
+	logLevelKeys   = []string{"level", "lvl", "severity", "log.level", "loglevel"}
+	logTimeKeys    = []string{"time", "ts", "timestamp", "@timestamp", "t"}
+	logMessageKeys = []string{"msg", "message", "@message"}
+)
+
+// LogField is an extra key/value pair of a structured log line.
+type LogField struct {
+	Key   string
+	Value string
+}
+
+// LogLine is one line of container output as shown in the parsed log view.
+// Lines that are not JSON objects keep only Raw and render as-is.
+type LogLine struct {
+	Raw     string
+	JSON    bool
+	Level   string
+	Time    string
+	Message string
+	Fields  []LogField
+}
+
+// parseLogs splits container output into lines and parses each one,
+// returning the lines and how many of them were JSON.
+func parseLogs(logs string) ([]LogLine, int) {
+	logs = strings.TrimSuffix(logs, "\n")
+	if logs == "" {
+		return nil, 0
+	}
+	raw := strings.Split(logs, "\n")
+	lines := make([]LogLine, 0, len(raw))
+	structured := 0
+	for _, l := range raw {
+		line := parseLogLine(strings.TrimSuffix(l, "\r"))
+		if line.JSON {
+			structured++
+		}
+		lines = append(lines, line)
+	}
+	return lines, structured
+}
+
+// parseLogLine extracts the level, timestamp and message of a JSON log
+// line. Any other line is returned with JSON unset.
+func parseLogLine(raw string) LogLine {
+	line := LogLine{Raw: raw}
+	trimmed := strings.TrimSpace(raw)
+	if !strings.HasPrefix(trimmed, "{") {
+		return line
+	}
+
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil || dec.More() {
+		return line
+	}
+	line.JSON = true
+
+	if k, v := takeLogField(obj, logLevelKeys); k != "" {
+		line.Level = normalizeLogLevel(v)
+	}
+	if k, v := takeLogField(obj, logTimeKeys); k != "" {
+		line.Time = formatLogTime(v)
+	}
+	if k, v := takeLogField(obj, logMessageKeys); k != "" {
+		line.Message = formatLogValue(v)
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		line.Fields = append(line.Fields, LogField{Key: k, Value: formatLogValue(obj[k])})
+	}
+	return line
+}
+
+// takeLogField removes and returns the first of keys present in obj.
+func takeLogField(obj map[string]any, keys []string) (string, any) {
+	for _, k := range keys {
+		if v, ok := obj[k]; ok {
+			delete(obj, k)
+			return k, v
+		}
+	}
+	return "", nil
+}
+
+// normalizeLogLevel maps the level spellings used by common loggers,
+// including pino/bunyan numeric levels, onto logLevels. Unrecognised
+// levels are returned lower-cased.
+func normalizeLogLevel(v any) string {
+	if n, ok := v.(json.Number); ok {
+		i, err := n.Int64()
+		if err != nil {
+			return n.String()
+		}
+		switch {
+		case i >= 60:
+			return "fatal"
+		case i >= 50:
+			return "error"
+		case i >= 40:
+			return "warn"
+		case i >= 30:
+			return "info"
+		case i >= 20:
+			return "debug"
+		default:
+			return "trace"
+		}
+	}
+
+	level := strings.ToLower(strings.TrimSpace(formatLogValue(v)))
+	switch level {
+	case "trc", "trace":
+		return "trace"
+	case "dbg", "debug":
+		return "debug"
+	case "inf", "info", "information", "notice":
+		return "info"
+	case "wrn", "warn", "warning":
+		return "warn"
+	case "err", "error":
+		return "error"
+	case "crit", "critical", "alert", "emerg", "emergency", "panic", "dpanic", "fatal":
+		return "fatal"
+	}
+	return level
+}
+
+// logLevelRank is the position of level in logLevels, or -1.
+func logLevelRank(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatLogTime renders epoch timestamps in RFC 3339: seconds as written
+// by zap, or milliseconds as written by pino and bunyan. String
+// timestamps are passed through unchanged.
+func formatLogTime(v any) string {
+	if n, ok := v.(json.Number); ok {
+		if f, err := strconv.ParseFloat(n.String(), 64); err == nil {
+			if f > 1e11 {
+				f /= 1000
+			}
+			sec := int64(f)
+			return time.Unix(sec, int64((f-float64(sec))*1e9)).UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return formatLogValue(v)
+}
+
+func formatLogValue(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case nil:
+		return "null"
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// filterLogLines keeps the lines at or above minLevel. Plain-text lines
+// follow the structured line before them, so a stack trace printed after
+// an error stays with it. An empty minLevel keeps everything.
+func filterLogLines(lines []LogLine, minLevel string) []LogLine {
+	threshold := logLevelRank(minLevel)
+	if threshold < 0 {
+		return lines
+	}
+	var out []LogLine
+	keep := false
+	for _, l := range lines {
+		if l.JSON {
+			keep = logLevelRank(l.Level) >= threshold
+		}
+		if keep {
+			out = append(out, l)
+		}
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package web
+
+import "testing"
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		raw     string
+		json    bool
+		level   string
+		time    string
+		message string
+		fields  int
+	}{
+		{raw: "plain text line"},
+		{raw: "{not json"},
+		{raw: `{"a":1} trailing`},
+		{raw: `{"level":"INFO","time":"2026-01-02T03:04:05Z","msg":"started","port":8080}`, json: true, level: "info", time: "2026-01-02T03:04:05Z", message: "started", fields: 1},
+		{raw: `{"severity":"WARNING","message":"slow","@timestamp":"x"}`, json: true, level: "warn", time: "x", message: "slow"},
+		{raw: `{"level":50,"time":1700000000000,"msg":"boom"}`, json: true, level: "error", time: "2023-11-14T22:13:20Z", message: "boom"},
+		{raw: `{"level":"error","ts":1700000000.5,"msg":"x","err":{"code":3}}`, json: true, level: "error", time: "2023-11-14T22:13:20.5Z", message: "x", fields: 1},
+		{raw: `{"foo":"bar"}`, json: true, fields: 1},
+	}
+
+	for _, tt := range tests {
+		got := parseLogLine(tt.raw)
+		if got.JSON != tt.json || got.Level != tt.level || got.Time != tt.time || got.Message != tt.message || len(got.Fields) != tt.fields {
+			t.Errorf("parseLogLine(%q) = %+v", tt.raw, got)
+		}
+	}
+}
+
+func TestFilterLogLines(t *testing.T) {
+	lines, structured := parseLogs(`{"level":"info","msg":"a"}
+{"level":"error","msg":"b"}
+  at main.go:10
+{"level":"debug","msg":"c"}
+  not shown
+`)
+	if len(lines) != 5 || structured != 3 {
+		t.Fatalf("parseLogs gave %d lines, %d structured", len(lines), structured)
+	}
+
+	got := filterLogLines(lines, "warn")
+	if len(got) != 2 || got[0].Message != "b" || got[1].Raw != "  at main.go:10" {
+		t.Errorf("filterLogLines(warn) = %+v", got)
+	}
+	if got := filterLogLines(lines, ""); len(got) != 5 {
+		t.Errorf("filterLogLines(\"\") kept %d lines, want 5", len(got))
+	}
+}
@@ -0,0 +1,133 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+type namespaceOverrideKey struct{}
+
+// namespace returns the namespace a request operates in: the ?namespace=
+// override when one was accepted by withNamespaceOverride, otherwise the
+// namespace selected in the UI.
+func (s *Server) namespace(r *http.Request) string {
+	if ns, ok := r.Context().Value(namespaceOverrideKey{}).(string); ok {
+		return ns
+	}
+	return s.manager.Namespace()
+}
+
+// withNamespaceOverride lets any page be opened in a specific namespace via
+// ?namespace=, so shared links land on the right namespace regardless of the
+// viewer's current selection. The namespace must pass checkNamespace, like
+// one switched to. The selected namespace itself is unchanged.
+func (s *Server) withNamespaceOverride(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ns := strings.TrimSpace(r.URL.Query().Get("namespace"))
+		// The switch endpoints take namespace as their own form input.
+		if ns == "" || strings.HasPrefix(r.URL.Path, "/api/switch-") || ns == s.manager.Namespace() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			http.Error(w, fmt.Sprintf("Invalid namespace %q: %s", ns, strings.Join(errs, "; ")), http.StatusBadRequest)
+			return
+		}
+		if !s.manager.IsNamespaceAllowed(ns) {
+			s.renderPermissionDenied(w, r, "Namespace not allowed",
+				fmt.Sprintf("Namespace %s is not in the POD_NAMESPACES allowlist.", ns), "/", "")
+			return
+		}
+		if status, err := s.checkNamespaceCached(r.Context(), ns); err != nil {
+			http.Error(w, "Cannot open namespace: "+err.Error(), status)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), namespaceOverrideKey{}, ns)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// namespaceCheckTTL is how long withNamespaceOverride reuses the result of
+// checking a namespace, so following links within it costs no API calls.
+const namespaceCheckTTL = time.Minute
+
+type namespaceCheck struct {
+	checked time.Time
+	status  int
+	err     error
+}
+
+// namespaceChecks caches, per context and namespace, the result of
+// checkNamespace. Results that only say the API server could not be
+// asked are not kept.
+type namespaceChecks struct {
+	mu      sync.Mutex
+	entries map[string]namespaceCheck
+}
+
+// checkNamespaceCached is checkNamespace with its result reused for
+// namespaceCheckTTL.
+func (s *Server) checkNamespaceCached(ctx context.Context, ns string) (int, error) {
+	nc := &s.namespaces
+	_, current := s.manager.Contexts()
+	key := current + "/" + ns
+	nc.mu.Lock()
+	e, ok := nc.entries[key]
+	nc.mu.Unlock()
+	if ok && time.Since(e.checked) < namespaceCheckTTL {
+		return e.status, e.err
+	}
+
+	status, err := s.checkNamespace(ctx, ns)
+	if status == http.StatusBadGateway {
+		return status, err
+	}
+	nc.mu.Lock()
+	if nc.entries == nil {
+		nc.entries = make(map[string]namespaceCheck)
+	}
+	nc.entries[key] = namespaceCheck{checked: time.Now(), status: status, err: err}
+	nc.mu.Unlock()
+	return status, err
+}
+
+// checkNamespace verifies that a namespace can be switched to: that it
+// exists or, when namespaces cannot be read, that pods may be listed in it,
+// which a typo never allows unless pods may be listed everywhere. It
+// returns the HTTP status to answer with when it cannot.
+func (s *Server) checkNamespace(ctx context.Context, ns string) (int, error) {
+	client := s.manager.Client()
+	_, err := client.CoreV1().Namespaces().Get(ctx, ns, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		return http.StatusOK, nil
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound, fmt.Errorf("namespace %s does not exist", ns)
+	case !apierrors.IsForbidden(err):
+		return http.StatusBadGateway, fmt.Errorf("namespace %s could not be checked: %w", ns, err)
+	}
+
+	review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &authorizationv1.ResourceAttributes{
+			Namespace: ns, Verb: "list", Resource: "pods",
+		}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return http.StatusBadGateway, fmt.Errorf("namespace %s could not be checked: %w", ns, err)
+	}
+	if !review.Status.Allowed {
+		return http.StatusForbidden, fmt.Errorf("namespace %s does not exist or you may not use it: listing its pods is not allowed", ns)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// defaultLandingPage is where / redirects when Config.LandingPage is not set.
+const defaultLandingPage = "/pods"
+
+// NavSections are the sections of the top navigation, in their default
+// order.
+var NavSections = []string{"workloads", "config", "networking", "storage", "events", "resources"}
+
+// ParseNavSections checks a list of navigation sections, such as
+// "workloads", "events", for unknown names and duplicates.
+func ParseNavSections(names []string) ([]string, error) {
+	var out []string
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if !slices.Contains(NavSections, name) {
+			return nil, fmt.Errorf("unknown section %q, must be one of %s", name, strings.Join(NavSections, ", "))
+		}
+		if slices.Contains(out, name) {
+			return nil, fmt.Errorf("section %q is listed twice", name)
+		}
+		out = append(out, name)
+	}
+	return out, nil
+}
+
+// ParseLandingPage checks that a landing page is a path on this server,
+// such as /deployments or /events?since=1h. A bare page name such as
+// "deployments" is taken as its path.
+func ParseLandingPage(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "" || u.Host != "" || strings.HasPrefix(raw, "//") {
+		return "", fmt.Errorf("must be a page path such as /deployments")
+	}
+	if !strings.HasPrefix(raw, "/") {
+		raw = "/" + raw
+	}
+	if strings.TrimPrefix(u.Path, "/") == "" {
+		return "", fmt.Errorf("must be a page path such as /deployments")
+	}
+	return raw, nil
+}
+
+func (s *Server) landingPage() string {
+	if s.config.LandingPage != "" {
+		return s.config.LandingPage
+	}
+	return defaultLandingPage
+}
+
+func (s *Server) navSections() []string {
+	if len(s.config.NavSections) > 0 {
+		return s.config.NavSections
+	}
+	return NavSections
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNavSections(t *testing.T) {
+	tests := []struct {
+		in      []string
+		want    []string
+		wantErr bool
+	}{
+		{in: nil, want: nil},
+		{in: []string{"events", "Workloads"}, want: []string{"events", "workloads"}},
+		{in: []string{"workloads", "overview"}, wantErr: true},
+		{in: []string{"events", "events"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseNavSections(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseNavSections(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseNavSections(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseLandingPage(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "/deployments", want: "/deployments"},
+		{in: "events", want: "/events"},
+		{in: "/events?since=1h", want: "/events?since=1h"},
+		{in: "/", wantErr: true},
+		{in: "//evil.example.com/", wantErr: true},
+		{in: "https://evil.example.com/", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLandingPage(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLandingPage(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLandingPage(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
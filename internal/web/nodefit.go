@@ -0,0 +1,273 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	"k8s.io/klog/v2"
+)
+
+// NodeFitCheck is one rule that decides whether a pod may run on a node.
+type NodeFitCheck struct {
+	Rule   string
+	OK     bool
+	Soft   bool // only makes the scheduler prefer other nodes
+	Detail string
+}
+
+// explainNodeFit checks a pod against the node rules the scheduler filters
+// on before looking at resources: the node it is bound to, cordoning,
+// taints, the nodeSelector and required node affinity. The terms of
+// required node affinity are ORed, so the pod fits when one of them
+// passes; the other checks must all pass.
+func explainNodeFit(pod *corev1.Pod, node *corev1.Node) ([]NodeFitCheck, bool) {
+	var checks []NodeFitCheck
+	fits := true
+	add := func(c NodeFitCheck) {
+		if !c.OK && !c.Soft {
+			fits = false
+		}
+		checks = append(checks, c)
+	}
+
+	if pod.Spec.NodeName != "" {
+		add(NodeFitCheck{
+			Rule:   "spec.nodeName",
+			OK:     pod.Spec.NodeName == node.Name,
+			Detail: "The pod is bound to node " + pod.Spec.NodeName + ".",
+		})
+	}
+
+	taints := node.Spec.Taints
+	if node.Spec.Unschedulable {
+		taints = append([]corev1.Taint{{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}}, taints...)
+	}
+	for i := range taints {
+		t := &taints[i]
+		c := NodeFitCheck{Rule: "Taint " + t.ToString(), Soft: t.Effect == corev1.TaintEffectPreferNoSchedule}
+		if t.Key == corev1.TaintNodeUnschedulable {
+			c.Rule = "Cordoned (" + t.ToString() + ")"
+		}
+		for j := range pod.Spec.Tolerations {
+			tol := &pod.Spec.Tolerations[j]
+			if tol.ToleratesTaint(klog.Background(), t, false) {
+				c.OK = true
+				c.Detail = "Tolerated by " + tolerationString(tol) + "."
+				break
+			}
+		}
+		if !c.OK {
+			switch t.Effect {
+			case corev1.TaintEffectPreferNoSchedule:
+				c.Detail = "Not tolerated; the scheduler avoids this node but may still use it."
+			case corev1.TaintEffectNoExecute:
+				c.Detail = "Not tolerated; the pod is not scheduled here and would be evicted if it were running here."
+			default:
+				c.Detail = "Not tolerated; the pod is not scheduled here."
+			}
+		}
+		add(c)
+	}
+
+	keys := make([]string, 0, len(pod.Spec.NodeSelector))
+	for k := range pod.Spec.NodeSelector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		want := pod.Spec.NodeSelector[k]
+		got, ok := node.Labels[k]
+		c := NodeFitCheck{Rule: fmt.Sprintf("nodeSelector %s=%s", k, want), OK: ok && got == want}
+		switch {
+		case !ok:
+			c.Detail = "The node has no " + k + " label."
+		case got != want:
+			c.Detail = fmt.Sprintf("The node has %s=%s.", k, got)
+		default:
+			c.Detail = "The node has this label."
+		}
+		add(c)
+	}
+
+	if a := pod.Spec.Affinity; a != nil && a.NodeAffinity != nil && a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		terms := a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		var termChecks []NodeFitCheck
+		anyOK := false
+		for i, term := range terms {
+			c := NodeFitCheck{Rule: "Required node affinity", OK: true}
+			if len(terms) > 1 {
+				c.Rule = fmt.Sprintf("Required node affinity, term %d of %d", i+1, len(terms))
+			}
+			var failed []string
+			for _, req := range term.MatchExpressions {
+				if !requirementMatches(node, corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{req}}) {
+					failed = append(failed, requirementString(req)+" ("+nodeLabelString(node.Labels, req.Key)+")")
+				}
+			}
+			for _, req := range term.MatchFields {
+				if !requirementMatches(node, corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{req}}) {
+					failed = append(failed, requirementString(req)+" (the node is "+node.Name+")")
+				}
+			}
+			if len(failed) > 0 {
+				c.OK = false
+				c.Detail = "Fails " + strings.Join(failed, "; ") + "."
+			} else {
+				anyOK = true
+				c.Detail = "All expressions match."
+			}
+			termChecks = append(termChecks, c)
+		}
+		for _, c := range termChecks {
+			// One passing term is enough, so a failing term next to a
+			// passing one does not keep the pod off the node.
+			c.Soft = anyOK && !c.OK
+			add(c)
+		}
+	}
+	return checks, fits
+}
+
+func requirementMatches(node *corev1.Node, term corev1.NodeSelectorTerm) bool {
+	sel, err := nodeaffinity.NewNodeSelector(&corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{term}})
+	return err == nil && sel.Match(node)
+}
+
+// requirementString renders a node selector requirement, such as
+// "topology.kubernetes.io/zone In [eu-west-1a eu-west-1b]".
+func requirementString(req corev1.NodeSelectorRequirement) string {
+	s := req.Key + " " + string(req.Operator)
+	if len(req.Values) > 0 {
+		s += " [" + strings.Join(req.Values, " ") + "]"
+	}
+	return s
+}
+
+func nodeLabelString(nodeLabels map[string]string, key string) string {
+	if v, ok := nodeLabels[key]; ok {
+		return "the node has " + key + "=" + v
+	}
+	return "the node has no " + key + " label"
+}
+
+// tolerationString renders a toleration the way taints are written, such
+// as "dedicated=gpu:NoSchedule" or "Exists" for one that tolerates all.
+func tolerationString(t *corev1.Toleration) string {
+	s := t.Key
+	if s == "" {
+		s = "all taints"
+	}
+	if t.Operator == corev1.TolerationOpExists && t.Key != "" {
+		s += " (Exists)"
+	} else if t.Value != "" {
+		s += "=" + t.Value
+	}
+	if t.Effect != "" {
+		s += ":" + string(t.Effect)
+	}
+	return s
+}
+
+// NodeFitSummary is whether a pod may run on one node, with the first rule
+// that keeps it off.
+type NodeFitSummary struct {
+	Node     string
+	Platform string
+	Fits     bool
+	Reason   string
+}
+
+// nodeFitSummaries checks a pod against each node, for the list of nodes
+// on a pending pod's page.
+func nodeFitSummaries(pod *corev1.Pod, nodes []corev1.Node) []NodeFitSummary {
+	summaries := make([]NodeFitSummary, 0, len(nodes))
+	for i := range nodes {
+		checks, fits := explainNodeFit(pod, &nodes[i])
+		s := NodeFitSummary{Node: nodes[i].Name, Platform: nodePlatform(&nodes[i]), Fits: fits}
+		for _, c := range checks {
+			if !c.OK && !c.Soft {
+				s.Reason = c.Rule
+				break
+			}
+		}
+		summaries = append(summaries, s)
+	}
+	sort.SliceStable(summaries, func(i, j int) bool { return summaries[i].Fits && !summaries[j].Fits })
+	return summaries
+}
+
+type NodeFitPage struct {
+	BasePage
+	Pod      string
+	Node     string
+	Platform string
+	Pods     []string
+	Nodes    []string
+	Error    string
+	Ran      bool
+	Fits     bool
+	Checks   []NodeFitCheck
+}
+
+// handleNodeFit explains, for ?pod= in the current namespace and ?node=,
+// which taints the pod does not tolerate and which nodeSelector and node
+// affinity rules the node fails. Resources and inter-pod rules are not
+// checked here.
+func (s *Server) handleNodeFit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace := s.namespace(r)
+	client := s.manager.Client()
+	data := NodeFitPage{
+		BasePage: BasePage{Namespace: namespace, Title: "Node Fit", Active: "resources"},
+		Pod:      strings.TrimSpace(r.URL.Query().Get("pod")),
+		Node:     strings.TrimSpace(r.URL.Query().Get("node")),
+	}
+
+	// Suggestions for the form; either list may be unreadable.
+	if pods, err := client.CoreV1().Pods(namespace).List(r.Context(), metav1.ListOptions{}); err == nil {
+		for _, p := range pods.Items {
+			data.Pods = append(data.Pods, p.Name)
+		}
+		sort.Strings(data.Pods)
+	}
+	if nodes, err := client.CoreV1().Nodes().List(r.Context(), metav1.ListOptions{}); err == nil {
+		for _, n := range nodes.Items {
+			data.Nodes = append(data.Nodes, n.Name)
+		}
+		sort.Strings(data.Nodes)
+	}
+
+	if data.Pod != "" && data.Node != "" {
+		pod, err := client.CoreV1().Pods(namespace).Get(r.Context(), data.Pod, metav1.GetOptions{})
+		if err != nil {
+			if s.handleK8sForbidden(w, r, err, "get", "pods", data.Pod, "/pods", "pods") {
+				return
+			}
+			data.Error = err.Error()
+			s.renderTemplate(w, "node_fit.html", data)
+			return
+		}
+		node, err := client.CoreV1().Nodes().Get(r.Context(), data.Node, metav1.GetOptions{})
+		switch {
+		case apierrors.IsForbidden(err):
+			data.Error = fmt.Sprintf("You are not allowed to get nodes/%s. Nodes are cluster-scoped and need a ClusterRole.", data.Node)
+		case err != nil:
+			data.Error = err.Error()
+		default:
+			data.Ran = true
+			data.Platform = nodePlatform(node)
+			data.Checks, data.Fits = explainNodeFit(pod, node)
+		}
+	}
+
+	s.renderTemplate(w, "node_fit.html", data)
+}
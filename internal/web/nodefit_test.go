@@ -0,0 +1,85 @@
+package web
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExplainNodeFit(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu-1", Labels: map[string]string{"disktype": "hdd", "topology.kubernetes.io/zone": "b"}},
+		Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+			{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+			{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+		}},
+	}
+	zone := func(values ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: "topology.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: values},
+		}}
+	}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+	tolerateGPU := []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+
+	tests := []struct {
+		name   string
+		spec   corev1.PodSpec
+		fits   bool
+		failed []string // rules that fail hard
+	}{
+		{"untolerated taint", corev1.PodSpec{}, false, []string{"Taint dedicated=gpu:NoSchedule"}},
+		{"tolerated", corev1.PodSpec{Tolerations: tolerateGPU}, true, nil},
+		{"node selector", corev1.PodSpec{Tolerations: tolerateGPU, NodeSelector: map[string]string{"disktype": "ssd"}}, false, []string{"nodeSelector disktype=ssd"}},
+		{"affinity fails", corev1.PodSpec{Tolerations: tolerateGPU, Affinity: affinity(zone("a"))}, false, []string{"Required node affinity"}},
+		{"one affinity term passes", corev1.PodSpec{Tolerations: tolerateGPU, Affinity: affinity(zone("a"), zone("b", "c"))}, true, nil},
+		{"bound elsewhere", corev1.PodSpec{Tolerations: tolerateGPU, NodeName: "cpu-1"}, false, []string{"spec.nodeName"}},
+	}
+	for _, tt := range tests {
+		pod := &corev1.Pod{Spec: tt.spec}
+		checks, fits := explainNodeFit(pod, node)
+		if fits != tt.fits {
+			t.Errorf("%s: fits = %v, want %v (%+v)", tt.name, fits, tt.fits, checks)
+		}
+		var failed []string
+		for _, c := range checks {
+			if !c.OK && !c.Soft {
+				failed = append(failed, c.Rule)
+			}
+			if c.Rule == "Taint spot:PreferNoSchedule" && (!c.Soft || c.OK) {
+				t.Errorf("%s: the PreferNoSchedule taint should be a soft failure: %+v", tt.name, c)
+			}
+		}
+		if len(failed) != len(tt.failed) || (len(failed) > 0 && failed[0] != tt.failed[0]) {
+			t.Errorf("%s: failed rules = %q, want %q", tt.name, failed, tt.failed)
+		}
+	}
+}
+
+func TestExplainNodeFitCordoned(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1"}, Spec: corev1.NodeSpec{Unschedulable: true}}
+	if _, fits := explainNodeFit(&corev1.Pod{}, node); fits {
+		t.Error("a pod fits a cordoned node")
+	}
+	// DaemonSet pods tolerate the unschedulable taint.
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Tolerations: []corev1.Toleration{{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists}}}}
+	if _, fits := explainNodeFit(pod, node); !fits {
+		t.Error("a pod tolerating the cordon does not fit")
+	}
+}
+
+func TestNodeFitSummaries(t *testing.T) {
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "tainted"}, Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "x", Effect: corev1.TaintEffectNoSchedule}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "free"}},
+	}
+	got := nodeFitSummaries(&corev1.Pod{}, nodes)
+	if len(got) != 2 || got[0].Node != "free" || !got[0].Fits || got[1].Reason != "Taint x:NoSchedule" {
+		t.Errorf("nodeFitSummaries() = %+v", got)
+	}
+}
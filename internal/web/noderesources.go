@@ -0,0 +1,124 @@
+package web
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	resourcehelper "k8s.io/component-helpers/resource"
+)
+
+// nodeResourceOrder is the display order of the built-in node resources;
+// hugepages and extended resources follow, by name.
+var nodeResourceOrder = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage, corev1.ResourcePods}
+
+// isExtendedResource reports whether a resource is advertised by a device
+// plugin or an administrator, such as nvidia.com/gpu, rather than built into
+// Kubernetes. Extended resources have a domain outside kubernetes.io.
+func isExtendedResource(name corev1.ResourceName) bool {
+	return strings.Contains(string(name), "/") && !strings.Contains(string(name), "kubernetes.io/")
+}
+
+// NodeResourceView is one resource of a node: what it has, what pods may
+// be given, and how much the pods on it request.
+type NodeResourceView struct {
+	Name        string
+	Extended    bool
+	Capacity    string
+	Allocatable string
+	Requested   string
+	Bar         PercentBar
+	Note        string
+}
+
+// ExtendedResourcePod is a pod on a node that requests extended resources.
+type ExtendedResourcePod struct {
+	Namespace string
+	Name      string
+	Phase     string
+	Requests  []string // such as "nvidia.com/gpu: 2"
+}
+
+func formatResource(name corev1.ResourceName, q resource.Quantity) string {
+	if name == corev1.ResourceMemory || name == corev1.ResourceEphemeralStorage || strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix) {
+		return formatBytes(q)
+	}
+	return q.String()
+}
+
+// nodeResources lists a node's capacity and allocatable resources with the
+// sum of the requests of the pods on it that still hold them, and the pods
+// requesting extended resources. A pod's request is what the scheduler
+// counts: its containers, its largest init container and its overhead.
+func nodeResources(node *corev1.Node, pods []corev1.Pod) ([]NodeResourceView, []ExtendedResourcePod) {
+	requested := corev1.ResourceList{}
+	var active int64
+	var users []ExtendedResourcePod
+	for i := range pods {
+		p := &pods[i]
+		if p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		active++
+		reqs := resourcehelper.PodRequests(p, resourcehelper.PodResourcesOptions{})
+		var extended []string
+		for name, q := range reqs {
+			sum := requested[name]
+			sum.Add(q)
+			requested[name] = sum
+			if isExtendedResource(name) && !q.IsZero() {
+				extended = append(extended, fmt.Sprintf("%s: %s", name, q.String()))
+			}
+		}
+		if len(extended) > 0 {
+			sort.Strings(extended)
+			users = append(users, ExtendedResourcePod{Namespace: p.Namespace, Name: p.Name, Phase: string(p.Status.Phase), Requests: extended})
+		}
+	}
+	requested[corev1.ResourcePods] = *resource.NewQuantity(active, resource.DecimalSI)
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Namespace != users[j].Namespace {
+			return users[i].Namespace < users[j].Namespace
+		}
+		return users[i].Name < users[j].Name
+	})
+
+	names := map[corev1.ResourceName]bool{}
+	for name := range node.Status.Capacity {
+		names[name] = true
+	}
+	for name := range node.Status.Allocatable {
+		names[name] = true
+	}
+	var others []corev1.ResourceName
+	for name := range names {
+		if !slices.Contains(nodeResourceOrder, name) {
+			others = append(others, name)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i] < others[j] })
+
+	var views []NodeResourceView
+	for _, name := range append(append([]corev1.ResourceName{}, nodeResourceOrder...), others...) {
+		if !names[name] {
+			continue
+		}
+		capacity, allocatable, req := node.Status.Capacity[name], node.Status.Allocatable[name], requested[name]
+		view := NodeResourceView{
+			Name:        string(name),
+			Extended:    isExtendedResource(name),
+			Capacity:    formatResource(name, capacity),
+			Allocatable: formatResource(name, allocatable),
+			Requested:   formatResource(name, req),
+			Bar:         percentBar(req, allocatable),
+		}
+		if view.Extended && !capacity.IsZero() && allocatable.IsZero() {
+			view.Note = "None allocatable: the device plugin reports no healthy devices, or they are reserved."
+		}
+		views = append(views, view)
+	}
+	return views, users
+}
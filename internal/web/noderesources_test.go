@@ -0,0 +1,81 @@
+package web
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsExtendedResource(t *testing.T) {
+	tests := map[corev1.ResourceName]bool{
+		"nvidia.com/gpu":                  true,
+		"example.com/dongle":              true,
+		"cpu":                             false,
+		"hugepages-2Mi":                   false,
+		"attachable-volumes-aws-ebs":      false,
+		"kubernetes.io/batch-cpu":         false,
+		"scheduling.k8s.io/foo":           true,
+		"requests.kubernetes.io/whatever": false,
+	}
+	for name, want := range tests {
+		if got := isExtendedResource(name); got != want {
+			t.Errorf("isExtendedResource(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestNodeResources(t *testing.T) {
+	list := func(pairs ...string) corev1.ResourceList {
+		l := corev1.ResourceList{}
+		for i := 0; i < len(pairs); i += 2 {
+			l[corev1.ResourceName(pairs[i])] = resource.MustParse(pairs[i+1])
+		}
+		return l
+	}
+	node := &corev1.Node{Status: corev1.NodeStatus{
+		Capacity:    list("cpu", "8", "memory", "32Gi", "pods", "110", "nvidia.com/gpu", "4", "example.com/fpga", "1"),
+		Allocatable: list("cpu", "7800m", "memory", "30Gi", "pods", "110", "nvidia.com/gpu", "4", "example.com/fpga", "0"),
+	}}
+	pod := func(name string, phase corev1.PodPhase, requests corev1.ResourceList) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ml", Name: name},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: requests}}}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	pods := []corev1.Pod{
+		pod("train-b", corev1.PodRunning, list("cpu", "2", "nvidia.com/gpu", "2")),
+		pod("train-a", corev1.PodRunning, list("cpu", "1", "memory", "4Gi", "nvidia.com/gpu", "1")),
+		pod("done", corev1.PodSucceeded, list("cpu", "4", "nvidia.com/gpu", "1")),
+		pod("web", corev1.PodPending, list("cpu", "500m")),
+	}
+
+	views, users := nodeResources(node, pods)
+	var names []string
+	byName := map[string]NodeResourceView{}
+	for _, v := range views {
+		names = append(names, v.Name)
+		byName[v.Name] = v
+	}
+	if want := []string{"cpu", "memory", "pods", "example.com/fpga", "nvidia.com/gpu"}; !slices.Equal(names, want) {
+		t.Errorf("resources = %v, want %v", names, want)
+	}
+	if gpu := byName["nvidia.com/gpu"]; gpu.Requested != "3" || gpu.Bar.Percent != 75 || !gpu.Extended {
+		t.Errorf("nvidia.com/gpu = %+v, want 3 of 4 requested", gpu)
+	}
+	if cpu := byName["cpu"]; cpu.Requested != "3500m" || cpu.Extended {
+		t.Errorf("cpu = %+v, want 3500m requested by the pods still holding it", cpu)
+	}
+	if p := byName["pods"]; p.Requested != "3" {
+		t.Errorf("pods = %+v, want 3 pods", p)
+	}
+	if fpga := byName["example.com/fpga"]; fpga.Note == "" {
+		t.Errorf("example.com/fpga with nothing allocatable has no note")
+	}
+	if len(users) != 2 || users[0].Name != "train-a" || users[0].Requests[0] != "nvidia.com/gpu: 1" || users[1].Name != "train-b" {
+		t.Errorf("extended resource pods = %+v", users)
+	}
+}
@@ -0,0 +1,159 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// accessCheckTTL is how long the result of an access check is reused, so a
+// changed Role takes effect without a restart.
+const accessCheckTTL = 5 * time.Minute
+
+// navPages are the navigation links backed by one resource kind, keyed by
+// the page's Active name. A link is hidden when the kind cannot be listed
+// in the namespace, and a section when all of its links are.
+var navPages = []struct {
+	Page     string
+	Section  string
+	Label    string
+	Group    string
+	Resource string
+}{
+	{"pods", "workloads", "Pods", "", "pods"},
+	{"deployments", "workloads", "Deployments", "apps", "deployments"},
+	{"statefulsets", "workloads", "StatefulSets", "apps", "statefulsets"},
+	{"jobs", "workloads", "Jobs", "batch", "jobs"},
+	{"cronjobs", "workloads", "CronJobs", "batch", "cronjobs"},
+	{"replicationcontrollers", "workloads", "ReplicationControllers", "", "replicationcontrollers"},
+	{"configmaps", "config", "ConfigMaps", "", "configmaps"},
+	{"secrets", "config", "Secrets", "", "secrets"},
+	{"services", "networking", "Services", "", "services"},
+	{"ingresses", "networking", "Ingresses", "networking.k8s.io", "ingresses"},
+	{"pvcs", "storage", "Storage", "", "persistentvolumeclaims"},
+	{"events", "events", "Events", "", "events"},
+}
+
+// namespacesCheck is the key of the cluster-wide check for listing
+// namespaces in an accessChecks entry.
+const namespacesCheck = "namespaces"
+
+type accessEntry struct {
+	checked time.Time
+	denied  map[string]bool
+}
+
+// accessChecks caches, per context and namespace, which lists the
+// credentials are denied, as found by SelfSubjectAccessReviews.
+type accessChecks struct {
+	mu      sync.Mutex
+	entries map[string]accessEntry
+}
+
+// denied returns the keys of checks whose list request the API server
+// denies, running the checks when there is no fresh result for key. A
+// check that fails, or gets no answer in time, counts as allowed: pages
+// then show the API server's error as before.
+func (ac *accessChecks) denied(key string, checks map[string]authorizationv1.ResourceAttributes, client kubernetes.Interface) map[string]bool {
+	ac.mu.Lock()
+	e, ok := ac.entries[key]
+	ac.mu.Unlock()
+	if ok && time.Since(e.checked) < accessCheckTTL {
+		return e.denied
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	e = accessEntry{checked: time.Now(), denied: make(map[string]bool)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, attrs := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attrs},
+			}, metav1.CreateOptions{})
+			if err != nil || review.Status.Allowed || review.Status.EvaluationError != "" {
+				return
+			}
+			mu.Lock()
+			e.denied[name] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	ac.mu.Lock()
+	if ac.entries == nil {
+		ac.entries = make(map[string]accessEntry)
+	}
+	ac.entries[key] = e
+	ac.mu.Unlock()
+	return e.denied
+}
+
+// navAccess checks which navigation links the credentials cannot list in
+// namespace, and returns what to hide with a note naming it.
+func (s *Server) navAccess(client kubernetes.Interface, kubeContext, namespace string) (map[string]bool, string) {
+	checks := make(map[string]authorizationv1.ResourceAttributes)
+	for _, p := range navPages {
+		if p.Page == "replicationcontrollers" && !s.config.ReplicationControllers {
+			continue
+		}
+		checks[p.Page] = authorizationv1.ResourceAttributes{Namespace: namespace, Verb: "list", Group: p.Group, Resource: p.Resource}
+	}
+	hidden, labels := hiddenNav(s.access.denied(kubeContext+"/"+namespace, checks, client), s.config.ReplicationControllers)
+	if len(labels) == 0 {
+		return nil, ""
+	}
+	verb := "are"
+	if len(labels) == 1 {
+		verb = "is"
+	}
+	return hidden, fmt.Sprintf("%s %s hidden from the menu because the Kubernetes credentials of k8s-ui are not allowed to list them in namespace %s.",
+		strings.Join(labels, ", "), verb, namespace)
+}
+
+// hiddenNav turns the denied pages into the pages and sections to leave
+// out of the navigation, with the labels of the pages. A section is left
+// out when none of its pages are shown.
+func hiddenNav(denied map[string]bool, replicationControllers bool) (map[string]bool, []string) {
+	if len(denied) == 0 {
+		return nil, nil
+	}
+	hidden := make(map[string]bool)
+	shown := make(map[string]bool)
+	var labels []string
+	for _, p := range navPages {
+		switch {
+		case p.Page == "replicationcontrollers" && !replicationControllers:
+		case denied[p.Page]:
+			hidden[p.Page] = true
+			labels = append(labels, p.Label)
+		default:
+			shown[p.Section] = true
+		}
+	}
+	for _, p := range navPages {
+		if !shown[p.Section] {
+			hidden[p.Section] = true
+		}
+	}
+	return hidden, labels
+}
+
+// canListNamespaces reports whether the credentials may list namespaces,
+// checked once per context rather than by a failing list on every page.
+func (s *Server) canListNamespaces(client kubernetes.Interface, kubeContext string) bool {
+	denied := s.access.denied(kubeContext+"/", map[string]authorizationv1.ResourceAttributes{
+		namespacesCheck: {Verb: "list", Resource: "namespaces"},
+	}, client)
+	return !denied[namespacesCheck]
+}
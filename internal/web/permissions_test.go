@@ -0,0 +1,60 @@
+package web
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHiddenNav(t *testing.T) {
+	tests := []struct {
+		name       string
+		denied     map[string]bool
+		rc         bool
+		wantHidden map[string]bool
+		wantLabels []string
+	}{
+		{name: "nothing denied"},
+		{
+			name:       "one page of a section",
+			denied:     map[string]bool{"secrets": true},
+			wantHidden: map[string]bool{"secrets": true},
+			wantLabels: []string{"Secrets"},
+		},
+		{
+			name:       "whole section",
+			denied:     map[string]bool{"configmaps": true, "secrets": true},
+			wantHidden: map[string]bool{"configmaps": true, "secrets": true, "config": true},
+			wantLabels: []string{"ConfigMaps", "Secrets"},
+		},
+		{
+			name:       "single page section",
+			denied:     map[string]bool{"events": true},
+			wantHidden: map[string]bool{"events": true},
+			wantLabels: []string{"Events"},
+		},
+		{
+			name:       "disabled page does not keep its section shown",
+			denied:     map[string]bool{"pods": true, "deployments": true, "statefulsets": true, "jobs": true, "cronjobs": true},
+			wantHidden: map[string]bool{"pods": true, "deployments": true, "statefulsets": true, "jobs": true, "cronjobs": true, "workloads": true},
+			wantLabels: []string{"Pods", "Deployments", "StatefulSets", "Jobs", "CronJobs"},
+		},
+		{
+			name:       "enabled page keeps its section shown",
+			denied:     map[string]bool{"pods": true, "deployments": true, "statefulsets": true, "jobs": true, "cronjobs": true},
+			rc:         true,
+			wantHidden: map[string]bool{"pods": true, "deployments": true, "statefulsets": true, "jobs": true, "cronjobs": true},
+			wantLabels: []string{"Pods", "Deployments", "StatefulSets", "Jobs", "CronJobs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hidden, labels := hiddenNav(tt.denied, tt.rc)
+			if !reflect.DeepEqual(hidden, tt.wantHidden) {
+				t.Errorf("hidden = %v, want %v", hidden, tt.wantHidden)
+			}
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", labels, tt.wantLabels)
+			}
+		})
+	}
+}
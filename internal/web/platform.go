@@ -0,0 +1,210 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	"k8s.io/utils/ptr"
+)
+
+// platformLabels are the node labels the kubelet sets from the node's
+// operating system and CPU architecture, with what they are called in
+// messages.
+var platformLabels = []struct {
+	Key  string
+	Name string
+}{
+	{corev1.LabelOSStable, "Operating system"},
+	{corev1.LabelArchStable, "Architecture"},
+}
+
+// nodePlatform returns a node's operating system and architecture, such as
+// "linux/arm64", from its labels or, for nodes without them, its node info.
+func nodePlatform(node *corev1.Node) string {
+	os, arch := node.Labels[corev1.LabelOSStable], node.Labels[corev1.LabelArchStable]
+	if os == "" {
+		os = node.Status.NodeInfo.OperatingSystem
+	}
+	if arch == "" {
+		arch = node.Status.NodeInfo.Architecture
+	}
+	if os == "" && arch == "" {
+		return ""
+	}
+	return os + "/" + arch
+}
+
+// platformRequirement narrows the pod's nodeSelector and required node
+// affinity down to the rules on one label key, and describes them. It
+// returns nil when the pod does not restrict the key: neither the
+// nodeSelector nor every affinity term mentions it.
+func platformRequirement(pod *corev1.Pod, key string) (*nodeaffinity.RequiredNodeAffinity, []string) {
+	narrowed := &corev1.Pod{}
+	var rules []string
+	if v, ok := pod.Spec.NodeSelector[key]; ok {
+		narrowed.Spec.NodeSelector = map[string]string{key: v}
+		rules = append(rules, "nodeSelector "+key+"="+v)
+	}
+	if a := pod.Spec.Affinity; a != nil && a.NodeAffinity != nil && a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		var terms []corev1.NodeSelectorTerm
+		for _, term := range a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			var reqs []corev1.NodeSelectorRequirement
+			for _, req := range term.MatchExpressions {
+				if req.Key == key {
+					reqs = append(reqs, req)
+					rules = append(rules, "node affinity "+requirementString(req))
+				}
+			}
+			if len(reqs) == 0 {
+				// A term without rules on key lets any value through.
+				terms = nil
+				break
+			}
+			terms = append(terms, corev1.NodeSelectorTerm{MatchExpressions: reqs})
+		}
+		if len(terms) > 0 {
+			narrowed.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+			}}
+		}
+	}
+	if narrowed.Spec.NodeSelector == nil && narrowed.Spec.Affinity == nil {
+		return nil, nil
+	}
+	req := nodeaffinity.GetRequiredNodeAffinity(narrowed)
+	return &req, rules
+}
+
+// platformConflicts finds the operating system and architecture rules of a
+// pod that no node satisfies, such as a nodeSelector for arm64 in a cluster
+// of amd64 nodes, and lists the platforms the nodes do have.
+func platformConflicts(pod *corev1.Pod, nodes []corev1.Node) []SchedulingConflict {
+	if len(nodes) == 0 {
+		return nil
+	}
+	var conflicts []SchedulingConflict
+	for _, pl := range platformLabels {
+		req, rules := platformRequirement(pod, pl.Key)
+		if req == nil {
+			continue
+		}
+		counts := map[string]int{}
+		matched := false
+		for i := range nodes {
+			if ok, err := req.Match(&nodes[i]); err == nil && ok {
+				matched = true
+				break
+			}
+			v := nodes[i].Labels[pl.Key]
+			if v == "" {
+				v = "unlabelled"
+			}
+			counts[v]++
+		}
+		if matched {
+			continue
+		}
+		have := make([]string, 0, len(counts))
+		for v, n := range counts {
+			have = append(have, fmt.Sprintf("%s (%d)", v, n))
+		}
+		sort.Strings(have)
+		conflicts = append(conflicts, SchedulingConflict{
+			Constraint: pl.Name,
+			Message: fmt.Sprintf("The pod requires %s, but no node matches: the nodes' %s labels are %s.",
+				strings.Join(rules, " and "), pl.Key, strings.Join(have, ", ")),
+		})
+	}
+	return conflicts
+}
+
+// execFormatError is how the container runtime reports an executable built
+// for another architecture.
+const execFormatError = "exec format error"
+
+// PlatformMismatchView is a container that could not start because its
+// image has no build for the platform of the node it was scheduled on.
+type PlatformMismatchView struct {
+	Container string
+	Image     string
+	Node      string
+	Platform  string // the node's platform; empty when the node is unreadable
+	Message   string
+}
+
+// containerExecFormatError returns the message in which the runtime reported
+// an exec format error for a container, from its current or last state.
+func containerExecFormatError(cs corev1.ContainerStatus) (string, bool) {
+	var messages []string
+	if w := cs.State.Waiting; w != nil {
+		messages = append(messages, w.Message)
+	}
+	if t := cs.State.Terminated; t != nil {
+		messages = append(messages, t.Message)
+	}
+	if t := cs.LastTerminationState.Terminated; t != nil {
+		messages = append(messages, t.Message)
+	}
+	for _, m := range messages {
+		if strings.Contains(m, execFormatError) {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// podPlatformMismatches finds the containers of a pod that failed with an
+// exec format error. The runtime reports it in the container's state when it
+// cannot start the entrypoint; when the image's shell or entrypoint prints
+// it instead, it is in the last lines of the crashed container's log, which
+// are only read for containers in CrashLoopBackOff.
+func (s *Server) podPlatformMismatches(ctx context.Context, pod *corev1.Pod) []PlatformMismatchView {
+	if pod.Spec.NodeName == "" {
+		return nil
+	}
+	images := make(map[string]string)
+	for _, c := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		images[c.Name] = c.Image
+	}
+
+	client := s.manager.Client()
+	var views []PlatformMismatchView
+	for _, cs := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		msg, ok := containerExecFormatError(cs)
+		if !ok && cs.State.Waiting != nil && cs.State.Waiting.Reason == "CrashLoopBackOff" {
+			req := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+				Container:  cs.Name,
+				Previous:   true,
+				TailLines:  ptr.To[int64](5),
+				LimitBytes: ptr.To[int64](4096),
+			})
+			if stream, err := req.Stream(ctx); err == nil {
+				b, _ := io.ReadAll(stream)
+				stream.Close()
+				for _, line := range strings.Split(string(b), "\n") {
+					if strings.Contains(line, execFormatError) {
+						msg, ok = strings.TrimSpace(line), true
+						break
+					}
+				}
+			}
+		}
+		if ok {
+			views = append(views, PlatformMismatchView{Container: cs.Name, Image: images[cs.Name], Node: pod.Spec.NodeName, Message: msg})
+		}
+	}
+	if len(views) > 0 {
+		if node, err := client.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{}); err == nil {
+			for i := range views {
+				views[i].Platform = nodePlatform(node)
+			}
+		}
+	}
+	return views
+}
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func platformNode(name, os, arch string) corev1.Node {
+	return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+		corev1.LabelOSStable:   os,
+		corev1.LabelArchStable: arch,
+	}}}
+}
+
+func TestNodePlatform(t *testing.T) {
+	n := platformNode("a", "linux", "arm64")
+	if got := nodePlatform(&n); got != "linux/arm64" {
+		t.Errorf("nodePlatform() = %q", got)
+	}
+	unlabelled := corev1.Node{Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{OperatingSystem: "windows", Architecture: "amd64"}}}
+	if got := nodePlatform(&unlabelled); got != "windows/amd64" {
+		t.Errorf("nodePlatform() without labels = %q", got)
+	}
+}
+
+func TestPlatformConflicts(t *testing.T) {
+	nodes := []corev1.Node{
+		platformNode("a", "linux", "amd64"),
+		platformNode("b", "linux", "amd64"),
+		platformNode("c", "windows", "amd64"),
+	}
+	archIn := func(values ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: values},
+		}}
+	}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+	zoneOnly := corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+		{Key: "topology.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}},
+	}}
+
+	tests := []struct {
+		name string
+		spec corev1.PodSpec
+		want []string
+	}{
+		{"no rules", corev1.PodSpec{}, nil},
+		{"arm64 selector", corev1.PodSpec{NodeSelector: map[string]string{corev1.LabelArchStable: "arm64"}}, []string{
+			"The pod requires nodeSelector kubernetes.io/arch=arm64, but no node matches: the nodes' kubernetes.io/arch labels are amd64 (3).",
+		}},
+		{"multi-arch affinity", corev1.PodSpec{Affinity: affinity(archIn("arm64", "amd64"))}, nil},
+		{"arm64 affinity", corev1.PodSpec{Affinity: affinity(archIn("arm64"))}, []string{
+			"The pod requires node affinity kubernetes.io/arch In [arm64], but no node matches: the nodes' kubernetes.io/arch labels are amd64 (3).",
+		}},
+		{"another term allows any arch", corev1.PodSpec{Affinity: affinity(archIn("arm64"), zoneOnly)}, nil},
+		{"darwin", corev1.PodSpec{NodeSelector: map[string]string{corev1.LabelOSStable: "darwin"}}, []string{
+			"The pod requires nodeSelector kubernetes.io/os=darwin, but no node matches: the nodes' kubernetes.io/os labels are linux (2), windows (1).",
+		}},
+	}
+	for _, tt := range tests {
+		got := platformConflicts(&corev1.Pod{Spec: tt.spec}, nodes)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: platformConflicts() = %+v, want %q", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].Message != tt.want[i] {
+				t.Errorf("%s: message %q, want %q", tt.name, got[i].Message, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestContainerExecFormatError(t *testing.T) {
+	failed := corev1.ContainerStatus{LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+		Reason:  "StartError",
+		Message: `failed to create containerd task: exec: "/app": exec format error`,
+	}}}
+	if _, ok := containerExecFormatError(failed); !ok {
+		t.Error("exec format error in the last state not found")
+	}
+	oom := corev1.ContainerStatus{LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"}}}
+	if _, ok := containerExecFormatError(oom); ok {
+		t.Error("OOMKilled reported as an exec format error")
+	}
+}
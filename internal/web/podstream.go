@@ -0,0 +1,208 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/utils/ptr"
+)
+
+// podStreamHeartbeat is how often an idle pod stream sends a comment, so
+// proxies do not close it. podStreamWatchTimeout is how long one watch
+// runs before the pods are listed again, which also catches up after
+// missed events.
+const (
+	podStreamHeartbeat    = 15 * time.Second
+	podStreamWatchTimeout = 5 * time.Minute
+)
+
+// PodStreamStatus is what the pod stream sends for each pod.
+type PodStreamStatus struct {
+	APIPodStatus
+	Terminating bool `json:"terminating,omitempty"`
+}
+
+func podStreamStatus(p *corev1.Pod) PodStreamStatus {
+	return PodStreamStatus{APIPodStatus: apiPodStatus(p), Terminating: p.DeletionTimestamp != nil}
+}
+
+// podStreamChanged reports whether a pod's status differs from what was
+// last sent in the fields the stream is about.
+func podStreamChanged(prev, next PodStreamStatus) bool {
+	return prev.Phase != next.Phase || prev.Ready != next.Ready || prev.Restarts != next.Restarts ||
+		prev.Node != next.Node || prev.Terminating != next.Terminating
+}
+
+// writeSSE writes one server-sent event with a JSON payload.
+func writeSSE(w io.Writer, event string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+// handlePodStream streams the status of the pods matching ?selector= as
+// server-sent events, so a page can show pod health live during a rollout
+// without reloading. It first sends a "pod" event for every matching pod
+// and a "synced" event, then a "pod" event whenever a pod's phase,
+// readiness, restarts, node or termination changes and a "delete" event
+// when a pod is gone. An "error" event ends the stream.
+func (s *Server) handlePodStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	selector, err := labels.Parse(r.URL.Query().Get("selector"))
+	if err != nil {
+		http.Error(w, "Invalid selector: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if selector.Empty() {
+		http.Error(w, "A selector is required", http.StatusBadRequest)
+		return
+	}
+	namespace := s.namespace(r)
+	pods := s.manager.Client().CoreV1().Pods(namespace)
+
+	// Check access before the stream starts, so a denied request gets a
+	// proper status instead of an error event.
+	list, err := pods.List(r.Context(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "list", "pods", "", "/pods", "pods") {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	r, end := s.trackSession(r, "pod status stream", "pods with "+selector.String())
+	defer end()
+	st, err := newResponseStream(w, r, "text/event-stream")
+	if err != nil {
+		http.Error(w, "Streaming is not supported: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sent := make(map[string]PodStreamStatus)
+	// sync sends the pods of a fresh list that changed since they were
+	// last sent, and deletes for those no longer listed.
+	sync := func(items []corev1.Pod) error {
+		seen := make(map[string]bool, len(items))
+		for i := range items {
+			seen[items[i].Name] = true
+			next := podStreamStatus(&items[i])
+			if prev, ok := sent[next.Name]; ok && !podStreamChanged(prev, next) {
+				continue
+			}
+			sent[next.Name] = next
+			if err := writeSSE(st, "pod", next); err != nil {
+				return err
+			}
+		}
+		for name := range sent {
+			if !seen[name] {
+				delete(sent, name)
+				if err := writeSSE(st, "delete", map[string]string{"name": name}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := sync(list.Items); err != nil {
+		return
+	}
+	if err := writeSSE(st, "synced", map[string]int{"pods": len(sent)}); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(podStreamHeartbeat)
+	defer heartbeat.Stop()
+	resourceVersion := list.ResourceVersion
+	for {
+		watcher, err := pods.Watch(r.Context(), metav1.ListOptions{
+			LabelSelector:   selector.String(),
+			ResourceVersion: resourceVersion,
+			TimeoutSeconds:  ptr.To(int64(podStreamWatchTimeout.Seconds())),
+		})
+		if err != nil {
+			if r.Context().Err() == nil {
+				_ = writeSSE(st, "error", map[string]string{"message": err.Error()})
+			}
+			return
+		}
+
+		done := func() bool {
+			defer watcher.Stop()
+			for {
+				select {
+				case <-st.Done():
+					return true
+				case <-heartbeat.C:
+					if _, err := io.WriteString(st, ": heartbeat\n\n"); err != nil {
+						return true
+					}
+				case ev, ok := <-watcher.ResultChan():
+					if !ok {
+						return false
+					}
+					p, isPod := ev.Object.(*corev1.Pod)
+					switch {
+					case ev.Type == watch.Error:
+						// Usually an expired resource version; list again.
+						return false
+					case !isPod:
+					case ev.Type == watch.Deleted || !selector.Matches(labels.Set(p.Labels)):
+						if _, ok := sent[p.Name]; ok {
+							delete(sent, p.Name)
+							if err := writeSSE(st, "delete", map[string]string{"name": p.Name}); err != nil {
+								return true
+							}
+						}
+					default:
+						next := podStreamStatus(p)
+						if prev, ok := sent[p.Name]; ok && !podStreamChanged(prev, next) {
+							continue
+						}
+						sent[p.Name] = next
+						if err := writeSSE(st, "pod", next); err != nil {
+							return true
+						}
+					}
+				}
+			}
+		}()
+		if done {
+			return
+		}
+
+		// Pause briefly so a watch that keeps ending at once does not turn
+		// into a tight list loop.
+		select {
+		case <-st.Done():
+			return
+		case <-time.After(time.Second):
+		}
+		list, err := pods.List(r.Context(), metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			if r.Context().Err() == nil {
+				_ = writeSSE(st, "error", map[string]string{"message": err.Error()})
+			}
+			return
+		}
+		if err := sync(list.Items); err != nil {
+			return
+		}
+		resourceVersion = list.ResourceVersion
+	}
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodStreamChanged(t *testing.T) {
+	base := PodStreamStatus{APIPodStatus: APIPodStatus{Name: "web-1", Phase: "Running", Ready: "1/1", Restarts: 0, Node: "n1"}}
+	tests := []struct {
+		name   string
+		modify func(*PodStreamStatus)
+		want   bool
+	}{
+		{"unchanged", func(*PodStreamStatus) {}, false},
+		{"age is not a change", func(p *PodStreamStatus) { p.Created = time.Now() }, false},
+		{"phase", func(p *PodStreamStatus) { p.Phase = "Failed" }, true},
+		{"readiness", func(p *PodStreamStatus) { p.Ready = "0/1" }, true},
+		{"restarts", func(p *PodStreamStatus) { p.Restarts = 1 }, true},
+		{"scheduled", func(p *PodStreamStatus) { p.Node = "n2" }, true},
+		{"terminating", func(p *PodStreamStatus) { p.Terminating = true }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := base
+			tt.modify(&next)
+			if got := podStreamChanged(base, next); got != tt.want {
+				t.Errorf("podStreamChanged = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSSE(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", DeletionTimestamp: &metav1.Time{}}}
+	pod.Status.Phase = corev1.PodRunning
+	var b strings.Builder
+	if err := writeSSE(&b, "pod", podStreamStatus(pod)); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	if !strings.HasPrefix(got, "event: pod\ndata: {") || !strings.HasSuffix(got, "}\n\n") {
+		t.Fatalf("event = %q", got)
+	}
+	for _, want := range []string{`"name":"web-1"`, `"phase":"Running"`, `"terminating":true`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("event %q lacks %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+)
+
+// rbacDenial matches the message of a Forbidden error returned by RBAC, as
+// opposed to one returned by an admission plugin or webhook, such as Pod
+// Security, a ResourceQuota or a policy engine.
+var rbacDenial = regexp.MustCompile(`cannot [a-z]+ resource "`)
+
+// isAdmissionRejection reports whether err is a Forbidden error raised by
+// admission rather than by the caller's permissions.
+func isAdmissionRejection(err error) bool {
+	return apierrors.IsForbidden(err) && !rbacDenial.MatchString(err.Error())
+}
+
+// podTemplateOf returns the pod template of a workload object and the
+// number of pods it asks for. DaemonSets report -1, as they run one pod per
+// eligible node. ok is false for objects that do not create pods.
+func podTemplateOf(obj *unstructured.Unstructured) (template corev1.PodTemplateSpec, replicas int32, ok bool, err error) {
+	gvk := obj.GroupVersionKind()
+	convert := func(into any) error {
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, into)
+	}
+	switch {
+	case gvk.Group == "" && gvk.Kind == "Pod":
+		var p corev1.Pod
+		if err := convert(&p); err != nil {
+			return template, 0, false, err
+		}
+		return corev1.PodTemplateSpec{ObjectMeta: p.ObjectMeta, Spec: p.Spec}, 1, true, nil
+	case gvk.Group == "apps" && gvk.Kind == "Deployment":
+		var d appsv1.Deployment
+		if err := convert(&d); err != nil {
+			return template, 0, false, err
+		}
+		return d.Spec.Template, ptr.Deref(d.Spec.Replicas, 1), true, nil
+	case gvk.Group == "apps" && gvk.Kind == "ReplicaSet":
+		var rs appsv1.ReplicaSet
+		if err := convert(&rs); err != nil {
+			return template, 0, false, err
+		}
+		return rs.Spec.Template, ptr.Deref(rs.Spec.Replicas, 1), true, nil
+	case gvk.Group == "apps" && gvk.Kind == "StatefulSet":
+		var ss appsv1.StatefulSet
+		if err := convert(&ss); err != nil {
+			return template, 0, false, err
+		}
+		return ss.Spec.Template, ptr.Deref(ss.Spec.Replicas, 1), true, nil
+	case gvk.Group == "apps" && gvk.Kind == "DaemonSet":
+		var ds appsv1.DaemonSet
+		if err := convert(&ds); err != nil {
+			return template, 0, false, err
+		}
+		return ds.Spec.Template, -1, true, nil
+	case gvk.Group == "batch" && gvk.Kind == "Job":
+		var j batchv1.Job
+		if err := convert(&j); err != nil {
+			return template, 0, false, err
+		}
+		return j.Spec.Template, ptr.Deref(j.Spec.Parallelism, 1), true, nil
+	case gvk.Group == "batch" && gvk.Kind == "CronJob":
+		var cj batchv1.CronJob
+		if err := convert(&cj); err != nil {
+			return template, 0, false, err
+		}
+		return cj.Spec.JobTemplate.Spec.Template, ptr.Deref(cj.Spec.JobTemplate.Spec.Parallelism, 1), true, nil
+	}
+	return template, 0, false, nil
+}
+
+// podRequests is what the scheduler reserves on a node for one pod built
+// from spec, including the pod slot itself.
+func podRequests(spec *corev1.PodSpec) corev1.ResourceList {
+	out := corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1")}
+	for name, q := range podUsage(spec) {
+		if res, ok := strings.CutPrefix(string(name), "requests."); ok {
+			out[corev1.ResourceName(res)] = q
+		}
+	}
+	return out
+}
+
+// nodeFree is the allocatable capacity of node not yet requested by the
+// pods running on it.
+func nodeFree(node *corev1.Node, pods []corev1.Pod) corev1.ResourceList {
+	free := node.Status.Allocatable.DeepCopy()
+	for i := range pods {
+		p := &pods[i]
+		if p.Spec.NodeName != node.Name || p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for name, q := range podRequests(&p.Spec) {
+			f := free[name]
+			f.Sub(q)
+			free[name] = f
+		}
+	}
+	return free
+}
+
+// formatResources lists resources in name order, such as "cpu 500m, memory 1Gi".
+func formatResources(list corev1.ResourceList) string {
+	names := make([]string, 0, len(list))
+	for name := range list {
+		if name != corev1.ResourcePods {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		q := list[corev1.ResourceName(name)]
+		names[i] = name + " " + q.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// resourceFitIssues checks that at least one of the nodes that accept the
+// pod has room for its requests, given the pods already running. It is a
+// first-fit check for one pod, not a simulation of every replica.
+func resourceFitIssues(spec *corev1.PodSpec, candidates []*corev1.Node, pods []corev1.Pod) []ScaleIssue {
+	if len(candidates) == 0 {
+		return nil
+	}
+	want := podRequests(spec)
+	var best *corev1.Node
+	var bestFree corev1.ResourceList
+	bestShort := -1
+	for _, n := range candidates {
+		free := nodeFree(n, pods)
+		short := 0
+		for name, q := range want {
+			if f, ok := free[name]; !ok || f.Cmp(q) < 0 {
+				short++
+			}
+		}
+		if short == 0 {
+			return nil
+		}
+		if bestShort < 0 || short < bestShort {
+			best, bestFree, bestShort = n, free, short
+		}
+	}
+
+	shown := corev1.ResourceList{}
+	for name := range want {
+		q := bestFree[name]
+		if q.Sign() < 0 {
+			q = resource.Quantity{}
+		}
+		shown[name] = q
+	}
+	return []ScaleIssue{{
+		Field: "nodes",
+		Message: fmt.Sprintf("None of the %d nodes that accept the pod has room for its requests (%s). Closest is %s with %s free.",
+			len(candidates), formatResources(want), best.Name, formatResources(shown)),
+	}}
+}
+
+// WorkloadPrecheck is what a pre-check found for a workload that is about
+// to be created.
+type WorkloadPrecheck struct {
+	Issues   []ScaleIssue
+	Skipped  []string // checks that could not run, and why
+	Nodes    int
+	FitNodes int // nodes whose taints and the pod's node affinity allow it
+	Replicas int32
+}
+
+// precheckWorkload checks whether the pods of a new workload, as returned
+// by a dry run, can be scheduled: whether any node accepts them and has
+// room, whether inter-pod rules can be satisfied, and whether the
+// namespace quotas have room for all replicas. The checks are a basic
+// simulation; the scheduler may still decide differently.
+func (s *Server) precheckWorkload(ctx context.Context, namespace string, obj *unstructured.Unstructured) (WorkloadPrecheck, error) {
+	var check WorkloadPrecheck
+	template, replicas, ok, err := podTemplateOf(obj)
+	if err != nil || !ok {
+		return check, err
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: obj.GetName(), Namespace: namespace, Labels: template.Labels},
+		Spec:       template.Spec,
+	}
+	client := s.manager.Client()
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	switch {
+	case err == nil:
+		check.Nodes = len(nodes.Items)
+		var candidates []*corev1.Node
+		for i := range nodes.Items {
+			if nodeAcceptsPod(pod, &nodes.Items[i], true, true) {
+				candidates = append(candidates, &nodes.Items[i])
+			}
+		}
+		check.FitNodes = len(candidates)
+		if replicas < 0 {
+			replicas = int32(len(candidates))
+		}
+
+		nsPods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			check.Skipped = append(check.Skipped, "Pod anti-affinity and topology spread: "+err.Error())
+		} else {
+			for _, c := range schedulingConflicts(pod, nodes.Items, nsPods.Items) {
+				check.Issues = append(check.Issues, ScaleIssue{Field: "nodes", Message: c.Constraint + ": " + c.Message})
+			}
+		}
+
+		allPods, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: "status.phase!=Succeeded,status.phase!=Failed"})
+		if err != nil {
+			check.Skipped = append(check.Skipped, "Free node resources, which needs pods in all namespaces: "+err.Error())
+		} else {
+			check.Issues = append(check.Issues, resourceFitIssues(&pod.Spec, candidates, allPods.Items)...)
+		}
+	case apierrors.IsForbidden(err):
+		check.Skipped = append(check.Skipped, "Node fit, as the current identity cannot list nodes.")
+	default:
+		return check, err
+	}
+
+	if replicas < 0 {
+		replicas = 1
+	}
+	check.Replicas = replicas
+	if s.config.QuotaCheck == QuotaCheckOff {
+		check.Skipped = append(check.Skipped, "Quota, as QUOTA_CHECK is off.")
+	} else if replicas > 0 {
+		issues, err := s.checkQuota(ctx, namespace, scaleUsage(podUsage(&pod.Spec), int64(replicas)))
+		if err != nil {
+			return check, err
+		}
+		check.Issues = append(check.Issues, issues...)
+	}
+	return check, nil
+}
@@ -0,0 +1,112 @@
+package web
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+func TestIsAdmissionRejection(t *testing.T) {
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rbac", apierrors.NewForbidden(deployments, "web", errors.New(`User "bob" cannot create resource "deployments" in API group "apps" in the namespace "shop"`)), false},
+		{"pod security", apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "web", errors.New(`violates PodSecurity "restricted:latest": privileged`)), true},
+		{"webhook", apierrors.NewForbidden(deployments, "web", errors.New(`admission webhook "validation.gatekeeper.sh" denied the request`)), true},
+		{"not forbidden", apierrors.NewBadRequest("bad"), false},
+	}
+	for _, tt := range tests {
+		if got := isAdmissionRejection(tt.err); got != tt.want {
+			t.Errorf("%s: isAdmissionRejection() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPodTemplateOf(t *testing.T) {
+	tests := []struct {
+		manifest string
+		replicas int32
+		ok       bool
+	}{
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata: {name: web}\nspec: {replicas: 3, template: {spec: {containers: [{name: app, image: nginx}]}}}", 3, true},
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata: {name: web}\nspec: {template: {spec: {containers: [{name: app, image: nginx}]}}}", 1, true},
+		{"apiVersion: apps/v1\nkind: DaemonSet\nmetadata: {name: agent}\nspec: {template: {spec: {containers: [{name: app, image: agent}]}}}", -1, true},
+		{"apiVersion: batch/v1\nkind: CronJob\nmetadata: {name: nightly}\nspec: {schedule: '@daily', jobTemplate: {spec: {parallelism: 2, template: {spec: {containers: [{name: app, image: job}]}}}}}", 2, true},
+		{"apiVersion: v1\nkind: Pod\nmetadata: {name: one}\nspec: {containers: [{name: app, image: nginx}]}", 1, true},
+		{"apiVersion: v1\nkind: ConfigMap\nmetadata: {name: settings}", 0, false},
+	}
+	for _, tt := range tests {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(tt.manifest), &obj.Object); err != nil {
+			t.Fatal(err)
+		}
+		template, replicas, ok, err := podTemplateOf(obj)
+		if err != nil || ok != tt.ok || replicas != tt.replicas {
+			t.Errorf("podTemplateOf(%s) = %d, %v, %v, want %d, %v", obj.GetKind(), replicas, ok, err, tt.replicas, tt.ok)
+			continue
+		}
+		if ok && len(template.Spec.Containers) != 1 {
+			t.Errorf("podTemplateOf(%s) returned %d containers, want 1", obj.GetKind(), len(template.Spec.Containers))
+		}
+	}
+}
+
+func fitNode(name, cpu, memory string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		}},
+	}
+}
+
+func requestsSpec(node, cpu, memory string) corev1.PodSpec {
+	return corev1.PodSpec{
+		NodeName: node,
+		Containers: []corev1.Container{{Name: "app", Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}}}},
+	}
+}
+
+func TestResourceFitIssues(t *testing.T) {
+	nodes := []*corev1.Node{fitNode("a", "4", "8Gi"), fitNode("b", "2", "16Gi")}
+	running := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "busy"}, Spec: requestsSpec("a", "3", "1Gi")},
+		{ObjectMeta: metav1.ObjectMeta{Name: "done"}, Spec: requestsSpec("b", "2", "1Gi"), Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+	}
+
+	tests := []struct {
+		name  string
+		spec  corev1.PodSpec
+		nodes []*corev1.Node
+		want  string
+	}{
+		{name: "fits on b", spec: requestsSpec("", "2", "4Gi"), nodes: nodes},
+		{name: "too much cpu everywhere", spec: requestsSpec("", "3", "1Gi"), nodes: nodes, want: "None of the 2 nodes"},
+		{name: "no candidate nodes", spec: requestsSpec("", "64", "1Ti")},
+	}
+	for _, tt := range tests {
+		issues := resourceFitIssues(&tt.spec, tt.nodes, running)
+		switch {
+		case tt.want == "" && len(issues) > 0:
+			t.Errorf("%s: got %v, want no issues", tt.name, issues)
+		case tt.want != "" && (len(issues) != 1 || !strings.Contains(issues[0].Message, tt.want)):
+			t.Errorf("%s: got %v, want a message containing %q", tt.name, issues, tt.want)
+		}
+	}
+}
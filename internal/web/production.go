@@ -0,0 +1,116 @@
+package web
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// productionConfirmField is the form field that must hold the namespace
+// name before a mutating request is allowed in a production scope.
+const productionConfirmField = "production_confirm"
+
+// ProductionField is an original form value carried through the
+// confirmation page.
+type ProductionField struct {
+	Name  string
+	Value string
+}
+
+type ProductionConfirmPage struct {
+	BasePage
+	Action  string
+	Method  string
+	Fields  []ProductionField
+	Context string
+	Retry   bool
+	BackURL string
+}
+
+// isProduction reports whether the current context or the given namespace
+// is marked as production by PRODUCTION_CONTEXTS or PRODUCTION_NAMESPACES.
+func (s *Server) isProduction(namespace string) bool {
+	if slices.Contains(s.config.ProductionNamespaces, namespace) {
+		return true
+	}
+	_, current := s.manager.Contexts()
+	return current != "" && slices.Contains(s.config.ProductionContexts, current)
+}
+
+// withProductionGuard requires mutating requests in a production scope to
+// carry the namespace name in production_confirm. Requests without it get
+// a confirmation page that re-submits the original form once the name is
+// typed.
+func (s *Server) withProductionGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Context and namespace switching and dry runs do not change
+		// cluster state.
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == "/tools/dry-run" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ns := s.namespace(r)
+		if !s.isProduction(ns) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		confirm := strings.TrimSpace(r.PostForm.Get(productionConfirmField))
+		if confirm == ns {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var fields []ProductionField
+		for name, values := range r.PostForm {
+			if name == productionConfirmField {
+				continue
+			}
+			for _, v := range values {
+				fields = append(fields, ProductionField{Name: name, Value: v})
+			}
+		}
+		// The confirmation re-posts the form from its own page, so keep
+		// the page the action was started from.
+		if _, ok := r.PostForm[returnToField]; !ok {
+			if back, ok := refererPath(r); ok {
+				fields = append(fields, ProductionField{Name: returnToField, Value: back})
+			}
+		}
+		s.renderProductionConfirm(w, r, ns, http.MethodPost, r.URL.RequestURI(), fields, confirm != "")
+	})
+}
+
+// renderProductionConfirm asks for the namespace name before the form
+// with fields is sent again to action with method.
+func (s *Server) renderProductionConfirm(w http.ResponseWriter, r *http.Request, ns, method, action string, fields []ProductionField, retry bool) {
+	slices.SortStableFunc(fields, func(a, b ProductionField) int { return strings.Compare(a.Name, b.Name) })
+	_, current := s.manager.Contexts()
+	backURL := r.Referer()
+	if backURL == "" {
+		backURL = "/"
+	}
+	w.WriteHeader(http.StatusPreconditionRequired)
+	s.renderTemplate(w, "production_confirm.html", ProductionConfirmPage{
+		BasePage: BasePage{Namespace: ns, Title: "Confirm production change"},
+		Action:   action,
+		Method:   method,
+		Fields:   fields,
+		Context:  current,
+		Retry:    retry,
+		BackURL:  backURL,
+	})
+}
+
+// execConfirmed reports whether an exec terminal may be opened: outside
+// production always, and in production once the namespace name has been
+// typed, as a shell can change anything in the pod. The exec page asks for
+// it and passes it on to the WebSocket.
+func (s *Server) execConfirmed(r *http.Request) bool {
+	ns := s.namespace(r)
+	return !s.isProduction(ns) || strings.TrimSpace(r.URL.Query().Get(productionConfirmField)) == ns
+}
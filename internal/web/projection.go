@@ -0,0 +1,224 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+)
+
+// ProjectedFileView is one file a downwardAPI or projected volume writes,
+// with the value the kubelet will put in it when it can be worked out from
+// the pod alone.
+type ProjectedFileView struct {
+	Path   string
+	Source string
+	Value  string
+	Note   string
+}
+
+// ProjectedVolumeView lists the files of a downwardAPI or projected volume.
+type ProjectedVolumeView struct {
+	Name  string
+	Kind  string
+	Files []ProjectedFileView
+}
+
+// projectedVolumes resolves the downwardAPI and projected volumes of a pod.
+// ConfigMap and Secret values are not read; only the keys are listed.
+func projectedVolumes(pod *corev1.Pod) []ProjectedVolumeView {
+	var views []ProjectedVolumeView
+	for _, v := range pod.Spec.Volumes {
+		switch {
+		case v.DownwardAPI != nil:
+			views = append(views, ProjectedVolumeView{
+				Name:  v.Name,
+				Kind:  "downwardAPI",
+				Files: downwardAPIFiles(pod, v.DownwardAPI.Items),
+			})
+		case v.Projected != nil:
+			var files []ProjectedFileView
+			for _, src := range v.Projected.Sources {
+				switch {
+				case src.DownwardAPI != nil:
+					files = append(files, downwardAPIFiles(pod, src.DownwardAPI.Items)...)
+				case src.ConfigMap != nil:
+					files = append(files, keyToPathFiles("ConfigMap "+src.ConfigMap.Name, src.ConfigMap.Items, src.ConfigMap.Optional)...)
+				case src.Secret != nil:
+					files = append(files, keyToPathFiles("Secret "+src.Secret.Name, src.Secret.Items, src.Secret.Optional)...)
+				case src.ServiceAccountToken != nil:
+					t := src.ServiceAccountToken
+					note := "Audience: API server"
+					if t.Audience != "" {
+						note = "Audience: " + t.Audience
+					}
+					if t.ExpirationSeconds != nil {
+						note += fmt.Sprintf("; expires after %s and is rotated by the kubelet", formatDuration(time.Duration(*t.ExpirationSeconds)*time.Second))
+					}
+					files = append(files, ProjectedFileView{
+						Path:   t.Path,
+						Source: "Token for service account " + serviceAccountName(pod),
+						Note:   note,
+					})
+				case src.ClusterTrustBundle != nil:
+					name := "signer " + ptr.Deref(src.ClusterTrustBundle.SignerName, "")
+					if src.ClusterTrustBundle.Name != nil {
+						name = *src.ClusterTrustBundle.Name
+					}
+					files = append(files, ProjectedFileView{Path: src.ClusterTrustBundle.Path, Source: "ClusterTrustBundle " + name})
+				}
+			}
+			views = append(views, ProjectedVolumeView{Name: v.Name, Kind: "projected", Files: files})
+		}
+	}
+	return views
+}
+
+func downwardAPIFiles(pod *corev1.Pod, items []corev1.DownwardAPIVolumeFile) []ProjectedFileView {
+	var files []ProjectedFileView
+	for _, item := range items {
+		f := ProjectedFileView{Path: item.Path}
+		switch {
+		case item.FieldRef != nil:
+			f.Source = item.FieldRef.FieldPath
+			f.Value, f.Note = downwardFieldValue(pod, item.FieldRef.FieldPath)
+		case item.ResourceFieldRef != nil:
+			ref := item.ResourceFieldRef
+			f.Source = ref.Resource
+			if ref.ContainerName != "" {
+				f.Source = ref.ContainerName + ": " + ref.Resource
+			}
+			f.Value, f.Note = downwardResourceValue(pod, ref)
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// downwardFieldValue renders a fieldRef the way the kubelet writes it to a
+// file. Labels and annotations are written one key="value" per line.
+func downwardFieldValue(pod *corev1.Pod, fieldPath string) (string, string) {
+	switch fieldPath {
+	case "metadata.name":
+		return pod.Name, ""
+	case "metadata.namespace":
+		return pod.Namespace, ""
+	case "metadata.uid":
+		return string(pod.UID), ""
+	case "metadata.labels":
+		return formatDownwardMap(pod.Labels), ""
+	case "metadata.annotations":
+		return formatDownwardMap(pod.Annotations), ""
+	}
+	for _, prefix := range []string{"metadata.labels", "metadata.annotations"} {
+		if key, ok := subscriptKey(fieldPath, prefix); ok {
+			values := pod.Labels
+			if prefix == "metadata.annotations" {
+				values = pod.Annotations
+			}
+			if v, ok := values[key]; ok {
+				return v, ""
+			}
+			return "", "Not set on the pod; the file is empty"
+		}
+	}
+	return "", "Not supported in volumes"
+}
+
+// subscriptKey extracts key from a path such as metadata.labels['key'].
+func subscriptKey(fieldPath, prefix string) (string, bool) {
+	rest, ok := strings.CutPrefix(fieldPath, prefix+"[")
+	if !ok || !strings.HasSuffix(rest, "]") {
+		return "", false
+	}
+	rest = strings.TrimSuffix(rest, "]")
+	if unquoted, err := strconv.Unquote(strings.ReplaceAll(rest, "'", `"`)); err == nil {
+		return unquoted, true
+	}
+	return rest, true
+}
+
+func formatDownwardMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, k+"="+strconv.Quote(m[k]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// downwardResourceValue works out a resourceFieldRef value: the container's
+// request or limit divided by the divisor and rounded up. Unset limits fall
+// back to the node's allocatable capacity, which is not known here.
+func downwardResourceValue(pod *corev1.Pod, ref *corev1.ResourceFieldSelector) (string, string) {
+	var container *corev1.Container
+	for i := range pod.Spec.Containers {
+		if ref.ContainerName == "" || pod.Spec.Containers[i].Name == ref.ContainerName {
+			container = &pod.Spec.Containers[i]
+			break
+		}
+	}
+	if container == nil {
+		return "", "Container " + ref.ContainerName + " not found"
+	}
+
+	kind, name, ok := strings.Cut(ref.Resource, ".")
+	if !ok {
+		return "", "Unknown resource"
+	}
+	list := container.Resources.Limits
+	if kind == "requests" {
+		list = container.Resources.Requests
+	}
+	q, set := list[corev1.ResourceName(name)]
+	if !set {
+		if kind == "limits" {
+			return "", "No limit set; the node's allocatable " + name + " is used"
+		}
+		return "0", "No request set"
+	}
+
+	divisor := ref.Divisor
+	if divisor.IsZero() {
+		divisor = resource.MustParse("1")
+	}
+	var value float64
+	if name == string(corev1.ResourceCPU) {
+		value = math.Ceil(float64(q.MilliValue()) / float64(divisor.MilliValue()))
+	} else {
+		value = math.Ceil(float64(q.Value()) / float64(divisor.Value()))
+	}
+	return strconv.FormatInt(int64(value), 10), "Divisor " + divisor.String()
+}
+
+func keyToPathFiles(source string, items []corev1.KeyToPath, optional *bool) []ProjectedFileView {
+	note := ""
+	if optional != nil && *optional {
+		note = "Optional"
+	}
+	if len(items) == 0 {
+		return []ProjectedFileView{{Path: "(every key)", Source: source, Note: note}}
+	}
+	files := make([]ProjectedFileView, 0, len(items))
+	for _, item := range items {
+		files = append(files, ProjectedFileView{Path: item.Path, Source: source + " key " + item.Key, Note: note})
+	}
+	return files
+}
+
+func serviceAccountName(pod *corev1.Pod) string {
+	if pod.Spec.ServiceAccountName != "" {
+		return pod.Spec.ServiceAccountName
+	}
+	return "default"
+}
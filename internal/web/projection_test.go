@@ -0,0 +1,72 @@
+package web
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProjectedVolumes(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web-1",
+			Namespace:   "shop",
+			Labels:      map[string]string{"app": "web", "tier": "front"},
+			Annotations: map[string]string{"owner": "team-a"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("300Mi")},
+				},
+			}},
+			Volumes: []corev1.Volume{{
+				Name: "podinfo",
+				VolumeSource: corev1.VolumeSource{DownwardAPI: &corev1.DownwardAPIVolumeSource{Items: []corev1.DownwardAPIVolumeFile{
+					{Path: "labels", FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels"}},
+					{Path: "owner", FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.annotations['owner']"}},
+					{Path: "cpu", ResourceFieldRef: &corev1.ResourceFieldSelector{ContainerName: "app", Resource: "requests.cpu", Divisor: resource.MustParse("1m")}},
+					{Path: "mem", ResourceFieldRef: &corev1.ResourceFieldSelector{Resource: "limits.memory", Divisor: resource.MustParse("1Mi")}},
+					{Path: "cpu-limit", ResourceFieldRef: &corev1.ResourceFieldSelector{Resource: "limits.cpu"}},
+				}}},
+			}, {
+				Name: "mixed",
+				VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+					{DownwardAPI: &corev1.DownwardAPIProjection{Items: []corev1.DownwardAPIVolumeFile{{Path: "ns", FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}}}}},
+					{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "cfg"}, Items: []corev1.KeyToPath{{Key: "a", Path: "a.txt"}}}},
+					{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token"}},
+				}}},
+			}, {
+				Name:         "data",
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			}},
+		},
+	}
+
+	views := projectedVolumes(pod)
+	if len(views) != 2 {
+		t.Fatalf("projectedVolumes() returned %d volumes, want 2", len(views))
+	}
+
+	want := []ProjectedFileView{
+		{Path: "labels", Source: "metadata.labels", Value: "app=\"web\"\ntier=\"front\""},
+		{Path: "owner", Source: "metadata.annotations['owner']", Value: "team-a"},
+		{Path: "cpu", Source: "app: requests.cpu", Value: "250", Note: "Divisor 1m"},
+		{Path: "mem", Source: "limits.memory", Value: "300", Note: "Divisor 1Mi"},
+		{Path: "cpu-limit", Source: "limits.cpu", Note: "No limit set; the node's allocatable cpu is used"},
+	}
+	for i, f := range views[0].Files {
+		if f != want[i] {
+			t.Errorf("file %d = %+v, want %+v", i, f, want[i])
+		}
+	}
+
+	mixed := views[1].Files
+	if len(mixed) != 3 || mixed[0].Value != "shop" || mixed[1].Source != "ConfigMap cfg key a" || mixed[2].Source != "Token for service account default" {
+		t.Errorf("projected files = %+v", mixed)
+	}
+}
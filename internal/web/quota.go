@@ -0,0 +1,202 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Quota check modes for Config.QuotaCheck.
+const (
+	QuotaCheckWarn  = "warn"  // ask for confirmation before exceeding a quota
+	QuotaCheckBlock = "block" // refuse actions that would exceed a quota
+	QuotaCheckOff   = "off"
+)
+
+// podUsage is what one pod built from spec charges against a ResourceQuota:
+// "pods", plus the requests and limits under both their quota names, such
+// as "requests.cpu" and "cpu". Like the scheduler, a pod needs the larger of
+// its containers' sum and its largest init container; sidecars run
+// alongside the containers and count towards the sum.
+func podUsage(spec *corev1.PodSpec) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	limits := corev1.ResourceList{}
+	for _, c := range spec.Containers {
+		addResources(requests, c.Resources.Requests)
+		addResources(limits, c.Resources.Limits)
+	}
+	for _, c := range spec.InitContainers {
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			addResources(requests, c.Resources.Requests)
+			addResources(limits, c.Resources.Limits)
+		}
+	}
+	for _, c := range spec.InitContainers {
+		if c.RestartPolicy == nil || *c.RestartPolicy != corev1.ContainerRestartPolicyAlways {
+			maxResources(requests, c.Resources.Requests)
+			maxResources(limits, c.Resources.Limits)
+		}
+	}
+	addResources(requests, spec.Overhead)
+	addResources(limits, spec.Overhead)
+
+	usage := corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1")}
+	for name, q := range requests {
+		usage["requests."+name] = q
+		switch name {
+		case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+			usage[name] = q
+		}
+	}
+	for name, q := range limits {
+		usage["limits."+name] = q
+	}
+	return usage
+}
+
+func addResources(into, from corev1.ResourceList) {
+	for name, q := range from {
+		sum := into[name]
+		sum.Add(q)
+		into[name] = sum
+	}
+}
+
+func maxResources(into, from corev1.ResourceList) {
+	for name, q := range from {
+		if cur, ok := into[name]; !ok || q.Cmp(cur) > 0 {
+			into[name] = q.DeepCopy()
+		}
+	}
+}
+
+// scaleUsage multiplies a per-pod usage by n pods.
+func scaleUsage(usage corev1.ResourceList, n int64) corev1.ResourceList {
+	out := make(corev1.ResourceList, len(usage))
+	for name, q := range usage {
+		q = q.DeepCopy()
+		q.Mul(n)
+		out[name] = q
+	}
+	return out
+}
+
+// quotaIssues reports each quota that adding delta would push over its hard
+// limit. Quotas with scopes are skipped: whether they apply depends on pod
+// fields, such as priority class, that the check does not model.
+func quotaIssues(quotas []corev1.ResourceQuota, delta corev1.ResourceList) []ScaleIssue {
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].Name < quotas[j].Name })
+	names := make([]string, 0, len(delta))
+	for name := range delta {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	var issues []ScaleIssue
+	for _, q := range quotas {
+		if len(q.Spec.Scopes) > 0 || q.Spec.ScopeSelector != nil {
+			continue
+		}
+		for _, name := range names {
+			res := corev1.ResourceName(name)
+			hard, ok := q.Status.Hard[res]
+			if !ok {
+				continue
+			}
+			used := q.Status.Used[res]
+			want := used.DeepCopy()
+			want.Add(delta[res])
+			if want.Cmp(hard) <= 0 {
+				continue
+			}
+			add := delta[res]
+			issues = append(issues, ScaleIssue{
+				Field: "quota",
+				Message: fmt.Sprintf("ResourceQuota %s allows %s of %s; %s is in use and this needs %s more.",
+					q.Name, hard.String(), name, used.String(), add.String()),
+			})
+		}
+	}
+	return issues
+}
+
+// checkQuota reports the namespace quotas that delta would exceed. Without
+// permission to read quotas there is nothing to check against.
+func (s *Server) checkQuota(ctx context.Context, namespace string, delta corev1.ResourceList) ([]ScaleIssue, error) {
+	if s.config.QuotaCheck == QuotaCheckOff || len(delta) == 0 {
+		return nil, nil
+	}
+	list, err := s.manager.Client().CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsForbidden(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return quotaIssues(list.Items, delta), nil
+}
+
+// QuotaRejectedPage explains why an action would exceed a quota, and
+// offers to go ahead when quotas only warn.
+type QuotaRejectedPage struct {
+	BasePage
+	Action    string // verb for the heading and button, such as "Trigger"
+	Kind      string
+	Name      string
+	Errors    []ScaleIssue
+	Warnings  []ScaleIssue
+	ActionURL string
+	BackURL   string
+	// ProductionConfirm carries an accepted production confirmation
+	// into the "Anyway" form.
+	ProductionConfirm string
+}
+
+// validateQuota checks a create action against the namespace quotas. It
+// renders QuotaRejectedPage and returns false when the action would exceed
+// a quota and either quotas block or the user has not confirmed with force=1.
+// consequence is added to each issue to say what exceeding the quota means
+// for this action.
+func (s *Server) validateQuota(w http.ResponseWriter, r *http.Request, action, kind, name string, delta corev1.ResourceList, consequence, backURL, active string) bool {
+	issues, err := s.checkQuota(r.Context(), s.namespace(r), delta)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if len(issues) == 0 {
+		return true
+	}
+	for i := range issues {
+		issues[i].Message += " " + consequence
+	}
+
+	page := QuotaRejectedPage{
+		BasePage:  BasePage{Namespace: s.namespace(r), Title: kind + " " + name, Active: active},
+		Action:    action,
+		Kind:      kind,
+		Name:      name,
+		ActionURL: r.URL.RequestURI(),
+		BackURL:   backURL,
+
+		ProductionConfirm: r.PostFormValue(productionConfirmField),
+	}
+	if s.config.QuotaCheck == QuotaCheckBlock {
+		page.Errors = issues
+		w.WriteHeader(http.StatusForbidden)
+		s.renderTemplate(w, "quota_rejected.html", page)
+		return false
+	}
+	if r.FormValue("force") != "1" {
+		page.Warnings = issues
+		w.WriteHeader(http.StatusConflict)
+		s.renderTemplate(w, "quota_rejected.html", page)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package web
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestPodUsage(t *testing.T) {
+	res := func(cpu, mem string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(mem)},
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(mem)},
+		}
+	}
+	spec := &corev1.PodSpec{
+		Containers: []corev1.Container{
+			{Name: "app", Resources: res("500m", "256Mi")},
+			{Name: "proxy", Resources: res("100m", "64Mi")},
+		},
+		InitContainers: []corev1.Container{
+			{Name: "migrate", Resources: res("1", "128Mi")},
+			{Name: "log-shipper", RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways), Resources: res("100m", "32Mi")},
+		},
+	}
+
+	got := podUsage(spec)
+	for name, want := range map[corev1.ResourceName]string{
+		"pods":            "1",
+		"requests.cpu":    "1", // the migrate init container needs more than the 700m sum
+		"cpu":             "1",
+		"requests.memory": "352Mi", // app, proxy and the sidecar
+		"limits.memory":   "352Mi",
+	} {
+		q := got[name]
+		if q.Cmp(resource.MustParse(want)) != 0 {
+			t.Errorf("%s = %s, want %s", name, q.String(), want)
+		}
+	}
+
+	scaled := scaleUsage(got, 3)
+	if q := scaled["requests.memory"]; q.Cmp(resource.MustParse("1056Mi")) != 0 {
+		t.Errorf("requests.memory for 3 pods = %s, want 1056Mi", q.String())
+	}
+}
+
+func TestQuotaIssues(t *testing.T) {
+	quota := func(name string, hard, used corev1.ResourceList) corev1.ResourceQuota {
+		return corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+		}
+	}
+	compute := quota("compute",
+		corev1.ResourceList{"requests.cpu": resource.MustParse("4"), "pods": resource.MustParse("10")},
+		corev1.ResourceList{"requests.cpu": resource.MustParse("3"), "pods": resource.MustParse("6")})
+	scoped := quota("best-effort", corev1.ResourceList{"pods": resource.MustParse("1")}, nil)
+	scoped.Spec.Scopes = []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}
+
+	delta := corev1.ResourceList{"requests.cpu": resource.MustParse("1500m"), "pods": resource.MustParse("3")}
+	issues := quotaIssues([]corev1.ResourceQuota{scoped, compute}, delta)
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "compute allows 4 of requests.cpu; 3 is in use and this needs 1500m more") {
+		t.Fatalf("quotaIssues() = %+v, want only the requests.cpu of compute", issues)
+	}
+
+	delta["requests.cpu"] = resource.MustParse("1")
+	if issues := quotaIssues([]corev1.ResourceQuota{compute}, delta); len(issues) != 0 {
+		t.Errorf("quotaIssues() at exactly the limit = %+v, want none", issues)
+	}
+}
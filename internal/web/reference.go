@@ -0,0 +1,137 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// detailPages are the batch YAML resources that have a page of their own;
+// the others are linked to their describe output.
+var detailPages = map[string]bool{
+	"pods":        true,
+	"deployments": true,
+	"jobs":        true,
+	"cronjobs":    true,
+	"services":    true,
+	"secrets":     true,
+}
+
+// ResourceReference is the canonical ways to refer to one resource, for
+// pasting into tickets, chats and scripts.
+type ResourceReference struct {
+	Context    string `json:"context,omitempty"`
+	Namespace  string `json:"namespace"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid,omitempty"`
+	Ref        string `json:"ref"`  // namespace/Kind/name
+	Path       string `json:"path"` // the API server path, as selfLink was
+	URL        string `json:"url"`  // the k8s-ui page, in the resource's namespace
+	Kubectl    string `json:"kubectl"`
+}
+
+// ReferenceTarget is the resource a "Copy reference" menu asks /api/ref
+// about.
+type ReferenceTarget struct {
+	Resource string
+	Name     string
+}
+
+func referenceTarget(resource, name string) ReferenceTarget {
+	return ReferenceTarget{Resource: resource, Name: name}
+}
+
+// resourcePageURL is the k8s-ui page of a resource addressed the way the
+// batch YAML view addresses it, with ?namespace= so the link opens in the
+// resource's namespace whatever the viewer has selected.
+func resourcePageURL(resource, namespace, name string) string {
+	q := url.Values{"namespace": {namespace}}
+	if detailPages[resource] {
+		return "/" + resource + "/" + url.PathEscape(name) + "?" + q.Encode()
+	}
+	q.Set("resource", resource)
+	q.Set("name", name)
+	return "/describe?" + q.Encode()
+}
+
+// shellSafe matches words that need no quoting in a POSIX shell.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// resourceReference builds the references of a namespaced resource.
+// Resources outside the core group are named resource.group in the kubectl
+// command, so it does not depend on short names or on which group kubectl
+// prefers for an ambiguous name.
+func resourceReference(resource string, gvr schema.GroupVersionResource, kind, kubeContext, namespace, name, uid string) ResourceReference {
+	ref := ResourceReference{
+		Context:    kubeContext,
+		Namespace:  namespace,
+		APIVersion: gvr.GroupVersion().String(),
+		Kind:       kind,
+		Name:       name,
+		UID:        uid,
+		Ref:        namespace + "/" + kind + "/" + name,
+		URL:        resourcePageURL(resource, namespace, name),
+	}
+	kubectlResource := gvr.Resource
+	if gvr.Group == "" {
+		ref.Path = fmt.Sprintf("/api/%s/namespaces/%s/%s/%s", gvr.Version, namespace, gvr.Resource, name)
+	} else {
+		ref.Path = fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s/%s", gvr.Group, gvr.Version, namespace, gvr.Resource, name)
+		kubectlResource += "." + gvr.Group
+	}
+	ref.Kubectl = fmt.Sprintf("kubectl get %s %s -n %s", kubectlResource, shellQuote(name), namespace)
+	if kubeContext != "" {
+		ref.Kubectl += " --context " + shellQuote(kubeContext)
+	}
+	return ref
+}
+
+// handleResourceReference returns the ResourceReference of ?resource=&name=
+// in the current namespace as JSON. resource takes the same values as the
+// batch YAML view. The resource is read to check that it exists and to
+// report its kind and UID.
+func (s *Server) handleResourceReference(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	resource := r.URL.Query().Get("resource")
+	name := r.URL.Query().Get("name")
+	gvr, _, _, ok := batchResource(resource)
+	if !ok || (resource == "replicationcontrollers" && !s.config.ReplicationControllers) {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("unknown resource %q", resource))
+		return
+	}
+	if name == "" {
+		writeAPIError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	dc, err := s.newDynamicClient()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	namespace := s.namespace(r)
+	obj, err := dc.Resource(gvr).Namespace(namespace).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		writeAPIK8sError(w, err)
+		return
+	}
+	_, current := s.manager.Contexts()
+	writeAPIJSON(w, http.StatusOK, resourceReference(resource, gvr, obj.GetKind(), current, namespace, name, string(obj.GetUID())))
+}
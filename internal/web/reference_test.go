@@ -0,0 +1,40 @@
+package web
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestResourceReference(t *testing.T) {
+	ref := resourceReference("deployments", batchResources["deployments"], "Deployment", "prod-eu", "shop", "web", "1234")
+	want := ResourceReference{
+		Context:    "prod-eu",
+		Namespace:  "shop",
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "web",
+		UID:        "1234",
+		Ref:        "shop/Deployment/web",
+		Path:       "/apis/apps/v1/namespaces/shop/deployments/web",
+		URL:        "/deployments/web?namespace=shop",
+		Kubectl:    "kubectl get deployments.apps web -n shop --context prod-eu",
+	}
+	if ref != want {
+		t.Errorf("resourceReference() = %+v, want %+v", ref, want)
+	}
+
+	ref = resourceReference("configmaps", batchResources["configmaps"], "ConfigMap", "", "shop", "settings", "")
+	if ref.Path != "/api/v1/namespaces/shop/configmaps/settings" || ref.Kubectl != "kubectl get configmaps settings -n shop" {
+		t.Errorf("core resource: path %q, kubectl %q", ref.Path, ref.Kubectl)
+	}
+	if ref.URL != "/describe?name=settings&namespace=shop&resource=configmaps" {
+		t.Errorf("core resource: url %q", ref.URL)
+	}
+
+	gvr := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	ref = resourceReference("cert-manager.io/v1/certificates", gvr, "Certificate", "kind cluster's", "shop", "tls", "")
+	if ref.Kubectl != `kubectl get certificates.cert-manager.io tls -n shop --context 'kind cluster'\''s'` {
+		t.Errorf("custom resource: kubectl %q", ref.Kubectl)
+	}
+}
@@ -0,0 +1,385 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// Each workload of a namespace restart gets restartRolloutTimeout to finish
+// its rollout; restartPollInterval is how often its status is checked.
+const (
+	restartRolloutTimeout = 10 * time.Minute
+	restartPollInterval   = 2 * time.Second
+)
+
+// Restart step states.
+const (
+	StepPending    = "Pending"
+	StepRollingOut = "Rolling out"
+	StepDone       = "Done"
+	StepFailed     = "Failed"
+	StepAborted    = "Aborted"
+	StepSkipped    = "Skipped"
+)
+
+// RestartStep is one workload of a namespace restart.
+type RestartStep struct {
+	Workload WorkloadRef
+	State    string
+	Detail   string
+}
+
+// namespaceRestart restarts the workloads of one namespace one after the
+// other, waiting for each rollout to finish before the next, so a shared
+// change such as a rotated secret or CA reaches every workload without
+// taking them all down at once.
+type namespaceRestart struct {
+	Namespace string
+	Context   string
+	Actor     string
+	Started   time.Time
+
+	mu       sync.Mutex
+	steps    []RestartStep
+	finished time.Time
+	outcome  string
+	cancel   context.CancelFunc
+}
+
+// restartRuns keeps the latest namespace restart per context and namespace.
+type restartRuns struct {
+	mu   sync.Mutex
+	runs map[string]*namespaceRestart
+}
+
+func (rr *restartRuns) get(key string) *namespaceRestart {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return rr.runs[key]
+}
+
+// start records run under key unless a run is still in progress there.
+func (rr *restartRuns) start(key string, run *namespaceRestart) bool {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if cur := rr.runs[key]; cur != nil && cur.running() {
+		return false
+	}
+	if rr.runs == nil {
+		rr.runs = make(map[string]*namespaceRestart)
+	}
+	rr.runs[key] = run
+	return true
+}
+
+func (run *namespaceRestart) running() bool {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return run.finished.IsZero()
+}
+
+// snapshot returns a copy of the steps, the outcome and the finish time,
+// which are empty while the run is in progress.
+func (run *namespaceRestart) snapshot() ([]RestartStep, string, time.Time) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return append([]RestartStep(nil), run.steps...), run.outcome, run.finished
+}
+
+func (run *namespaceRestart) setStep(i int, state, detail string) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	run.steps[i].State = state
+	run.steps[i].Detail = detail
+}
+
+// abort stops the run before its next workload. A rollout already under way
+// is left to the controller.
+func (run *namespaceRestart) abort() {
+	run.cancel()
+}
+
+// execute restarts the steps in order. It stops at the first failure, as a
+// rollout that does not finish usually means the shared change broke
+// something the later workloads would also hit.
+func (run *namespaceRestart) execute(ctx context.Context, client kubernetes.Interface, audit *auditLog) {
+	outcome := ""
+	for i := range run.steps {
+		ref := run.steps[i].Workload
+		if ctx.Err() != nil {
+			run.setStep(i, StepSkipped, "Aborted before this workload")
+			outcome = "Aborted."
+			continue
+		}
+		if outcome != "" {
+			run.setStep(i, StepSkipped, "")
+			continue
+		}
+
+		if reason := restartSkipReason(ctx, client, run.Namespace, ref); reason != "" {
+			run.setStep(i, StepSkipped, reason)
+			continue
+		}
+		run.setStep(i, StepRollingOut, "Restarting")
+		if err := restartWorkload(ctx, client, run.Namespace, ref); err != nil {
+			run.setStep(i, StepFailed, err.Error())
+			outcome = fmt.Sprintf("Stopped: %s %s could not be restarted.", ref.Kind, ref.Name)
+			continue
+		}
+		audit.add(AuditEntry{
+			Time:      time.Now(),
+			Context:   run.Context,
+			Namespace: run.Namespace,
+			Actor:     run.Actor,
+			Kind:      strings.ToLower(ref.Kind),
+			Name:      ref.Name,
+			Action:    "restarted by a namespace restart",
+		})
+		if err := run.waitRollout(ctx, client, i); err != nil {
+			if ctx.Err() != nil {
+				run.setStep(i, StepAborted, "Restarted, but no longer waiting for the rollout to finish")
+				outcome = "Aborted."
+				continue
+			}
+			run.setStep(i, StepFailed, err.Error())
+			outcome = fmt.Sprintf("Stopped: %s %s did not finish rolling out.", ref.Kind, ref.Name)
+			continue
+		}
+		run.setStep(i, StepDone, "")
+	}
+	if outcome == "" {
+		outcome = "All workloads were restarted."
+	}
+	log.Printf("Namespace restart of %s in context %s finished: %s", run.Namespace, run.Context, outcome)
+
+	run.mu.Lock()
+	run.outcome = outcome
+	run.finished = time.Now()
+	run.mu.Unlock()
+	run.cancel()
+}
+
+// restartSkipReason explains why a workload's rollout would never finish:
+// a paused Deployment, or an OnDelete update strategy that waits for pods
+// to be deleted by hand. It is empty for any other workload, and when the
+// workload cannot be read, leaving the error to the restart itself.
+func restartSkipReason(ctx context.Context, client kubernetes.Interface, namespace string, ref WorkloadRef) string {
+	apps := client.AppsV1()
+	switch ref.Kind {
+	case "Deployment":
+		d, err := apps.Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err == nil && d.Spec.Paused {
+			return "Deployment is paused; restart it after resuming"
+		}
+	case "StatefulSet":
+		ss, err := apps.StatefulSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err == nil && ss.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+			return "Uses the OnDelete update strategy; delete its pods to restart them"
+		}
+	case "DaemonSet":
+		ds, err := apps.DaemonSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err == nil && ds.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType {
+			return "Uses the OnDelete update strategy; delete its pods to restart them"
+		}
+	}
+	return ""
+}
+
+// waitRollout polls the workload of step i until its rollout has finished,
+// showing the progress in the step.
+func (run *namespaceRestart) waitRollout(ctx context.Context, client kubernetes.Interface, i int) error {
+	ctx, cancel := context.WithTimeout(ctx, restartRolloutTimeout)
+	defer cancel()
+	ref := run.steps[i].Workload
+	for {
+		done, progress, err := rolloutStatus(ctx, client, run.Namespace, ref)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		run.setStep(i, StepRollingOut, progress)
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("not rolled out after %s: %s", restartRolloutTimeout, progress)
+			}
+			return ctx.Err()
+		case <-time.After(restartPollInterval):
+		}
+	}
+}
+
+// rolloutStatus reports whether a restarted workload has finished rolling
+// out, the way kubectl rollout status decides it, and its progress.
+func rolloutStatus(ctx context.Context, client kubernetes.Interface, namespace string, ref WorkloadRef) (bool, string, error) {
+	apps := client.AppsV1()
+	switch ref.Kind {
+	case "Deployment":
+		d, err := apps.Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", err
+		}
+		st := apiDeploymentStatus(d)
+		return st.Complete, fmt.Sprintf("%d of %d updated, %d available", st.Updated, st.Replicas, st.Available), nil
+	case "StatefulSet":
+		ss, err := apps.StatefulSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", err
+		}
+		done, progress := statefulSetRolledOut(ss)
+		return done, progress, nil
+	case "DaemonSet":
+		ds, err := apps.DaemonSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, "", err
+		}
+		done, progress := daemonSetRolledOut(ds)
+		return done, progress, nil
+	}
+	return false, "", fmt.Errorf("%s does not support rollout restart", ref.Kind)
+}
+
+func statefulSetRolledOut(ss *appsv1.StatefulSet) (bool, string) {
+	want, st := ptr.Deref(ss.Spec.Replicas, 1), ss.Status
+	done := st.ObservedGeneration >= ss.Generation && st.UpdatedReplicas == want &&
+		st.ReadyReplicas == want && st.CurrentRevision == st.UpdateRevision
+	return done, fmt.Sprintf("%d of %d updated, %d ready", st.UpdatedReplicas, want, st.ReadyReplicas)
+}
+
+func daemonSetRolledOut(ds *appsv1.DaemonSet) (bool, string) {
+	st := ds.Status
+	done := st.ObservedGeneration >= ds.Generation && st.UpdatedNumberScheduled == st.DesiredNumberScheduled &&
+		st.NumberAvailable == st.DesiredNumberScheduled
+	return done, fmt.Sprintf("%d of %d updated, %d available", st.UpdatedNumberScheduled, st.DesiredNumberScheduled, st.NumberAvailable)
+}
+
+// NamespaceRestartPage lists the workloads a namespace restart covers and,
+// once started, its progress.
+type NamespaceRestartPage struct {
+	BasePage
+	Context   string
+	Workloads []WorkloadRef
+	Warning   string
+	Error     string
+
+	Started  bool
+	Running  bool
+	Steps    []RestartStep
+	Outcome  string
+	Duration string
+}
+
+// handleNamespaceRestart shows and starts a rolling restart of every
+// Deployment, StatefulSet and DaemonSet in the namespace. Only one restart
+// runs per namespace at a time; POST action=abort stops it.
+func (s *Server) handleNamespaceRestart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	namespace := s.namespace(r)
+	_, current := s.manager.Contexts()
+	key := current + "/" + namespace
+	run := s.restarts.get(key)
+
+	data := NamespaceRestartPage{
+		BasePage: BasePage{Namespace: namespace, Title: "Restart all workloads", Active: "deployments"},
+		Context:  current,
+	}
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form", http.StatusBadRequest)
+			return
+		}
+		switch r.PostForm.Get("action") {
+		case "abort":
+			if run != nil {
+				run.abort()
+			}
+			http.Redirect(w, r, keepNamespace(r, "/namespace/restart"), http.StatusSeeOther)
+			return
+		case "start":
+			workloads, warnings := s.restartableWorkloads(r.Context(), namespace)
+			if len(warnings) > 0 {
+				// Restarting only the kinds that could be listed would
+				// silently leave others on the old configuration.
+				data.Error = "Not started, some workload kinds could not be listed: " + strings.Join(warnings, "; ")
+				break
+			}
+			if len(workloads) == 0 {
+				data.Error = "There are no workloads to restart."
+				break
+			}
+			// The run outlives the request, and is aborted when the server
+			// shuts down.
+			ctx, cancel := context.WithCancel(s.background)
+			newRun := &namespaceRestart{Namespace: namespace, Context: current, Actor: requestActor(r), Started: time.Now(), cancel: cancel}
+			for _, ref := range workloads {
+				newRun.steps = append(newRun.steps, RestartStep{Workload: ref, State: StepPending})
+			}
+			if !s.restarts.start(key, newRun) {
+				cancel()
+				data.Error = "A restart of this namespace is already running."
+				break
+			}
+			log.Printf("Namespace restart of %s in context %s started for %d workloads", namespace, current, len(workloads))
+			s.running.Add(1)
+			go func() {
+				defer s.running.Done()
+				newRun.execute(ctx, s.manager.Client(), &s.auditLog)
+			}()
+			http.Redirect(w, r, keepNamespace(r, "/namespace/restart"), http.StatusSeeOther)
+			return
+		default:
+			http.Error(w, "Unknown action", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if run != nil {
+		steps, outcome, finished := run.snapshot()
+		data.Started = true
+		data.Running = finished.IsZero()
+		data.Steps = steps
+		data.Outcome = outcome
+		if finished.IsZero() {
+			finished = time.Now()
+		}
+		data.Duration = formatDuration(finished.Sub(run.Started))
+	}
+	if !data.Running {
+		var warnings []string
+		data.Workloads, warnings = s.restartableWorkloads(r.Context(), namespace)
+		if len(warnings) > 0 {
+			data.Warning = "Some workload kinds could not be listed: " + strings.Join(warnings, "; ")
+		}
+	}
+	s.renderTemplate(w, "namespace_restart.html", data)
+}
+
+// restartableWorkloads lists the Deployments, StatefulSets and DaemonSets
+// of the namespace.
+func (s *Server) restartableWorkloads(ctx context.Context, namespace string) ([]WorkloadRef, []string) {
+	all, warnings := s.workloadsUsing(ctx, namespace, func(*corev1.PodSpec) bool { return true })
+	var refs []WorkloadRef
+	for _, ref := range all {
+		if ref.Restartable() {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, warnings
+}
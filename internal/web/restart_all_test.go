@@ -0,0 +1,78 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestStatefulSetRolledOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		gen    int64
+		status appsv1.StatefulSetStatus
+		want   bool
+	}{
+		{"rolled out", 2, appsv1.StatefulSetStatus{ObservedGeneration: 2, UpdatedReplicas: 3, ReadyReplicas: 3, CurrentRevision: "r2", UpdateRevision: "r2"}, true},
+		{"restart not observed", 3, appsv1.StatefulSetStatus{ObservedGeneration: 2, UpdatedReplicas: 3, ReadyReplicas: 3, CurrentRevision: "r2", UpdateRevision: "r2"}, false},
+		{"partly updated", 2, appsv1.StatefulSetStatus{ObservedGeneration: 2, UpdatedReplicas: 1, ReadyReplicas: 3, CurrentRevision: "r1", UpdateRevision: "r2"}, false},
+		{"updated but not ready", 2, appsv1.StatefulSetStatus{ObservedGeneration: 2, UpdatedReplicas: 3, ReadyReplicas: 2, CurrentRevision: "r2", UpdateRevision: "r2"}, false},
+	}
+
+	for _, tt := range tests {
+		ss := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Generation: tt.gen},
+			Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To(int32(3))},
+			Status:     tt.status,
+		}
+		if got, progress := statefulSetRolledOut(ss); got != tt.want {
+			t.Errorf("%s: statefulSetRolledOut() = %v (%s), want %v", tt.name, got, progress, tt.want)
+		}
+	}
+}
+
+func TestDaemonSetRolledOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		status appsv1.DaemonSetStatus
+		want   bool
+	}{
+		{"rolled out", appsv1.DaemonSetStatus{ObservedGeneration: 5, DesiredNumberScheduled: 4, UpdatedNumberScheduled: 4, NumberAvailable: 4}, true},
+		{"restart not observed", appsv1.DaemonSetStatus{ObservedGeneration: 4, DesiredNumberScheduled: 4, UpdatedNumberScheduled: 4, NumberAvailable: 4}, false},
+		{"partly updated", appsv1.DaemonSetStatus{ObservedGeneration: 5, DesiredNumberScheduled: 4, UpdatedNumberScheduled: 2, NumberAvailable: 4}, false},
+		{"updated but unavailable", appsv1.DaemonSetStatus{ObservedGeneration: 5, DesiredNumberScheduled: 4, UpdatedNumberScheduled: 4, NumberAvailable: 3}, false},
+	}
+
+	for _, tt := range tests {
+		ds := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Generation: 5}, Status: tt.status}
+		if got, progress := daemonSetRolledOut(ds); got != tt.want {
+			t.Errorf("%s: daemonSetRolledOut() = %v (%s), want %v", tt.name, got, progress, tt.want)
+		}
+	}
+}
+
+func TestRestartRunsStartOnePerNamespace(t *testing.T) {
+	var rr restartRuns
+	first := &namespaceRestart{}
+	if !rr.start("ctx/shop", first) {
+		t.Fatal("first restart of a namespace was refused")
+	}
+	if rr.start("ctx/shop", &namespaceRestart{}) {
+		t.Error("second restart started while the first is running")
+	}
+	if !rr.start("ctx/other", &namespaceRestart{}) {
+		t.Error("restart of another namespace was refused")
+	}
+
+	first.finished = time.Now()
+	second := &namespaceRestart{}
+	if !rr.start("ctx/shop", second) {
+		t.Fatal("restart was refused after the previous one finished")
+	}
+	if rr.get("ctx/shop") != second {
+		t.Error("get() does not return the latest restart")
+	}
+}
@@ -0,0 +1,137 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// returnToField is the form field in which a form names the page to go
+// back to once its action is done.
+const returnToField = "return_to"
+
+// localPath returns the path and query of raw when it is a page of this
+// server. Absolute URLs of other hosts, scheme-relative //host URLs and
+// paths with a backslash, which browsers may read as //, are rejected, so
+// a return_to field cannot send the user to another site.
+func localPath(raw, host string) (string, bool) {
+	if raw == "" || strings.Contains(raw, `\`) {
+		return "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Opaque != "" || u.User != nil {
+		return "", false
+	}
+	if u.Scheme != "" || u.Host != "" {
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host != host {
+			return "", false
+		}
+	}
+	if !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(u.Path, "//") {
+		return "", false
+	}
+	u.Scheme, u.Host, u.Fragment = "", "", ""
+	return u.RequestURI(), true
+}
+
+// refererPath returns the page of this server a request was sent from.
+// A form's own action URL does not count: it is the page only when the
+// form was re-posted, such as after a production confirmation.
+func refererPath(r *http.Request) (string, bool) {
+	p, ok := localPath(r.Referer(), r.Host)
+	if !ok {
+		return "", false
+	}
+	if u, err := url.Parse(p); err != nil || u.Path == r.URL.Path {
+		return "", false
+	}
+	return p, true
+}
+
+// returnTo returns the page an action goes back to: the return_to field of
+// the request, or else the page it was sent from. Pages of objects the
+// action removed, given as gone with their sub-pages, are skipped, as are
+// other sites; fallback is used then.
+func returnTo(r *http.Request, fallback string, gone ...string) string {
+	target, ok := localPath(r.FormValue(returnToField), r.Host)
+	if !ok {
+		target, ok = refererPath(r)
+	}
+	if !ok {
+		return fallback
+	}
+	path, _, _ := strings.Cut(target, "?")
+	for _, g := range gone {
+		if path == g || strings.HasPrefix(path, g+"/") {
+			return fallback
+		}
+	}
+	return target
+}
+
+// redirectBack ends a form action by going back to the page given by
+// returnTo, in the namespace the action was made in.
+func (s *Server) redirectBack(w http.ResponseWriter, r *http.Request, fallback string, gone ...string) {
+	http.Redirect(w, r, keepNamespace(r, returnTo(r, fallback, gone...)), http.StatusSeeOther)
+}
+
+// keepNamespace adds the ?namespace= override of r, if any, to target, a
+// page of this server, so redirects after an action stay in it.
+func keepNamespace(r *http.Request, target string) string {
+	ns, ok := r.Context().Value(namespaceOverrideKey{}).(string)
+	if !ok {
+		return target
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host != "" || !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(u.Path, "/api/") {
+		return target
+	}
+	q := u.Query()
+	if q.Has("namespace") {
+		return target
+	}
+	q.Set("namespace", ns)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// objectPages are the path prefixes under which pages show one object of
+// a namespace, such as /pods/web-1/logs.
+var objectPages = []string{
+	"pods", "deployments", "statefulsets", "jobs", "cronjobs", "replicationcontrollers",
+	"configmaps", "secrets", "services", "ingresses", "pvcs", "crds",
+}
+
+// switchReturnTo returns the page to show after switching to namespace,
+// and to another context when contextChanged: the page the switch was made
+// from, unless it shows something the new scope may not have. A page of
+// one object becomes the list it belongs to, except when it was already
+// showing the new namespace through ?namespace=, and with a new context
+// node pages become the pod list. Pages that only make sense for one
+// object, such as describe output, go to /.
+func switchReturnTo(r *http.Request, namespace string, contextChanged bool) string {
+	u, err := url.Parse(returnTo(r, "/"))
+	if err != nil {
+		return "/"
+	}
+
+	q := u.Query()
+	viewing := q.Get("namespace")
+	q.Del("namespace")
+	u.RawQuery = q.Encode()
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case u.Path == "/describe" || u.Path == "/yaml":
+		return "/"
+	case len(segments) > 1 && segments[0] == "nodes" && contextChanged:
+		return "/pods"
+	case len(segments) > 1 && slices.Contains(objectPages, segments[0]):
+		if viewing == namespace && !contextChanged {
+			return u.RequestURI()
+		}
+		return "/" + segments[0]
+	}
+	return u.RequestURI()
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{raw: "/pods?status=Failed", want: "/pods?status=Failed", ok: true},
+		{raw: "/deployments/web#pods", want: "/deployments/web", ok: true},
+		{raw: "http://ui.example.com/jobs", want: "/jobs", ok: true},
+		{raw: "https://evil.example.com/jobs"},
+		{raw: "//evil.example.com/jobs"},
+		{raw: `/\evil.example.com`},
+		{raw: "javascript:alert(1)"},
+		{raw: "pods"},
+		{raw: ""},
+	}
+	for _, tt := range tests {
+		got, ok := localPath(tt.raw, "ui.example.com")
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("localPath(%q) = %q, %v; want %q, %v", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestReturnTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		returnTo string
+		referer  string
+		gone     []string
+		want     string
+	}{
+		{name: "no referer", path: "/deployments/web/restart", want: "/deployments"},
+		{name: "referer", path: "/deployments/web/restart", referer: "http://ui.example.com/deployments/web?namespace=shop", want: "/deployments/web?namespace=shop"},
+		{name: "other site", path: "/deployments/web/restart", referer: "https://evil.example.com/", want: "/deployments"},
+		{name: "field wins", path: "/deployments/web/restart", returnTo: "/pods", referer: "http://ui.example.com/deployments/web", want: "/pods"},
+		{name: "unsafe field", path: "/deployments/web/restart", returnTo: "//evil.example.com", referer: "http://ui.example.com/deployments/web", want: "/deployments/web"},
+		{name: "own action", path: "/deployments/web/restart", referer: "http://ui.example.com/deployments/web/restart", want: "/deployments"},
+		{name: "gone object", path: "/pods/web-1/restart", referer: "http://ui.example.com/pods/web-1/logs", gone: []string{"/pods/web-1"}, want: "/pods"},
+		{name: "similar name", path: "/pods/web-1/restart", referer: "http://ui.example.com/pods/web-10", gone: []string{"/pods/web-1"}, want: "/pods/web-10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			if tt.returnTo != "" {
+				form.Set(returnToField, tt.returnTo)
+			}
+			r := httptest.NewRequest("POST", "http://ui.example.com"+tt.path, strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.referer != "" {
+				r.Header.Set("Referer", tt.referer)
+			}
+			if got := returnTo(r, "/"+strings.Split(tt.path, "/")[1], tt.gone...); got != tt.want {
+				t.Errorf("returnTo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepNamespace(t *testing.T) {
+	r := httptest.NewRequest("POST", "/deployments/web/restart?namespace=shop", nil)
+	if got := keepNamespace(r, "/deployments"); got != "/deployments" {
+		t.Errorf("without an override keepNamespace() = %q, want it unchanged", got)
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), namespaceOverrideKey{}, "shop"))
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{target: "/deployments", want: "/deployments?namespace=shop"},
+		{target: "/configmaps/app/rollout?saved=1", want: "/configmaps/app/rollout?namespace=shop&saved=1"},
+		{target: "/pods?namespace=other", want: "/pods?namespace=other"},
+		{target: "/api/ref", want: "/api/ref"},
+	}
+	for _, tt := range tests {
+		if got := keepNamespace(r, tt.target); got != tt.want {
+			t.Errorf("keepNamespace(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestSwitchReturnTo(t *testing.T) {
+	tests := []struct {
+		referer        string
+		contextChanged bool
+		want           string
+	}{
+		{referer: "", want: "/"},
+		{referer: "/pods?status=Failed", want: "/pods?status=Failed"},
+		{referer: "/deployments/web", want: "/deployments"},
+		{referer: "/deployments/web?namespace=shop", want: "/deployments/web"},
+		{referer: "/deployments/web?namespace=shop", contextChanged: true, want: "/deployments"},
+		{referer: "/pods?namespace=other", want: "/pods"},
+		{referer: "/describe?resource=services&name=web", want: "/"},
+		{referer: "/nodes/node-1", want: "/nodes/node-1"},
+		{referer: "/nodes/node-1", contextChanged: true, want: "/pods"},
+		{referer: "/tools/node-fit?pod=web-1", want: "/tools/node-fit?pod=web-1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "http://ui.example.com/api/switch-namespace", nil)
+		if tt.referer != "" {
+			r.Header.Set("Referer", "http://ui.example.com"+tt.referer)
+		}
+		if got := switchReturnTo(r, "shop", tt.contextChanged); got != tt.want {
+			t.Errorf("switchReturnTo(%q, %v) = %q, want %q", tt.referer, tt.contextChanged, got, tt.want)
+		}
+	}
+}
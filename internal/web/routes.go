@@ -2,13 +2,14 @@ package web
 
 import (
 	"net/http"
+	"strings"
 )
 
 func (s *Server) registerRoutes() {
 	// Redirect root to /pods
 	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" {
-			http.Redirect(w, r, "/pods", http.StatusFound)
+			http.Redirect(w, r, s.landingPage(), http.StatusFound)
 			return
 		}
 		http.NotFound(w, r)
@@ -58,6 +59,7 @@ func (s *Server) registerRoutes() {
 
 	// Deployments
 	s.mux.HandleFunc("/deployments", s.handleDeploymentsList)
+	s.mux.HandleFunc("/namespace/restart", s.handleNamespaceRestart)
 	s.mux.HandleFunc("/deployments/", func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 		sub := path[len("/deployments/"):]
@@ -66,6 +68,10 @@ func (s *Server) registerRoutes() {
 			s.handleDeploymentRestart(w, r)
 			return
 		}
+		if len(sub) > 7 && sub[len(sub)-7:] == "/delete" {
+			s.handleSoftDelete(w, r, "deployments")
+			return
+		}
 		if len(sub) > 6 && sub[len(sub)-6:] == "/scale" {
 			s.handleDeploymentScale(w, r)
 			return
@@ -82,16 +88,58 @@ func (s *Server) registerRoutes() {
 			s.handleDeploymentYAML(w, r)
 			return
 		}
+		if len(sub) > 9 && sub[len(sub)-9:] == "/logs.zip" {
+			s.handleWorkloadLogsArchive(w, r, "deployments")
+			return
+		}
+		if len(sub) > 19 && sub[len(sub)-19:] == "/support-bundle.zip" {
+			s.handleSupportBundle(w, r, "deployments")
+			return
+		}
+		if sub != "" && !strings.Contains(sub, "/") {
+			s.handleDeploymentDetail(w, r)
+			return
+		}
 
 		http.Redirect(w, r, "/deployments", http.StatusFound)
 	})
 
+	// Nodes
+	s.mux.HandleFunc("/nodes/", func(w http.ResponseWriter, r *http.Request) {
+		if len(r.URL.Path) > 6 && r.URL.Path[len(r.URL.Path)-6:] == "/drain" {
+			s.handleNodeDrain(w, r)
+			return
+		}
+		if sub := r.URL.Path[len("/nodes/"):]; sub != "" && !strings.Contains(sub, "/") {
+			s.handleNodeDetail(w, r)
+			return
+		}
+		http.Redirect(w, r, "/pods", http.StatusFound)
+	})
+
 	// Events
 	s.mux.HandleFunc("/events", s.handleEventsList)
+	s.mux.HandleFunc("/activity", s.handleActivity)
+	s.mux.HandleFunc("/stream/pods", s.handlePodStream)
 
 	// Resources explorer
 	s.mux.HandleFunc("/resources", s.handleResourcesIndex)
 
+	// Batch YAML of rows selected on a list page
+	s.mux.HandleFunc("/yaml", s.handleBatchYAML)
+
+	// kubectl describe output for one resource
+	s.mux.HandleFunc("/describe", s.handleDescribe)
+
+	// Tools
+	s.mux.HandleFunc("/tools/image-drift", s.handleImageDrift)
+	s.mux.HandleFunc("/tools/dry-run", s.handleDryRun)
+	s.mux.HandleFunc("/tools/node-fit", s.handleNodeFit)
+	s.mux.HandleFunc("/cluster/versions", s.handleClusterVersions)
+	if s.config.ClusterHealth {
+		s.mux.HandleFunc("/cluster/health", s.handleClusterHealth)
+	}
+
 	// CRDs (read-only)
 	s.mux.HandleFunc("/crds", s.handleCRDsList)
 	s.mux.HandleFunc("/crds/", s.handleCRDsSubroutes)
@@ -117,6 +165,17 @@ func (s *Server) registerRoutes() {
 		http.Redirect(w, r, "/statefulsets", http.StatusFound)
 	})
 
+	if s.config.ReplicationControllers {
+		s.mux.HandleFunc("/replicationcontrollers", s.handleReplicationControllersList)
+		s.mux.HandleFunc("/replicationcontrollers/", func(w http.ResponseWriter, r *http.Request) {
+			if len(r.URL.Path) > 5 && r.URL.Path[len(r.URL.Path)-5:] == "/yaml" {
+				s.handleReplicationControllerYAML(w, r)
+				return
+			}
+			http.Redirect(w, r, "/replicationcontrollers", http.StatusFound)
+		})
+	}
+
 	s.mux.HandleFunc("/jobs", s.handleJobsList)
 	s.mux.HandleFunc("/jobs/", func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
@@ -130,6 +189,18 @@ func (s *Server) registerRoutes() {
 			s.handleJobYAML(w, r)
 			return
 		}
+		if len(sub) > 9 && sub[len(sub)-9:] == "/logs.zip" {
+			s.handleWorkloadLogsArchive(w, r, "jobs")
+			return
+		}
+		if len(sub) > 19 && sub[len(sub)-19:] == "/support-bundle.zip" {
+			s.handleSupportBundle(w, r, "jobs")
+			return
+		}
+		if sub != "" && !strings.Contains(sub, "/") {
+			s.handleJobDetail(w, r)
+			return
+		}
 		http.Redirect(w, r, "/jobs", http.StatusFound)
 	})
 
@@ -150,6 +221,10 @@ func (s *Server) registerRoutes() {
 			s.handleCronJobYAML(w, r)
 			return
 		}
+		if sub != "" && !strings.Contains(sub, "/") {
+			s.handleCronJobDetail(w, r)
+			return
+		}
 		http.Redirect(w, r, "/cronjobs", http.StatusFound)
 	})
 
@@ -160,6 +235,14 @@ func (s *Server) registerRoutes() {
 			s.handleServiceYAML(w, r)
 			return
 		}
+		if len(r.URL.Path) > 7 && r.URL.Path[len(r.URL.Path)-7:] == "/delete" {
+			s.handleSoftDelete(w, r, "services")
+			return
+		}
+		if sub := r.URL.Path[len("/services/"):]; sub != "" && !strings.Contains(sub, "/") {
+			s.handleServiceDetail(w, r)
+			return
+		}
 		http.Redirect(w, r, "/services", http.StatusFound)
 	})
 
@@ -190,9 +273,24 @@ func (s *Server) registerRoutes() {
 			s.handleConfigMapYAML(w, r)
 			return
 		}
+		if len(sub) > 8 && sub[len(sub)-8:] == "/rollout" {
+			s.handleConfigRollout(w, r, "configmaps")
+			return
+		}
+		if len(sub) > 7 && sub[len(sub)-7:] == "/delete" {
+			s.handleSoftDelete(w, r, "configmaps")
+			return
+		}
 		http.Redirect(w, r, "/configmaps", http.StatusFound)
 	})
 
+	s.mux.HandleFunc("/deleted", s.handleDeleted)
+	if s.config.SessionsPage {
+		s.mux.HandleFunc("/tools/sessions", s.handleSessions)
+		s.mux.HandleFunc("/tools/sessions/", s.handleSessionEnd)
+	}
+	s.mux.HandleFunc("/deleted/", s.handleDeletedUndo)
+
 	s.mux.HandleFunc("/secrets", s.handleSecretsList)
 	s.mux.HandleFunc("/secrets/", func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
@@ -202,6 +300,10 @@ func (s *Server) registerRoutes() {
 			s.handleSecretYAML(w, r)
 			return
 		}
+		if len(sub) > 8 && sub[len(sub)-8:] == "/rollout" {
+			s.handleConfigRollout(w, r, "secrets")
+			return
+		}
 
 		// Detail view
 		if sub != "" {
@@ -222,7 +324,32 @@ func (s *Server) registerRoutes() {
 		http.Redirect(w, r, "/pvcs", http.StatusFound)
 	})
 
+	// Probes; metrics and pprof are only served on the admin port
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	s.mux.HandleFunc("/readyz", s.handleReadyz)
+
 	// API
 	s.mux.HandleFunc("/api/switch-context", s.handleSwitchContext)
 	s.mux.HandleFunc("/api/switch-namespace", s.handleSwitchNamespace)
+	s.mux.HandleFunc("/api/ref", s.handleResourceReference)
+
+	// JSON API for automation, enabled by API tokens
+	if len(s.config.APITokens) > 0 {
+		s.mux.HandleFunc("/api/v1/pods", s.withAPIToken(ScopeRead, s.handleAPIPods))
+		s.mux.HandleFunc("/api/v1/pods/", s.withAPIToken(ScopeRead, s.handleAPIPod))
+		s.mux.HandleFunc("/api/v1/deployments", s.withAPIToken(ScopeRead, s.handleAPIDeployments))
+		s.mux.HandleFunc("/api/v1/ref", s.withAPIToken(ScopeRead, s.handleResourceReference))
+		s.mux.HandleFunc("/api/v1/deployments/", func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/restart") {
+				s.withAPIToken(ScopeRestart, s.handleAPIDeploymentRestart)(w, r)
+				return
+			}
+			s.withAPIToken(ScopeRead, s.handleAPIDeployment)(w, r)
+		})
+	}
+
+	// Slack slash command, authenticated by Slack's request signature
+	if s.config.SlackSigningSecret != "" {
+		s.mux.HandleFunc("/api/slack/command", s.handleSlackCommand)
+	}
 }
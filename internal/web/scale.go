@@ -0,0 +1,148 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScaleIssue is a single problem found while validating a scale request.
+type ScaleIssue struct {
+	Field   string
+	Message string
+}
+
+type ScaleRejectedPage struct {
+	BasePage
+	Kind      string
+	Name      string
+	Requested string
+	Errors    []ScaleIssue
+	Warnings  []ScaleIssue
+	ScaleURL  string
+	BackURL   string
+	// ProductionConfirm carries an accepted production confirmation
+	// into the "Scale Anyway" form.
+	ProductionConfirm string
+}
+
+// parseReplicas validates the raw replicas form value against the
+// configured cap. A zero max means no cap.
+func parseReplicas(raw string, max int32) (int32, []ScaleIssue) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, []ScaleIssue{{Field: "replicas", Message: "Replica count is required."}}
+	}
+	n, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil {
+		return 0, []ScaleIssue{{Field: "replicas", Message: fmt.Sprintf("%q is not a whole number.", raw)}}
+	}
+	if n < 0 {
+		return 0, []ScaleIssue{{Field: "replicas", Message: "Replica count cannot be negative."}}
+	}
+	if max > 0 && n > int64(max) {
+		return 0, []ScaleIssue{{Field: "replicas", Message: fmt.Sprintf("Replica count %d exceeds the configured maximum of %d (MAX_REPLICAS).", n, max)}}
+	}
+	return int32(n), nil
+}
+
+// scaleWarnings reports conditions that make a manual scale likely to be
+// overridden or surprising. kind is the scale target kind as used in
+// HPA scaleTargetRef, e.g. "Deployment".
+func (s *Server) scaleWarnings(ctx context.Context, namespace, kind, name string, paused bool, replicas int32) ([]ScaleIssue, error) {
+	var warnings []ScaleIssue
+
+	hpas, err := s.manager.Client().AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsForbidden(err) {
+		return nil, err
+	}
+	if err == nil {
+		for _, h := range hpas.Items {
+			ref := h.Spec.ScaleTargetRef
+			if ref.Kind != kind || ref.Name != name {
+				continue
+			}
+			min := int32(1)
+			if h.Spec.MinReplicas != nil {
+				min = *h.Spec.MinReplicas
+			}
+			msg := fmt.Sprintf("HorizontalPodAutoscaler %s manages this %s (min %d, max %d) and will override a manual replica count on its next sync.",
+				h.Name, kind, min, h.Spec.MaxReplicas)
+			if replicas == 0 {
+				msg = fmt.Sprintf("HorizontalPodAutoscaler %s manages this %s. Scaling to 0 disables autoscaling until the replica count is raised again.", h.Name, kind)
+			}
+			warnings = append(warnings, ScaleIssue{Field: "hpa", Message: msg})
+		}
+	}
+
+	if paused {
+		warnings = append(warnings, ScaleIssue{Field: "paused", Message: fmt.Sprintf("This %s is paused. The replica count changes, but pending template changes stay unrolled until it is resumed.", kind)})
+	}
+
+	return warnings, nil
+}
+
+// validateScale parses and checks a scale request. It renders a rejection
+// page and returns false when the request has errors, or has warnings that
+// the user has not confirmed with force=1. current and pod are the
+// workload's replica count and pod template, used to check that added pods
+// fit the namespace quotas.
+func (s *Server) validateScale(w http.ResponseWriter, r *http.Request, kind, name string, paused bool, current int32, pod *corev1.PodSpec, backURL, active string) (int32, bool) {
+	raw := r.FormValue("replicas")
+	page := ScaleRejectedPage{
+		BasePage:  BasePage{Namespace: s.namespace(r), Title: "Scale " + name, Active: active},
+		Kind:      kind,
+		Name:      name,
+		Requested: raw,
+		ScaleURL:  r.URL.RequestURI(),
+		BackURL:   backURL,
+
+		ProductionConfirm: r.PostFormValue(productionConfirmField),
+	}
+
+	replicas, errs := parseReplicas(raw, s.config.MaxReplicas)
+	if len(errs) > 0 {
+		page.Errors = errs
+		w.WriteHeader(http.StatusBadRequest)
+		s.renderTemplate(w, "scale_rejected.html", page)
+		return 0, false
+	}
+
+	warnings, err := s.scaleWarnings(r.Context(), s.namespace(r), kind, name, paused, replicas)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if replicas > current {
+		issues, err := s.checkQuota(r.Context(), s.namespace(r), scaleUsage(podUsage(pod), int64(replicas-current)))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return 0, false
+		}
+		for i := range issues {
+			issues[i].Message += fmt.Sprintf(" Pods over the quota are refused when the controller creates them, so the %s would stay below %d replicas.", kind, replicas)
+		}
+		if len(issues) > 0 && s.config.QuotaCheck == QuotaCheckBlock {
+			page.Errors = issues
+			w.WriteHeader(http.StatusForbidden)
+			s.renderTemplate(w, "scale_rejected.html", page)
+			return 0, false
+		}
+		warnings = append(warnings, issues...)
+	}
+	if len(warnings) > 0 && r.FormValue("force") != "1" {
+		page.Warnings = warnings
+		w.WriteHeader(http.StatusConflict)
+		s.renderTemplate(w, "scale_rejected.html", page)
+		return 0, false
+	}
+
+	return replicas, true
+}
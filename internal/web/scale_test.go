@@ -0,0 +1,34 @@
+package web
+
+import "testing"
+
+func TestParseReplicas(t *testing.T) {
+	tests := []struct {
+		raw     string
+		max     int32
+		want    int32
+		wantErr bool
+	}{
+		{raw: "3", want: 3},
+		{raw: " 0 ", want: 0},
+		{raw: "10", max: 10, want: 10},
+		{raw: "11", max: 10, wantErr: true},
+		{raw: "-1", wantErr: true},
+		{raw: "", wantErr: true},
+		{raw: "two", wantErr: true},
+		{raw: "99999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, issues := parseReplicas(tt.raw, tt.max)
+		if tt.wantErr {
+			if len(issues) == 0 {
+				t.Errorf("parseReplicas(%q, %d) = %d, want error", tt.raw, tt.max, got)
+			}
+			continue
+		}
+		if len(issues) > 0 || got != tt.want {
+			t.Errorf("parseReplicas(%q, %d) = %d, %v, want %d", tt.raw, tt.max, got, issues, tt.want)
+		}
+	}
+}
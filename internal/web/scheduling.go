@@ -0,0 +1,261 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	"k8s.io/klog/v2"
+)
+
+// SchedulingConflict explains why a pending pod cannot be placed on any of
+// the current nodes.
+type SchedulingConflict struct {
+	Constraint string
+	Message    string
+}
+
+// schedulingConflicts finds the required pod anti-affinity terms and
+// DoNotSchedule topology spread constraints that no current node can
+// satisfy, given the scheduled pods of the pod's namespace. Unlike the
+// scheduler's "0/5 nodes are available" event it names the rule and the
+// domains involved. When no node accepts the pod, it says which operating
+// system or architecture rule no node matches. Terms that select pods in
+// other namespaces are not checked.
+func schedulingConflicts(pod *corev1.Pod, nodes []corev1.Node, pods []corev1.Pod) []SchedulingConflict {
+	nodeByName := make(map[string]*corev1.Node, len(nodes))
+	var fits []*corev1.Node
+	for i := range nodes {
+		n := &nodes[i]
+		nodeByName[n.Name] = n
+		if nodeAcceptsPod(pod, n, true, true) {
+			fits = append(fits, n)
+		}
+	}
+	if len(nodes) > 0 && len(fits) == 0 {
+		return append(platformConflicts(pod, nodes), SchedulingConflict{
+			Constraint: "Node selection",
+			Message: fmt.Sprintf("None of the %d nodes accepts the pod: each is cordoned, has a taint the pod does not tolerate, or is excluded by its nodeSelector or required node affinity.",
+				len(nodes)),
+		})
+	}
+
+	var scheduled []*corev1.Pod
+	for i := range pods {
+		p := &pods[i]
+		if p.UID != pod.UID && p.Spec.NodeName != "" && p.Status.Phase != corev1.PodSucceeded && p.Status.Phase != corev1.PodFailed {
+			scheduled = append(scheduled, p)
+		}
+	}
+
+	var conflicts []SchedulingConflict
+	if a := pod.Spec.Affinity; a != nil && a.PodAntiAffinity != nil {
+		for _, term := range a.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			if c, ok := antiAffinityConflict(pod, term, fits, nodeByName, scheduled); ok {
+				conflicts = append(conflicts, c)
+			}
+		}
+	}
+	for _, tsc := range pod.Spec.TopologySpreadConstraints {
+		if tsc.WhenUnsatisfiable != corev1.DoNotSchedule {
+			continue
+		}
+		if c, ok := spreadConflict(pod, tsc, nodes, nodeByName, scheduled); ok {
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts
+}
+
+// podSchedulingConflicts runs schedulingConflicts against the current
+// nodes and pods, and checks the pod's taint and node affinity rules
+// against each node. It is a hint on the pod page, so without permission to
+// list nodes it reports nothing.
+func (s *Server) podSchedulingConflicts(ctx context.Context, pod *corev1.Pod) ([]SchedulingConflict, []NodeFitSummary) {
+	nodes, err := s.manager.Client().CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil
+	}
+	fits := nodeFitSummaries(pod, nodes.Items)
+	pods, err := s.manager.Client().CoreV1().Pods(pod.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fits
+	}
+	return schedulingConflicts(pod, nodes.Items, pods.Items), fits
+}
+
+// nodeAcceptsPod reports whether node passes the filters other than the
+// inter-pod rules: cordoning and taints when withTaints is set, and the
+// pod's nodeSelector and required node affinity when withAffinity is set.
+func nodeAcceptsPod(pod *corev1.Pod, node *corev1.Node, withAffinity, withTaints bool) bool {
+	if withTaints {
+		taints := node.Spec.Taints
+		if node.Spec.Unschedulable {
+			taints = append(slices.Clone(taints), corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule})
+		}
+		_, untolerated := corev1helpers.FindMatchingUntoleratedTaint(klog.Background(), taints, pod.Spec.Tolerations, func(t *corev1.Taint) bool {
+			return t.Effect == corev1.TaintEffectNoSchedule || t.Effect == corev1.TaintEffectNoExecute
+		}, false)
+		if untolerated {
+			return false
+		}
+	}
+	if withAffinity {
+		if ok, err := nodeaffinity.GetRequiredNodeAffinity(pod).Match(node); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// podTermSelector combines a label selector with the values of the pod's
+// own labels named by matchLabelKeys.
+func podTermSelector(pod *corev1.Pod, ls *metav1.LabelSelector, matchLabelKeys []string) (labels.Selector, error) {
+	sel, err := metav1.LabelSelectorAsSelector(ls)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range matchLabelKeys {
+		if v, ok := pod.Labels[key]; ok {
+			req, err := labels.NewRequirement(key, selection.Equals, []string{v})
+			if err != nil {
+				return nil, err
+			}
+			sel = sel.Add(*req)
+		}
+	}
+	return sel, nil
+}
+
+func antiAffinityConflict(pod *corev1.Pod, term corev1.PodAffinityTerm, fits []*corev1.Node, nodeByName map[string]*corev1.Node, scheduled []*corev1.Pod) (SchedulingConflict, bool) {
+	if term.NamespaceSelector != nil || slices.ContainsFunc(term.Namespaces, func(ns string) bool { return ns != pod.Namespace }) {
+		return SchedulingConflict{}, false
+	}
+	sel, err := podTermSelector(pod, term.LabelSelector, term.MatchLabelKeys)
+	if err != nil {
+		return SchedulingConflict{}, false
+	}
+
+	occupied := make(map[string][]string) // domain -> matching pods
+	for _, p := range scheduled {
+		if !sel.Matches(labels.Set(p.Labels)) {
+			continue
+		}
+		if n := nodeByName[p.Spec.NodeName]; n != nil {
+			if domain, ok := n.Labels[term.TopologyKey]; ok {
+				occupied[domain] = append(occupied[domain], p.Name)
+			}
+		}
+	}
+	domains := make(map[string]bool)
+	for _, n := range fits {
+		domain, ok := n.Labels[term.TopologyKey]
+		if !ok || len(occupied[domain]) == 0 {
+			return SchedulingConflict{}, false
+		}
+		domains[domain] = true
+	}
+
+	var blocked, examples []string
+	for domain := range domains {
+		blocked = append(blocked, domain)
+	}
+	sort.Strings(blocked)
+	for _, domain := range blocked {
+		examples = append(examples, occupied[domain][0]+" in "+domain)
+	}
+	return SchedulingConflict{
+		Constraint: fmt.Sprintf("Required pod anti-affinity on %s (%s)", term.TopologyKey, sel),
+		Message: fmt.Sprintf("Each of the %d %s domains the pod could use already runs a matching pod: %s. The pod stays pending until a node in a new %s domain is added, one of these pods goes away, or the rule is made preferred.",
+			len(blocked), term.TopologyKey, limitList(examples, 5), term.TopologyKey),
+	}, true
+}
+
+func spreadConflict(pod *corev1.Pod, tsc corev1.TopologySpreadConstraint, nodes []corev1.Node, nodeByName map[string]*corev1.Node, scheduled []*corev1.Pod) (SchedulingConflict, bool) {
+	sel, err := podTermSelector(pod, tsc.LabelSelector, tsc.MatchLabelKeys)
+	if err != nil {
+		return SchedulingConflict{}, false
+	}
+	constraint := fmt.Sprintf("Topology spread on %s (maxSkew %d)", tsc.TopologyKey, tsc.MaxSkew)
+
+	// Domains come from the nodes the policies count, which by default
+	// includes tainted and cordoned nodes the pod cannot run on.
+	honorAffinity := tsc.NodeAffinityPolicy == nil || *tsc.NodeAffinityPolicy == corev1.NodeInclusionPolicyHonor
+	honorTaints := tsc.NodeTaintsPolicy != nil && *tsc.NodeTaintsPolicy == corev1.NodeInclusionPolicyHonor
+	counts := make(map[string]int32)
+	counted := make(map[string]bool)
+	for i := range nodes {
+		n := &nodes[i]
+		domain, ok := n.Labels[tsc.TopologyKey]
+		if ok && nodeAcceptsPod(pod, n, honorAffinity, honorTaints) {
+			counts[domain] = 0
+			counted[n.Name] = true
+		}
+	}
+	if len(counts) == 0 {
+		return SchedulingConflict{
+			Constraint: constraint,
+			Message:    fmt.Sprintf("No node the pod may use has the %s label, so no node satisfies the constraint.", tsc.TopologyKey),
+		}, true
+	}
+	for _, p := range scheduled {
+		if counted[p.Spec.NodeName] && sel.Matches(labels.Set(p.Labels)) {
+			counts[nodeByName[p.Spec.NodeName].Labels[tsc.TopologyKey]]++
+		}
+	}
+
+	minCount := int32(-1)
+	for _, c := range counts {
+		if minCount < 0 || c < minCount {
+			minCount = c
+		}
+	}
+	if tsc.MinDomains != nil && int32(len(counts)) < *tsc.MinDomains {
+		minCount = 0
+	}
+	var room []string // domains the pod could join without exceeding maxSkew
+	for domain, c := range counts {
+		if c+1-minCount <= tsc.MaxSkew {
+			room = append(room, domain)
+		}
+	}
+	for i := range nodes {
+		n := &nodes[i]
+		if domain, ok := n.Labels[tsc.TopologyKey]; ok && slices.Contains(room, domain) && nodeAcceptsPod(pod, n, true, true) {
+			return SchedulingConflict{}, false
+		}
+	}
+
+	var perDomain []string
+	for domain := range counts {
+		perDomain = append(perDomain, domain)
+	}
+	sort.Strings(perDomain)
+	for i, domain := range perDomain {
+		perDomain[i] = fmt.Sprintf("%s %d", domain, counts[domain])
+	}
+	sort.Strings(room)
+	msg := fmt.Sprintf("Matching pods per %s: %s. ", tsc.TopologyKey, limitList(perDomain, 8))
+	if len(room) == 0 {
+		msg += fmt.Sprintf("Only %d domains exist but minDomains is %d, so every domain is treated as over the skew.", len(counts), *tsc.MinDomains)
+	} else {
+		msg += fmt.Sprintf("The pod may only go to %s, and no node there accepts it: they are cordoned, tainted or excluded by its node affinity, yet still count as domains.", limitList(room, 5))
+	}
+	return SchedulingConflict{Constraint: constraint, Message: msg}, true
+}
+
+// limitList joins items, naming at most n of them.
+func limitList(items []string, n int) string {
+	if len(items) <= n {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(items[:n], ", "), len(items)-n)
+}
@@ -0,0 +1,137 @@
+package web
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+func schedNode(name, zone string, modify ...func(*corev1.Node)) corev1.Node {
+	n := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+		"kubernetes.io/hostname":      name,
+		"topology.kubernetes.io/zone": zone,
+	}}}
+	for _, m := range modify {
+		m(&n)
+	}
+	return n
+}
+
+func schedPod(name, node string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", UID: types.UID("uid-" + name), Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestSchedulingConflicts(t *testing.T) {
+	web := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	antiAffinity := &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{LabelSelector: web, TopologyKey: "kubernetes.io/hostname"}},
+	}}
+	zoneSpread := []corev1.TopologySpreadConstraint{{
+		MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule, LabelSelector: web,
+	}}
+	cordon := func(n *corev1.Node) { n.Spec.Unschedulable = true }
+	taint := func(n *corev1.Node) {
+		n.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+	}
+
+	tests := []struct {
+		name         string
+		affinity     *corev1.Affinity
+		spread       []corev1.TopologySpreadConstraint
+		nodes        []corev1.Node
+		pods         []corev1.Pod
+		want         []string // a substring of each conflict's constraint
+		wantInDetail string
+	}{
+		{
+			name:         "anti-affinity with every node taken",
+			affinity:     antiAffinity,
+			nodes:        []corev1.Node{schedNode("n1", "a"), schedNode("n2", "b")},
+			pods:         []corev1.Pod{schedPod("web-1", "n1"), schedPod("web-2", "n2")},
+			want:         []string{"anti-affinity on kubernetes.io/hostname"},
+			wantInDetail: "web-1 in n1, web-2 in n2",
+		},
+		{
+			name:     "anti-affinity with a free node",
+			affinity: antiAffinity,
+			nodes:    []corev1.Node{schedNode("n1", "a"), schedNode("n2", "b"), schedNode("n3", "c")},
+			pods:     []corev1.Pod{schedPod("web-1", "n1"), schedPod("web-2", "n2")},
+		},
+		{
+			name:         "anti-affinity where the free node is cordoned",
+			affinity:     antiAffinity,
+			nodes:        []corev1.Node{schedNode("n1", "a"), schedNode("n2", "b"), schedNode("n3", "c", cordon)},
+			pods:         []corev1.Pod{schedPod("web-1", "n1"), schedPod("web-2", "n2")},
+			want:         []string{"anti-affinity"},
+			wantInDetail: "Each of the 2",
+		},
+		{
+			name:         "spread blocked by a tainted zone",
+			spread:       zoneSpread,
+			nodes:        []corev1.Node{schedNode("n1", "a"), schedNode("n2", "b"), schedNode("n3", "c", taint)},
+			pods:         []corev1.Pod{schedPod("web-1", "n1"), schedPod("web-2", "n2")},
+			want:         []string{"Topology spread on topology.kubernetes.io/zone"},
+			wantInDetail: "may only go to c",
+		},
+		{
+			name:   "spread that fits",
+			spread: zoneSpread,
+			nodes:  []corev1.Node{schedNode("n1", "a"), schedNode("n2", "b"), schedNode("n3", "c")},
+			pods:   []corev1.Pod{schedPod("web-1", "n1"), schedPod("web-2", "n2")},
+		},
+		{
+			name: "spread with tainted nodes left out by the policy",
+			spread: []corev1.TopologySpreadConstraint{{
+				MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule, LabelSelector: web,
+				NodeTaintsPolicy: ptr.To(corev1.NodeInclusionPolicyHonor),
+			}},
+			nodes: []corev1.Node{schedNode("n1", "a"), schedNode("n2", "b"), schedNode("n3", "c", taint)},
+			pods:  []corev1.Pod{schedPod("web-1", "n1"), schedPod("web-2", "n2")},
+		},
+		{
+			name: "spread over a label no node has",
+			spread: []corev1.TopologySpreadConstraint{{
+				MaxSkew: 1, TopologyKey: "example.com/rack", WhenUnsatisfiable: corev1.DoNotSchedule, LabelSelector: web,
+			}},
+			nodes:        []corev1.Node{schedNode("n1", "a")},
+			want:         []string{"example.com/rack"},
+			wantInDetail: "has the example.com/rack label",
+		},
+		{
+			name:         "no node accepts the pod",
+			affinity:     antiAffinity,
+			nodes:        []corev1.Node{schedNode("n1", "a", taint), schedNode("n2", "b", cordon)},
+			want:         []string{"Node selection"},
+			wantInDetail: "None of the 2 nodes",
+		},
+	}
+
+	for _, tt := range tests {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-3", Namespace: "shop", UID: "uid-web-3", Labels: map[string]string{"app": "web"}},
+			Spec:       corev1.PodSpec{Affinity: tt.affinity, TopologySpreadConstraints: tt.spread},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		}
+		got := schedulingConflicts(pod, tt.nodes, tt.pods)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %+v, want %d conflicts", tt.name, got, len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if !strings.Contains(got[i].Constraint, want) {
+				t.Errorf("%s: conflict %d = %q, want it to mention %q", tt.name, i, got[i].Constraint, want)
+			}
+		}
+		if tt.wantInDetail != "" && !strings.Contains(got[0].Message, tt.wantInDetail) {
+			t.Errorf("%s: message %q, want it to mention %q", tt.name, got[0].Message, tt.wantInDetail)
+		}
+	}
+}
@@ -1,23 +1,139 @@
 package web
 
 import (
+	"context"
 	"embed"
+	"fmt"
 	"html/template"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/rakeshavasarala/k8s-ui/internal/kube"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 //go:embed templates/*.html
 var templateFS embed.FS
 
+// Config holds operator-tunable settings for the web server.
+type Config struct {
+	// MaxReplicas caps the replica count accepted by scale actions.
+	// Zero means no cap.
+	MaxReplicas int32
+
+	// ExecIdleTimeout closes exec terminals that receive no input for
+	// this long. Zero means terminals are never closed for idleness.
+	ExecIdleTimeout time.Duration
+
+	// MaxExecSessions caps the number of exec terminals open at once
+	// across all users. Zero means no cap.
+	MaxExecSessions int
+
+	// ClusterHealth enables the Cluster Health page, which reads the core
+	// components in kube-system, for admins whose credentials may.
+	ClusterHealth bool
+
+	// SessionMaxAge closes exec terminals and followed streams that have
+	// been open for this long, however active. Zero uses the default of
+	// 12 hours.
+	SessionMaxAge time.Duration
+
+	// SessionsPage enables the Server Sessions page, which lists the
+	// terminals, streams and downloads of all users and can end them. The
+	// UI has no login, so it is meant for deployments used by admins only.
+	SessionsPage bool
+
+	// ProductionContexts and ProductionNamespaces mark scopes in which
+	// mutating actions need the namespace name typed as confirmation.
+	ProductionContexts   []string
+	ProductionNamespaces []string
+
+	// ReplicationControllers enables the ReplicationController list and
+	// YAML views for clusters that still run legacy workloads.
+	ReplicationControllers bool
+
+	// EventHistory keeps events seen by the server for this long, beyond
+	// the API server's own event TTL. Zero disables the history.
+	EventHistory time.Duration
+
+	// EventHistoryFile saves the event history so it survives restarts.
+	// Empty keeps it in memory only.
+	EventHistoryFile string
+
+	// QuotaCheck is what scale and create actions do when they would
+	// exceed a namespace ResourceQuota: QuotaCheckWarn (the default when
+	// empty) asks for confirmation, QuotaCheckBlock refuses the action and
+	// QuotaCheckOff skips the check.
+	QuotaCheck string
+
+	// LintRules are checked on manifests saved from the YAML editors and
+	// on dry runs. Empty checks nothing.
+	LintRules []LintRule
+
+	// LogMaxLines and LogMaxBytes cap the log shown on a pod's log page;
+	// older lines are cut and the full log stays available as a download.
+	// Zero uses the defaults of 10000 lines and 5 MiB.
+	LogMaxLines int64
+	LogMaxBytes int64
+
+	// LogLimits set per namespace how many lines and how far back log
+	// requests read by default and at most. Empty leaves it to the request.
+	LogLimits []LogLimit
+
+	// APITokens enable the JSON API under /api/v1 for the holders of
+	// these tokens. Without tokens the API is not served.
+	APITokens []APIToken
+
+	// SlackSigningSecret enables the Slack slash command endpoint at
+	// /api/slack/command; requests must be signed with it. Only the Slack
+	// user IDs in SlackRestartUsers may restart workloads, everyone else
+	// can only ask for status.
+	SlackSigningSecret string
+	SlackRestartUsers  []string
+
+	// DeleteUndoWindow is how long deleted Deployments, Services and
+	// ConfigMaps can be restored from the recently deleted page. Zero uses
+	// the default of 10 minutes.
+	DeleteUndoWindow time.Duration
+
+	// LandingPage is the page / redirects to. Empty uses /pods.
+	LandingPage string
+
+	// NavSections sets the order of the top navigation sections and hides
+	// those not listed; see NavSections for the names. Empty shows all of
+	// them in the default order. Hidden pages stay reachable by URL.
+	NavSections []string
+}
+
 type Server struct {
-	manager    *kube.Manager
-	mux        *http.ServeMux
-	layoutTmpl *template.Template
+	manager      *kube.Manager
+	config       Config
+	mux          *http.ServeMux
+	layoutTmpl   *template.Template
+	execSessions execSessions
+	restarts     restartRuns
+	deleted      deletedObjects
+	auditLog     auditLog
+	access       accessChecks
+	namespaces   namespaceChecks
+	artifacts    artifactRegistry
+	history      *kube.EventHistory
+	metrics      serverMetrics
+
+	// background is the context of the work the server runs besides
+	// serving requests, cancelled by Close, which waits for running until
+	// it has stopped.
+	background context.Context
+	stop       context.CancelFunc
+	running    sync.WaitGroup
+	httpServer *http.Server
 }
 
-func NewServer(m *kube.Manager) (*Server, error) {
+func NewServer(m *kube.Manager, cfg Config) (*Server, error) {
 	// Parse only the layout template initially
 	tmpl, err := template.New("layout.html").Funcs(FuncMap()).ParseFS(templateFS, "templates/layout.html")
 	if err != nil {
@@ -26,59 +142,147 @@ func NewServer(m *kube.Manager) (*Server, error) {
 
 	s := &Server{
 		manager:    m,
+		config:     cfg,
 		mux:        http.NewServeMux(),
 		layoutTmpl: tmpl,
 	}
+	s.background, s.stop = context.WithCancel(context.Background())
+
+	if cfg.EventHistory > 0 {
+		s.history, err = kube.NewEventHistory(m, cfg.EventHistory, cfg.EventHistoryFile)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	s.registerRoutes()
+	s.httpServer = &http.Server{Handler: s.Handler()}
 
 	return s, nil
 }
 
+// handleSwitchContext switches to the posted context once its API server
+// has answered. Errors are returned as JSON, for the inline message of the
+// context selector; on success the request is answered by switched.
 func (s *Server) handleSwitchContext(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	ctx := r.FormValue("context")
 	if ctx == "" {
-		http.Error(w, "Context is required", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, "context is required")
 		return
 	}
-
-	err := s.manager.SwitchContext(ctx)
-	if err != nil {
-		http.Error(w, "Failed to switch context: "+err.Error(), http.StatusInternalServerError)
+	if !s.manager.IsLocal() {
+		writeAPIError(w, http.StatusBadRequest, "the context cannot be switched in in-cluster mode")
+		return
+	}
+	if contexts, _ := s.manager.Contexts(); !slices.Contains(contexts, ctx) {
+		writeAPIError(w, http.StatusNotFound, fmt.Sprintf("context %s is not in the kubeconfig", ctx))
 		return
 	}
 
-	// Redirect back to referer or root
-	http.Redirect(w, r, "/", http.StatusFound)
+	if err := s.manager.SwitchContext(r.Context(), ctx); err != nil {
+		writeAPIError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	s.switched(w, r, true)
 }
 
+// handleSwitchNamespace switches to the posted namespace once it is known
+// to exist or to be usable, with errors returned as JSON like
+// handleSwitchContext.
 func (s *Server) handleSwitchNamespace(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	ns := r.FormValue("namespace")
+	ns := strings.TrimSpace(r.FormValue("namespace"))
 	if ns == "" {
-		http.Error(w, "Namespace is required", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, "namespace is required")
+		return
+	}
+	if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid namespace %q: %s", ns, strings.Join(errs, "; ")))
 		return
 	}
 	if !s.manager.IsNamespaceAllowed(ns) {
-		http.Error(w, "Namespace not allowed by POD_NAMESPACES", http.StatusForbidden)
+		writeAPIError(w, http.StatusForbidden, fmt.Sprintf("namespace %s is not in the POD_NAMESPACES allowlist", ns))
+		return
+	}
+	if status, err := s.checkNamespace(r.Context(), ns); err != nil {
+		writeAPIError(w, status, err.Error())
 		return
 	}
 
 	s.manager.SetNamespace(ns)
+	s.switched(w, r, false)
+}
+
+// switched answers a successful switch by going back to the page it was
+// made from, as far as the new scope has it; see switchReturnTo. A request
+// that accepts JSON gets the current context and namespace and that page
+// as return_to instead of a redirect.
+func (s *Server) switched(w http.ResponseWriter, r *http.Request, contextChanged bool) {
+	target := switchReturnTo(r, s.manager.Namespace(), contextChanged)
+	if !strings.Contains(r.Header.Get("Accept"), "application/json") {
+		http.Redirect(w, r, target, http.StatusFound)
+		return
+	}
+	_, current := s.manager.Contexts()
+	writeAPIJSON(w, http.StatusOK, map[string]string{"context": current, "namespace": s.manager.Namespace(), returnToField: target})
+}
 
-	// Redirect back to referer or root
-	http.Redirect(w, r, "/", http.StatusFound)
+// Handler returns the server's root HTTP handler.
+func (s *Server) Handler() http.Handler {
+	return s.withTracing(s.withMetrics(s.withAPISession(s.withNamespaceOverride(s.withProductionGuard(s.mux)))))
 }
 
 func (s *Server) ListenAndServe(addr string) error {
-	return http.ListenAndServe(addr, s.mux)
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve serves the UI on l, such as a Unix domain socket listener. After
+// Shutdown it returns http.ErrServerClosed.
+func (s *Server) Serve(l net.Listener) error {
+	if s.history != nil {
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			s.history.Run(s.background)
+		}()
+	}
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.runReaper(s.background)
+	}()
+	return s.httpServer.Serve(l)
+}
+
+// Shutdown stops serving: it closes the listeners, waits until the
+// requests in progress are done, cutting off those still open when ctx
+// ends, and then stops the background work like Close.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		s.httpServer.Close()
+	}
+	s.Close()
+	return err
+}
+
+// Close stops the work the server started besides serving, such as the
+// event history and namespace restarts, and waits until it is done, so the
+// history is saved and restarts record their outcome before exiting.
+func (s *Server) Close() {
+	s.stop()
+	s.running.Wait()
 }
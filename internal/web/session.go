@@ -0,0 +1,63 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+
+	"github.com/rakeshavasarala/k8s-ui/internal/kube"
+)
+
+// sessionCookie names the browser session that the Kubernetes API requests
+// made for a page are queued under when API fairness is on. It identifies
+// a browser for fair sharing only and grants nothing.
+const sessionCookie = "k8s_ui_session"
+
+func validSessionID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// withAPISession tags the Kubernetes API requests made while serving a
+// request with the browser session it comes from. Requests without a
+// session cookie, such as the first one of a browser or those of scripts
+// that ignore cookies, are tagged with the address they come from, so
+// they share one session instead of each getting its own; browsers are
+// given a cookie for their next requests. Requests with an API token are
+// tagged with the token instead, by withAPIToken.
+func (s *Server) withAPISession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.manager == nil || s.manager.FairQueue() == nil || r.Header.Get("Authorization") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if c, err := r.Cookie(sessionCookie); err == nil && validSessionID(c.Value) {
+			next.ServeHTTP(w, r.WithContext(kube.WithSession(r.Context(), "browser "+c.Value)))
+			return
+		}
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err == nil {
+			http.SetCookie(w, &http.Cookie{
+				Name:     sessionCookie,
+				Value:    hex.EncodeToString(b),
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		next.ServeHTTP(w, r.WithContext(kube.WithSession(r.Context(), "address "+remoteHost(r))))
+	})
+}
+
+// remoteHost returns the host part of the address a request comes from.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,329 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// defaultDeleteUndoWindow is how long a deleted object can be restored
+// when Config.DeleteUndoWindow is not set.
+const defaultDeleteUndoWindow = 10 * time.Minute
+
+// deletedObject is a snapshot of an object taken just before it was
+// deleted, stripped of the fields the API server assigns, so that it can
+// be created again.
+type deletedObject struct {
+	ID        string
+	Context   string
+	Namespace string
+	Kind      string // resource path, such as "deployments"
+	Name      string
+	DeletedAt time.Time
+	object    runtime.Object
+}
+
+// deletedObjects keeps the snapshots of recently deleted objects in memory
+// until their undo window has passed.
+type deletedObjects struct {
+	mu      sync.Mutex
+	objects map[string]*deletedObject
+}
+
+func (d *deletedObjects) add(obj *deletedObject) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.objects == nil {
+		d.objects = make(map[string]*deletedObject)
+	}
+	d.objects[obj.ID] = obj
+}
+
+// take removes and returns the snapshot with the given ID if it belongs to
+// the context and namespace and is still within its undo window.
+func (d *deletedObjects) take(id, kubeContext, namespace string, window time.Duration, now time.Time) *deletedObject {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pruneLocked(window, now)
+	obj := d.objects[id]
+	if obj == nil || obj.Context != kubeContext || obj.Namespace != namespace {
+		return nil
+	}
+	delete(d.objects, id)
+	return obj
+}
+
+// list returns the snapshots of a context and namespace, newest first.
+func (d *deletedObjects) list(kubeContext, namespace string, window time.Duration, now time.Time) []*deletedObject {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pruneLocked(window, now)
+	var out []*deletedObject
+	for _, obj := range d.objects {
+		if obj.Context == kubeContext && obj.Namespace == namespace {
+			out = append(out, obj)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DeletedAt.After(out[j].DeletedAt) })
+	return out
+}
+
+func (d *deletedObjects) pruneLocked(window time.Duration, now time.Time) {
+	for id, obj := range d.objects {
+		if now.Sub(obj.DeletedAt) > window {
+			delete(d.objects, id)
+		}
+	}
+}
+
+func (s *Server) deleteUndoWindow() time.Duration {
+	if s.config.DeleteUndoWindow > 0 {
+		return s.config.DeleteUndoWindow
+	}
+	return defaultDeleteUndoWindow
+}
+
+// restorableMeta clears the metadata the API server assigns, which a
+// create request must not carry.
+func restorableMeta(meta *metav1.ObjectMeta) {
+	meta.UID = ""
+	meta.ResourceVersion = ""
+	meta.Generation = 0
+	meta.CreationTimestamp = metav1.Time{}
+	meta.DeletionTimestamp = nil
+	meta.DeletionGracePeriodSeconds = nil
+	meta.ManagedFields = nil
+}
+
+// restorableService prepares a Service for re-creation. Its cluster IP is
+// released on delete and may have been handed out again since, so a new
+// one is allocated; headless Services stay headless. Node ports are kept,
+// as clients outside the cluster depend on them.
+func restorableService(svc *corev1.Service) {
+	restorableMeta(&svc.ObjectMeta)
+	if svc.Spec.ClusterIP != corev1.ClusterIPNone {
+		svc.Spec.ClusterIP = ""
+		svc.Spec.ClusterIPs = nil
+	}
+	svc.Status = corev1.ServiceStatus{}
+}
+
+// snapshotForDelete reads the object about to be deleted and returns a
+// restorable copy along with its UID, which the delete is made conditional
+// on so that the snapshot is of the object actually deleted.
+func (s *Server) snapshotForDelete(ctx context.Context, namespace, kind, name string) (runtime.Object, types.UID, error) {
+	client := s.manager.Client()
+	switch kind {
+	case "deployments":
+		d, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", err
+		}
+		uid := d.UID
+		restorableMeta(&d.ObjectMeta)
+		d.Status = appsv1.DeploymentStatus{}
+		return d, uid, nil
+	case "services":
+		svc, err := client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", err
+		}
+		uid := svc.UID
+		restorableService(svc)
+		return svc, uid, nil
+	case "configmaps":
+		cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, "", err
+		}
+		uid := cm.UID
+		restorableMeta(&cm.ObjectMeta)
+		return cm, uid, nil
+	}
+	return nil, "", fmt.Errorf("%s cannot be deleted with undo", kind)
+}
+
+func (s *Server) deleteObject(ctx context.Context, namespace, kind, name string, uid types.UID) error {
+	client := s.manager.Client()
+	propagation := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{Preconditions: metav1.NewUIDPreconditions(string(uid)), PropagationPolicy: &propagation}
+	switch kind {
+	case "deployments":
+		return client.AppsV1().Deployments(namespace).Delete(ctx, name, opts)
+	case "services":
+		return client.CoreV1().Services(namespace).Delete(ctx, name, opts)
+	case "configmaps":
+		return client.CoreV1().ConfigMaps(namespace).Delete(ctx, name, opts)
+	}
+	return fmt.Errorf("%s cannot be deleted with undo", kind)
+}
+
+func (s *Server) restoreObject(ctx context.Context, obj *deletedObject) error {
+	client := s.manager.Client()
+	var err error
+	switch o := obj.object.(type) {
+	case *appsv1.Deployment:
+		_, err = client.AppsV1().Deployments(obj.Namespace).Create(ctx, o.DeepCopy(), metav1.CreateOptions{})
+	case *corev1.Service:
+		_, err = client.CoreV1().Services(obj.Namespace).Create(ctx, o.DeepCopy(), metav1.CreateOptions{})
+	case *corev1.ConfigMap:
+		_, err = client.CoreV1().ConfigMaps(obj.Namespace).Create(ctx, o.DeepCopy(), metav1.CreateOptions{})
+	default:
+		err = fmt.Errorf("cannot restore %s", obj.Kind)
+	}
+	return err
+}
+
+// handleSoftDelete deletes a Deployment, Service or ConfigMap at
+// /{kind}/{name}/delete after keeping a snapshot of it, and sends the user
+// to the recently deleted page, from which it can be restored until the
+// undo window has passed.
+func (s *Server) handleSoftDelete(w http.ResponseWriter, r *http.Request, kind string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"+kind+"/"), "/delete")
+	if name == "" || strings.Contains(name, "/") {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+	namespace := s.namespace(r)
+	backURL := "/" + kind
+
+	snapshot, uid, err := s.snapshotForDelete(r.Context(), namespace, kind, name)
+	if err == nil {
+		err = s.deleteObject(r.Context(), namespace, kind, name, uid)
+	}
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "delete", kind, name, backURL, kind) {
+			return
+		}
+		if apierrors.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, current := s.manager.Contexts()
+	s.deleted.add(&deletedObject{
+		ID:        hex.EncodeToString(id),
+		Context:   current,
+		Namespace: namespace,
+		Kind:      kind,
+		Name:      name,
+		DeletedAt: time.Now(),
+		object:    snapshot,
+	})
+	log.Printf("Deleted %s %s/%s in context %s; undo possible for %s", kind, namespace, name, current, s.deleteUndoWindow())
+	s.audit(requestActor(r), namespace, strings.TrimSuffix(kind, "s"), name, "deleted")
+	http.Redirect(w, r, keepNamespace(r, "/deleted"), http.StatusSeeOther)
+}
+
+// DeletedObjectView is a recently deleted object that can still be restored.
+type DeletedObjectView struct {
+	ID        string
+	Kind      string
+	Name      string
+	DeletedAt string
+	ExpiresIn string
+}
+
+type DeletedPage struct {
+	BasePage
+	Objects []DeletedObjectView
+	Window  string
+	Error   string
+}
+
+// handleDeleted lists the objects deleted in the namespace that can still
+// be restored.
+func (s *Server) handleDeleted(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.renderDeleted(w, r, "")
+}
+
+func (s *Server) renderDeleted(w http.ResponseWriter, r *http.Request, errMsg string) {
+	namespace := s.namespace(r)
+	_, current := s.manager.Contexts()
+	window := s.deleteUndoWindow()
+	data := DeletedPage{
+		BasePage: BasePage{Namespace: namespace, Title: "Recently Deleted", Active: "resources"},
+		Window:   formatDuration(window),
+		Error:    errMsg,
+	}
+	now := time.Now()
+	for _, obj := range s.deleted.list(current, namespace, window, now) {
+		data.Objects = append(data.Objects, DeletedObjectView{
+			ID:        obj.ID,
+			Kind:      obj.Kind,
+			Name:      obj.Name,
+			DeletedAt: formatAge(obj.DeletedAt),
+			ExpiresIn: formatDuration(window - now.Sub(obj.DeletedAt)),
+		})
+	}
+	s.renderTemplate(w, "deleted.html", data)
+}
+
+// handleDeletedUndo creates an object again from its snapshot at
+// /deleted/{id}/undo.
+func (s *Server) handleDeletedUndo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := apiName(r, "/deleted/", "/undo")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	namespace := s.namespace(r)
+	_, current := s.manager.Contexts()
+	obj := s.deleted.take(id, current, namespace, s.deleteUndoWindow(), time.Now())
+	if obj == nil {
+		http.Error(w, "The deleted object is no longer available to restore", http.StatusNotFound)
+		return
+	}
+	if err := s.restoreObject(r.Context(), obj); err != nil {
+		// Keep the snapshot so the user can retry, for example after
+		// removing an object created under the same name since.
+		s.deleted.add(obj)
+		if s.handleK8sForbidden(w, r, err, "create", obj.Kind, obj.Name, "/deleted", "resources") {
+			return
+		}
+		s.renderDeleted(w, r, fmt.Sprintf("Could not restore %s %s: %v", obj.Kind, obj.Name, err))
+		return
+	}
+
+	log.Printf("Restored %s %s/%s in context %s", obj.Kind, namespace, obj.Name, current)
+	s.audit(requestActor(r), namespace, strings.TrimSuffix(obj.Kind, "s"), obj.Name, "restored from Recently Deleted")
+	url := "/" + obj.Kind
+	if obj.Kind != "configmaps" {
+		url += "/" + obj.Name
+	}
+	http.Redirect(w, r, keepNamespace(r, url), http.StatusSeeOther)
+}
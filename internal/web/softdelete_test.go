@@ -0,0 +1,72 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeletedObjects(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	window := 10 * time.Minute
+	var d deletedObjects
+	d.add(&deletedObject{ID: "old", Context: "prod", Namespace: "web", Name: "a", DeletedAt: now.Add(-11 * time.Minute)})
+	d.add(&deletedObject{ID: "first", Context: "prod", Namespace: "web", Name: "b", DeletedAt: now.Add(-5 * time.Minute)})
+	d.add(&deletedObject{ID: "second", Context: "prod", Namespace: "web", Name: "c", DeletedAt: now.Add(-time.Minute)})
+	d.add(&deletedObject{ID: "other", Context: "dev", Namespace: "web", Name: "d", DeletedAt: now})
+
+	got := d.list("prod", "web", window, now)
+	if len(got) != 2 || got[0].ID != "second" || got[1].ID != "first" {
+		t.Fatalf("list = %v, want second and first", got)
+	}
+	if obj := d.take("old", "prod", "web", window, now); obj != nil {
+		t.Errorf("take returned an expired snapshot")
+	}
+	if obj := d.take("other", "prod", "web", window, now); obj != nil {
+		t.Errorf("take returned a snapshot of another context")
+	}
+	if obj := d.take("first", "prod", "web", window, now); obj == nil || obj.Name != "b" {
+		t.Fatalf("take(first) = %v, want b", obj)
+	}
+	if obj := d.take("first", "prod", "web", window, now); obj != nil {
+		t.Errorf("take returned the same snapshot twice")
+	}
+	if got := d.list("dev", "web", window, now); len(got) != 1 {
+		t.Errorf("list(dev) = %v, want one snapshot", got)
+	}
+}
+
+func TestRestorableService(t *testing.T) {
+	tests := []struct {
+		name      string
+		clusterIP string
+		want      string
+	}{
+		{"allocated IP is released", "10.0.0.12", ""},
+		{"headless stays headless", corev1.ClusterIPNone, corev1.ClusterIPNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", UID: "uid", ResourceVersion: "12", CreationTimestamp: metav1.Now()},
+				Spec: corev1.ServiceSpec{
+					ClusterIP:  tt.clusterIP,
+					ClusterIPs: []string{tt.clusterIP},
+					Ports:      []corev1.ServicePort{{Port: 80, NodePort: 30080}},
+				},
+			}
+			restorableService(svc)
+			if svc.Spec.ClusterIP != tt.want {
+				t.Errorf("ClusterIP = %q, want %q", svc.Spec.ClusterIP, tt.want)
+			}
+			if svc.UID != "" || svc.ResourceVersion != "" || !svc.CreationTimestamp.IsZero() {
+				t.Errorf("server-assigned metadata kept: %+v", svc.ObjectMeta)
+			}
+			if svc.Spec.Ports[0].NodePort != 30080 {
+				t.Errorf("NodePort = %d, want 30080", svc.Spec.Ports[0].NodePort)
+			}
+		})
+	}
+}
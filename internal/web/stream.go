@@ -0,0 +1,114 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// streamWriteTimeout bounds each write to a streaming response, so a client
+// that stops reading without closing the connection does not hold the
+// handler and its upstream API stream open.
+const streamWriteTimeout = 30 * time.Second
+
+// responseStream writes a long-running response, such as followed logs,
+// flushing as it goes. Headers ask proxies not to buffer or compress the
+// body, which would hold back output until a buffer fills.
+type responseStream struct {
+	ctx          context.Context
+	w            io.Writer
+	flush        func() error
+	setDeadline  func(time.Time) error
+	writeTimeout time.Duration
+}
+
+// newResponseStream starts a streaming response with the given content
+// type. The headers are sent at once, so the client sees the response start
+// even when no data follows for a while. It fails, without writing
+// anything, when the connection cannot be flushed.
+func newResponseStream(w http.ResponseWriter, r *http.Request, contentType string) (*responseStream, error) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache, no-transform")
+	w.Header().Set("X-Accel-Buffering", "no")
+	if err := rc.Flush(); err != nil {
+		return nil, err
+	}
+	return &responseStream{
+		ctx:          r.Context(),
+		w:            w,
+		flush:        rc.Flush,
+		setDeadline:  rc.SetWriteDeadline,
+		writeTimeout: streamWriteTimeout,
+	}, nil
+}
+
+// Done is closed when the client disconnects.
+func (st *responseStream) Done() <-chan struct{} {
+	return st.ctx.Done()
+}
+
+// Write writes p and flushes it to the client.
+func (st *responseStream) Write(p []byte) (int, error) {
+	if st.setDeadline != nil && st.writeTimeout > 0 {
+		// Connections that cannot set deadlines, such as in tests, just
+		// write without one.
+		if err := st.setDeadline(time.Now().Add(st.writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return 0, err
+		}
+	}
+	n, err := st.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if err := st.flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return n, err
+	}
+	return n, nil
+}
+
+// CopyLines writes src to the client a line at a time until src ends, the
+// client disconnects or a write fails. Lines are read in a goroutine so a
+// closed tab is noticed at once rather than at the next line, which may
+// never come for a quiet source. The caller closes src, which ends the
+// reader. The error is nil at end of src or when the client has gone.
+func (st *responseStream) CopyLines(src io.Reader) error {
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(src)
+		for {
+			line, err := reader.ReadString('\n')
+			if line != "" {
+				select {
+				case lines <- line:
+				case <-st.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-st.Done():
+			return nil
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case line := <-lines:
+			if _, err := io.WriteString(st, line); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseStreamCopyLines(t *testing.T) {
+	rec := httptest.NewRecorder()
+	st, err := newResponseStream(rec, httptest.NewRequest("GET", "/pods/web/logs?follow=1", nil), "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Flushed || rec.Header().Get("Cache-Control") != "no-cache, no-transform" {
+		t.Errorf("headers not flushed at start: flushed %v, Cache-Control %q", rec.Flushed, rec.Header().Get("Cache-Control"))
+	}
+	if err := st.CopyLines(strings.NewReader("one\ntwo\npartial")); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != "one\ntwo\npartial" {
+		t.Errorf("body = %q, want all three lines", got)
+	}
+
+	// A quiet source must not hold the handler once the client has gone.
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/pods/web/logs?follow=1", nil).WithContext(ctx)
+	st, err = newResponseStream(httptest.NewRecorder(), r, "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- st.CopyLines(pr) }()
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("CopyLines after disconnect = %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("CopyLines did not return after the client disconnected")
+	}
+}
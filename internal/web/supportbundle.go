@@ -0,0 +1,226 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// supportBundleTailLines is how many of the newest lines of each log a
+// support bundle includes.
+const supportBundleTailLines int64 = 1000
+
+// bundleManifestName is the file of an object in the manifests folder of a
+// support bundle, such as "replicaset-web-7d9f.yaml".
+func bundleManifestName(obj runtime.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	name := ""
+	if o, ok := obj.(metav1.Object); ok {
+		name = o.GetName()
+	}
+	return "manifests/" + strings.ToLower(kind) + "-" + name + ".yaml"
+}
+
+// bundleEvents renders the events of the objects with the given UIDs as a
+// table, oldest first.
+func bundleEvents(events []corev1.Event, uids map[string]bool) []byte {
+	var matched []corev1.Event
+	for _, e := range events {
+		if uids[string(e.InvolvedObject.UID)] {
+			matched = append(matched, e)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return eventTime(matched[i]).Before(eventTime(matched[j])) })
+
+	var b bytes.Buffer
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LAST SEEN\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE")
+	for _, e := range matched {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s/%s\t%d\t%s\n",
+			eventTime(e).UTC().Format(time.RFC3339), e.Type, e.Reason,
+			strings.ToLower(e.InvolvedObject.Kind), e.InvolvedObject.Name, eventCount(e),
+			strings.Join(strings.Fields(e.Message), " "))
+	}
+	tw.Flush()
+	return b.Bytes()
+}
+
+// bundleNode describes a node for a support bundle: its platform, versions,
+// cordoning, taints and conditions.
+func bundleNode(node *corev1.Node) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Node:          %s\n", node.Name)
+	fmt.Fprintf(&b, "Platform:      %s\n", nodePlatform(node))
+	fmt.Fprintf(&b, "Kubelet:       %s\n", node.Status.NodeInfo.KubeletVersion)
+	fmt.Fprintf(&b, "Runtime:       %s\n", node.Status.NodeInfo.ContainerRuntimeVersion)
+	fmt.Fprintf(&b, "Unschedulable: %t\n", node.Spec.Unschedulable)
+	var taints []string
+	for i := range node.Spec.Taints {
+		taints = append(taints, node.Spec.Taints[i].ToString())
+	}
+	if len(taints) == 0 {
+		taints = []string{"<none>"}
+	}
+	fmt.Fprintf(&b, "Taints:        %s\n\n", strings.Join(taints, ", "))
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONDITION\tSTATUS\tSINCE\tREASON\tMESSAGE")
+	for _, c := range node.Status.Conditions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Type, c.Status,
+			c.LastTransitionTime.UTC().Format(time.RFC3339), c.Reason, c.Message)
+	}
+	tw.Flush()
+	return b.Bytes()
+}
+
+// handleSupportBundle downloads a support bundle of the Deployment or Job
+// at /<kind>/<name>/support-bundle.zip: what is asked for on tickets about
+// a workload, gathered in one archive. It holds the YAML of the workload,
+// its ReplicaSets and pods, their events, the newest lines of every log
+// and the previous log of restarted containers, and the conditions of the
+// nodes the pods run on. Secrets are not included. Parts that cannot be
+// read are listed in errors.txt, so a bundle is made even with partial
+// permissions.
+func (s *Server) handleSupportBundle(w http.ResponseWriter, r *http.Request, kind string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"+kind+"/"), "/support-bundle.zip")
+	namespace := s.namespace(r)
+	ctx := r.Context()
+	client := s.manager.Client()
+
+	objects, pods, err := s.workloadPods(ctx, kind, namespace, name)
+	if err != nil {
+		if s.handleK8sForbidden(w, r, err, "list", "pods", "", "/"+kind+"/"+name, kind) {
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	uids := make(map[string]bool)
+	for _, obj := range objects {
+		uids[string(obj.(metav1.Object).GetUID())] = true
+	}
+	for i := range pods {
+		objects = append(objects, &pods[i])
+		uids[string(pods[i].UID)] = true
+	}
+
+	var failed []string
+	files := make(map[string][]byte)
+	for _, obj := range objects {
+		if gvks, _, err := scheme.Scheme.ObjectKinds(obj); err == nil {
+			obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+		}
+		obj.(metav1.Object).SetManagedFields(nil)
+		y, err := yaml.Marshal(obj)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", bundleManifestName(obj), err))
+			continue
+		}
+		files[bundleManifestName(obj)] = y
+	}
+
+	if events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{}); err != nil {
+		failed = append(failed, "events.txt: "+err.Error())
+	} else {
+		files["events.txt"] = bundleEvents(events.Items, uids)
+	}
+
+	var nodes []string
+	for _, p := range pods {
+		if p.Spec.NodeName != "" && !slices.Contains(nodes, p.Spec.NodeName) {
+			nodes = append(nodes, p.Spec.NodeName)
+		}
+	}
+	sort.Strings(nodes)
+	for _, nodeName := range nodes {
+		file := "nodes/" + nodeName + ".txt"
+		node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			failed = append(failed, file+": "+err.Error())
+			continue
+		}
+		files[file] = bundleNode(node)
+	}
+
+	r, dir, done, err := s.trackDownload(r, "k8s-ui-bundle-", "support bundle of "+artifactName(strings.TrimSuffix(kind, "s"), name, ""))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer done()
+	entries := logArchiveEntries(pods, true)
+	limit := s.namespaceLogLimit(namespace)
+	tail := supportBundleTailLines
+	if limit.MaxTailLines > 0 && limit.MaxTailLines < tail {
+		tail = limit.MaxTailLines
+	}
+	errs := s.readLogs(r.Context(), namespace, dir, entries, corev1.PodLogOptions{TailLines: &tail, SinceSeconds: sinceSeconds(limit.MaxSince)})
+	if err := downloadStopped(r); err != nil {
+		http.Error(w, "The download was stopped: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	now := time.Now()
+	_, kubeContext := s.manager.Contexts()
+	var summary bytes.Buffer
+	fmt.Fprintf(&summary, "Support bundle of %s/%s in namespace %s\n", strings.TrimSuffix(kind, "s"), name, namespace)
+	if kubeContext != "" {
+		fmt.Fprintf(&summary, "Context:   %s\n", kubeContext)
+	}
+	fmt.Fprintf(&summary, "Generated: %s\n", now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&summary, "Pods:      %d on %d nodes\n\n", len(pods), len(nodes))
+	if kind == "deployments" {
+		fmt.Fprintf(&summary, "manifests/  YAML of the Deployment, its ReplicaSets and its pods\n")
+	} else {
+		fmt.Fprintf(&summary, "manifests/  YAML of the Job and its pods\n")
+	}
+	fmt.Fprintf(&summary, "events.txt  their events, oldest first\n")
+	fmt.Fprintf(&summary, "logs/       the last %d lines of each container log, and the previous log of restarted containers\n", tail)
+	fmt.Fprintf(&summary, "nodes/      platform, taints and conditions of the nodes the pods run on\n")
+	fmt.Fprintf(&summary, "Secrets are not included.\n")
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s-%s-support-bundle.zip", strings.TrimSuffix(kind, "s"), name)))
+	zw := zip.NewWriter(w)
+	if zipFile(zw, "summary.txt", summary.Bytes(), now) != nil {
+		return
+	}
+	names := make([]string, 0, len(files))
+	for file := range files {
+		names = append(names, file)
+	}
+	sort.Strings(names)
+	for _, file := range names {
+		if zipFile(zw, file, files[file], now) != nil {
+			return
+		}
+	}
+	logFailed, err := zipLogs(zw, "logs/", dir, entries, errs, now)
+	if err != nil {
+		// The response has started, so the archive is left truncated.
+		return
+	}
+	failed = append(failed, logFailed...)
+	if len(failed) > 0 {
+		if zipFile(zw, "errors.txt", []byte(strings.Join(failed, "\n")+"\n"), now) != nil {
+			return
+		}
+	}
+	zw.Close()
+}
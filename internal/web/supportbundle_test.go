@@ -0,0 +1,76 @@
+package web
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestBundleManifestName(t *testing.T) {
+	rs := &appsv1.ReplicaSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "ReplicaSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-7d9f"},
+	}
+	if got := bundleManifestName(rs); got != "manifests/replicaset-web-7d9f.yaml" {
+		t.Errorf("bundleManifestName() = %q", got)
+	}
+}
+
+func TestBundleEvents(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := func(uid, name, reason string, at time.Time) corev1.Event {
+		return corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: name, UID: types.UID("uid-" + uid)},
+			Type:           corev1.EventTypeWarning,
+			Reason:         reason,
+			Message:        "Back-off restarting\nfailed container",
+			LastTimestamp:  metav1.NewTime(at),
+			Count:          3,
+		}
+	}
+	events := []corev1.Event{
+		event("b", "web-b", "BackOff", now),
+		event("other", "db-0", "Unhealthy", now),
+		event("a", "web-a", "Failed", now.Add(-time.Minute)),
+	}
+	got := string(bundleEvents(events, map[string]bool{"uid-a": true, "uid-b": true}))
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want header and 2 events:\n%s", len(lines), got)
+	}
+	if !strings.Contains(lines[1], "pod/web-a") || !strings.Contains(lines[2], "pod/web-b") {
+		t.Errorf("events not oldest first:\n%s", got)
+	}
+	if !strings.Contains(lines[2], "2024-05-01T12:00:00Z") || !strings.Contains(lines[2], "Back-off restarting failed container") {
+		t.Errorf("unexpected event line %q", lines[2])
+	}
+	if strings.Contains(got, "db-0") {
+		t.Errorf("event of another object included:\n%s", got)
+	}
+}
+
+func TestBundleNode(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{
+			corev1.LabelOSStable: "linux", corev1.LabelArchStable: "arm64",
+		}},
+		Spec: corev1.NodeSpec{
+			Unschedulable: true,
+			Taints:        []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}},
+		},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue, Reason: "KubeletHasInsufficientMemory"},
+		}},
+	}
+	got := string(bundleNode(node))
+	for _, want := range []string{"linux/arm64", "Unschedulable: true", "dedicated=gpu:NoSchedule", "MemoryPressure", "KubeletHasInsufficientMemory"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("bundleNode() lacks %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package web
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// withTracing starts a span for each request, joining the trace of the
+// ingress or proxy in front of the UI when it sends a traceparent header.
+// Spans are named after the route rather than the path, so pod and object
+// names do not make every span name unique. Probes are not traced.
+func (s *Server) withTracing(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "k8s-ui",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			_, pattern := s.mux.Handler(r)
+			if pattern == "" {
+				pattern = "unmatched"
+			}
+			return r.Method + " " + pattern
+		}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return r.URL.Path != "/healthz" && r.URL.Path != "/readyz"
+		}),
+	)
+}
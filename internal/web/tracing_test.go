@@ -0,0 +1,34 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	s := &Server{mux: http.NewServeMux()}
+	s.mux.HandleFunc("/pods/", func(w http.ResponseWriter, r *http.Request) {})
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	h := s.withTracing(s.mux)
+	for _, path := range []string{"/pods/web-1/logs", "/healthz", "/nowhere"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	if len(names) != 2 || names[0] != "GET /pods/" || names[1] != "GET unmatched" {
+		t.Errorf("span names = %q, want [GET /pods/ GET unmatched] without the probe", names)
+	}
+}
@@ -0,0 +1,226 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rakeshavasarala/k8s-ui/internal/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// WorkloadRef identifies a workload whose pod template matched a usage query.
+type WorkloadRef struct {
+	Kind string
+	Name string
+	URL  string
+}
+
+// Restartable reports whether the workload supports a rollout restart.
+func (w WorkloadRef) Restartable() bool {
+	switch w.Kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+		return true
+	}
+	return false
+}
+
+// workloadsUsing returns the workloads in the namespace whose pod template satisfies
+// match. The workload kinds are listed in parallel; kinds that cannot be listed are
+// reported as warnings rather than errors so callers can still show partial results.
+func (s *Server) workloadsUsing(ctx context.Context, namespace string, match func(spec *corev1.PodSpec) bool) ([]WorkloadRef, []string) {
+	client := s.manager.Client()
+
+	// Each task fills its own slot so results keep a stable kind order.
+	found := make([][]WorkloadRef, 5)
+	tasks := []kube.Task{
+		{Name: "deployments", Run: func(ctx context.Context) error {
+			list, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			for _, d := range list.Items {
+				if match(&d.Spec.Template.Spec) {
+					found[0] = append(found[0], WorkloadRef{Kind: "Deployment", Name: d.Name, URL: "/deployments/" + d.Name})
+				}
+			}
+			return nil
+		}},
+		{Name: "statefulsets", Run: func(ctx context.Context) error {
+			list, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			for _, ss := range list.Items {
+				if match(&ss.Spec.Template.Spec) {
+					found[1] = append(found[1], WorkloadRef{Kind: "StatefulSet", Name: ss.Name, URL: "/statefulsets/" + ss.Name + "/yaml"})
+				}
+			}
+			return nil
+		}},
+		{Name: "daemonsets", Run: func(ctx context.Context) error {
+			list, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			for _, ds := range list.Items {
+				if match(&ds.Spec.Template.Spec) {
+					found[2] = append(found[2], WorkloadRef{Kind: "DaemonSet", Name: ds.Name})
+				}
+			}
+			return nil
+		}},
+		{Name: "cronjobs", Run: func(ctx context.Context) error {
+			list, err := client.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			for _, cj := range list.Items {
+				if match(&cj.Spec.JobTemplate.Spec.Template.Spec) {
+					found[3] = append(found[3], WorkloadRef{Kind: "CronJob", Name: cj.Name, URL: "/cronjobs/" + cj.Name})
+				}
+			}
+			return nil
+		}},
+		{Name: "jobs", Run: func(ctx context.Context) error {
+			list, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			for _, j := range list.Items {
+				// Jobs created by a CronJob are already covered by their parent.
+				if metav1.GetControllerOf(&j) != nil {
+					continue
+				}
+				if match(&j.Spec.Template.Spec) {
+					found[4] = append(found[4], WorkloadRef{Kind: "Job", Name: j.Name, URL: "/jobs/" + j.Name})
+				}
+			}
+			return nil
+		}},
+	}
+
+	var warnings []string
+	for _, f := range kube.FanOut(ctx, 0, tasks) {
+		warnings = append(warnings, f.Error())
+	}
+
+	var refs []WorkloadRef
+	for _, kind := range found {
+		refs = append(refs, kind...)
+	}
+	return refs, warnings
+}
+
+// podSpecUsesConfigMap reports whether a pod spec mounts or reads the named ConfigMap.
+func podSpecUsesConfigMap(spec *corev1.PodSpec, name string) bool {
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil && v.ConfigMap.Name == name {
+			return true
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.ConfigMap != nil && src.ConfigMap.Name == name {
+					return true
+				}
+			}
+		}
+	}
+	return anyContainer(spec, func(c *corev1.Container) bool {
+		for _, from := range c.EnvFrom {
+			if from.ConfigMapRef != nil && from.ConfigMapRef.Name == name {
+				return true
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil && env.ValueFrom.ConfigMapKeyRef.Name == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// podSpecUsesSecret reports whether a pod spec mounts or reads the named Secret.
+// imagePullSecrets are not included since they do not require a restart to apply.
+func podSpecUsesSecret(spec *corev1.PodSpec, name string) bool {
+	for _, v := range spec.Volumes {
+		if v.Secret != nil && v.Secret.SecretName == name {
+			return true
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.Secret != nil && src.Secret.Name == name {
+					return true
+				}
+			}
+		}
+	}
+	return anyContainer(spec, func(c *corev1.Container) bool {
+		for _, from := range c.EnvFrom {
+			if from.SecretRef != nil && from.SecretRef.Name == name {
+				return true
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func anyContainer(spec *corev1.PodSpec, match func(c *corev1.Container) bool) bool {
+	for i := range spec.InitContainers {
+		if match(&spec.InitContainers[i]) {
+			return true
+		}
+	}
+	for i := range spec.Containers {
+		if match(&spec.Containers[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// rolloutRestartPatch returns the merge patch kubectl uses for "rollout restart".
+func rolloutRestartPatch() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						"kubectl.kubernetes.io/restartedAt": time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+}
+
+// restartWorkload performs a rollout restart of a Deployment, StatefulSet or DaemonSet.
+func restartWorkload(ctx context.Context, client kubernetes.Interface, namespace string, ref WorkloadRef) error {
+	payload, err := rolloutRestartPatch()
+	if err != nil {
+		return err
+	}
+
+	apps := client.AppsV1()
+	switch ref.Kind {
+	case "Deployment":
+		_, err = apps.Deployments(namespace).Patch(ctx, ref.Name, types.MergePatchType, payload, metav1.PatchOptions{})
+	case "StatefulSet":
+		_, err = apps.StatefulSets(namespace).Patch(ctx, ref.Name, types.MergePatchType, payload, metav1.PatchOptions{})
+	case "DaemonSet":
+		_, err = apps.DaemonSets(namespace).Patch(ctx, ref.Name, types.MergePatchType, payload, metav1.PatchOptions{})
+	default:
+		return fmt.Errorf("%s does not support rollout restart", ref.Kind)
+	}
+	return err
+}
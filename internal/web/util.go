@@ -23,8 +23,26 @@ type BasePage struct {
 	CurrentContext   string
 	Namespaces       []string // Optional: if we want to list all available namespaces
 	CurrentNamespace string
-	IsLocal          bool
-	Warning          string
+	// SelectedNamespace is the namespace chosen in the selector when the
+	// page is showing a different one via ?namespace=; empty otherwise.
+	SelectedNamespace string
+	IsLocal           bool
+	Warning           string
+	// Production is set when the context or namespace is marked as
+	// production; mutating actions then need a typed confirmation.
+	Production bool
+	// ReplicationControllers shows the legacy ReplicationControllers page
+	// in the navigation when it is enabled.
+	ReplicationControllers bool
+	// Nav lists the navigation sections to show, in order.
+	Nav []string
+	// NavHidden holds the pages and sections left out of the navigation
+	// because their resources cannot be listed; AccessNote says so.
+	NavHidden  map[string]bool
+	AccessNote string
+	// NamespaceInput replaces the namespace selector with a text field
+	// when namespaces cannot be listed.
+	NamespaceInput bool
 } // e.g., "pods", "deployments"
 
 // FuncMap returns the template function map.
@@ -36,6 +54,10 @@ func FuncMap() template.FuncMap {
 		"getFirstContainer": getFirstContainerName,
 		"sub":               func(a, b int) int { return a - b },
 		"add":               func(a, b int) int { return a + b },
+		"formatBytes":       formatBytes,
+		"percentBar":        percentBar,
+		"shortDigest":       shortDigest,
+		"referenceTarget":   referenceTarget,
 	}
 }
 
@@ -43,7 +65,14 @@ func formatAge(t time.Time) string {
 	if t.IsZero() {
 		return "-"
 	}
-	d := time.Since(t)
+	return formatDuration(time.Since(t))
+}
+
+// formatDuration renders a duration in the same compact form as formatAge.
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
 	if d < time.Minute {
 		return fmt.Sprintf("%ds", int(d.Seconds()))
 	}
@@ -196,17 +225,23 @@ func (s *Server) updateBasePageField(f reflect.Value) {
 	isLocal := s.manager.IsLocal()
 	allowedNamespaces := s.manager.AllowedNamespaces()
 
+	client := s.manager.Client()
+
 	var namespaces []string
 	var warning string
+	var namespaceInput bool
 	if len(allowedNamespaces) > 0 {
 		namespaces = allowedNamespaces
-	} else if isLocal && s.manager.Client() != nil {
+	} else if isLocal && client != nil && !s.canListNamespaces(client, currentContext) {
+		// Asked once instead of failing a list on every page.
+		namespaceInput = true
+	} else if isLocal && client != nil {
 		// Namespace listing is only useful in local mode where users can switch namespaces.
 		// In-cluster mode typically uses a fixed namespace and may not have list permissions.
 		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 		defer cancel()
 
-		nsList, err := s.manager.Client().CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		nsList, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 		if err == nil {
 			for _, ns := range nsList.Items {
 				namespaces = append(namespaces, ns.Name)
@@ -218,23 +253,60 @@ func (s *Server) updateBasePageField(f reflect.Value) {
 	}
 
 	currentBase := f.Interface().(BasePage)
+	// Keep any page-specific warning set by the handler.
+	if currentBase.Warning != "" {
+		if warning != "" {
+			warning = currentBase.Warning + " " + warning
+		} else {
+			warning = currentBase.Warning
+		}
+	}
+
+	// Handlers fill Namespace from the request, which may carry a
+	// ?namespace= override that differs from the selected namespace.
+	selected := s.manager.Namespace()
+	namespace := currentBase.Namespace
+	if namespace == "" {
+		namespace = selected
+	}
+	var selectedNamespace string
+	if namespace != selected {
+		selectedNamespace = selected
+	}
+
+	var navHidden map[string]bool
+	var accessNote string
+	if client != nil {
+		navHidden, accessNote = s.navAccess(client, currentContext, namespace)
+	}
 
 	newBase := BasePage{
-		Title:            currentBase.Title,
-		Active:           currentBase.Active,
-		Namespace:        s.manager.Namespace(),
-		Contexts:         contexts,
-		CurrentContext:   currentContext,
-		Namespaces:       namespaces,
-		CurrentNamespace: s.manager.Namespace(),
-		IsLocal:          isLocal,
-		Warning:          warning,
+		Title:             currentBase.Title,
+		Active:            currentBase.Active,
+		Namespace:         namespace,
+		Contexts:          contexts,
+		CurrentContext:    currentContext,
+		Namespaces:        namespaces,
+		CurrentNamespace:  namespace,
+		SelectedNamespace: selectedNamespace,
+		IsLocal:           isLocal,
+		Warning:           warning,
+		Production:        s.isProduction(namespace),
+
+		ReplicationControllers: s.config.ReplicationControllers,
+		Nav:                    s.navSections(),
+		NavHidden:              navHidden,
+		AccessNote:             accessNote,
+		NamespaceInput:         namespaceInput,
 	}
 
 	f.Set(reflect.ValueOf(newBase))
 }
 
-func (s *Server) handleK8sForbidden(w http.ResponseWriter, err error, verb, resource, name, backURL, active string) bool {
+func (s *Server) handleK8sForbidden(w http.ResponseWriter, r *http.Request, err error, verb, resource, name, backURL, active string) bool {
+	if s.handleK8sUnauthorized(w, r, err, backURL, active) {
+		return true
+	}
 	if !apierrors.IsForbidden(err) {
 		return false
 	}
@@ -244,13 +316,13 @@ func (s *Server) handleK8sForbidden(w http.ResponseWriter, err error, verb, reso
 		target = fmt.Sprintf("%s/%s", resource, name)
 	}
 
-	message := fmt.Sprintf("You are not allowed to %s %s in namespace %s.", verb, target, s.manager.Namespace())
+	message := fmt.Sprintf("You are not allowed to %s %s in namespace %s.", verb, target, s.namespace(r))
 	title := fmt.Sprintf("Access denied for %s", resource)
-	s.renderPermissionDenied(w, title, message, backURL, active)
+	s.renderPermissionDenied(w, r, title, message, backURL, active)
 	return true
 }
 
-func (s *Server) renderPermissionDenied(w http.ResponseWriter, title, message, backURL, active string) {
+func (s *Server) renderPermissionDenied(w http.ResponseWriter, r *http.Request, title, message, backURL, active string) {
 	w.WriteHeader(http.StatusForbidden)
 
 	data := struct {
@@ -259,7 +331,7 @@ func (s *Server) renderPermissionDenied(w http.ResponseWriter, title, message, b
 		Message   string
 		BackURL   string
 	}{
-		BasePage:  BasePage{Namespace: s.manager.Namespace(), Title: "Access Denied", Active: active},
+		BasePage:  BasePage{Namespace: s.namespace(r), Title: "Access Denied", Active: active},
 		TitleLine: title,
 		Message:   message,
 		BackURL:   backURL,
@@ -267,3 +339,40 @@ func (s *Server) renderPermissionDenied(w http.ResponseWriter, title, message, b
 
 	s.renderTemplate(w, "permission_denied.html", data)
 }
+
+// handleK8sUnauthorized handles a 401 from the API server: it renders a
+// re-authentication page with a retry link, instead of showing the raw
+// Unauthorized error, once the credentials have been reloaded. The client
+// already started reloading them on the 401; RefreshCredentials waits for
+// that and returns its result.
+func (s *Server) handleK8sUnauthorized(w http.ResponseWriter, r *http.Request, err error, backURL, active string) bool {
+	if !apierrors.IsUnauthorized(err) {
+		return false
+	}
+
+	retryURL := backURL
+	if r.Method == http.MethodGet {
+		retryURL = r.URL.RequestURI()
+	}
+
+	var refreshError string
+	if rerr := s.manager.RefreshCredentials(); rerr != nil {
+		refreshError = rerr.Error()
+	}
+
+	w.WriteHeader(http.StatusUnauthorized)
+	data := struct {
+		BasePage
+		Message      string
+		RefreshError string
+		RetryURL     string
+	}{
+		BasePage:     BasePage{Namespace: s.namespace(r), Title: "Re-authentication required", Active: active},
+		Message:      err.Error(),
+		RefreshError: refreshError,
+		RetryURL:     retryURL,
+	}
+
+	s.renderTemplate(w, "reauth.html", data)
+	return true
+}
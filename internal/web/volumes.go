@@ -0,0 +1,185 @@
+package web
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// volumeFailureReasons are the kubelet and attach/detach controller events
+// that mean a pod is stuck waiting for its storage.
+var volumeFailureReasons = map[string]bool{
+	"FailedAttachVolume": true,
+	"FailedMount":        true,
+	"FailedMapVolume":    true,
+}
+
+// VolumeEventView is a recent attach or mount failure of a pod's volumes.
+type VolumeEventView struct {
+	Reason  string
+	Message string
+	Count   int32
+	Age     string
+}
+
+// volumeFailureEvents picks the attach and mount failures out of a pod's
+// events.
+func volumeFailureEvents(events []corev1.Event) []VolumeEventView {
+	var views []VolumeEventView
+	for _, e := range events {
+		if !volumeFailureReasons[e.Reason] {
+			continue
+		}
+		views = append(views, VolumeEventView{
+			Reason:  e.Reason,
+			Message: e.Message,
+			Count:   eventCount(e),
+			Age:     formatAge(eventTime(e)),
+		})
+	}
+	return views
+}
+
+// podClaim is a PersistentVolumeClaim a pod mounts as one of its volumes.
+type podClaim struct {
+	Volume string
+	Claim  string
+}
+
+// podClaims lists the claims a pod's volumes refer to, including the claims
+// created for its generic ephemeral volumes, which are named after the pod
+// and the volume.
+func podClaims(pod *corev1.Pod) []podClaim {
+	var claims []podClaim
+	for _, v := range pod.Spec.Volumes {
+		switch {
+		case v.PersistentVolumeClaim != nil:
+			claims = append(claims, podClaim{Volume: v.Name, Claim: v.PersistentVolumeClaim.ClaimName})
+		case v.Ephemeral != nil:
+			claims = append(claims, podClaim{Volume: v.Name, Claim: pod.Name + "-" + v.Name})
+		}
+	}
+	return claims
+}
+
+// claimProblem explains why a claim or its volume can keep a pod from
+// mounting it, or returns "" when nothing looks wrong. pvc is nil when the
+// claim does not exist, and pv is nil when it is not bound or the volume
+// could not be read.
+func claimProblem(pvc *corev1.PersistentVolumeClaim, pv *corev1.PersistentVolume) string {
+	switch {
+	case pvc == nil:
+		return "The claim does not exist, so the pod cannot start until it is created."
+	case pvc.DeletionTimestamp != nil:
+		return "The claim is being deleted and is kept only until no pod uses it."
+	case pvc.Status.Phase == corev1.ClaimPending:
+		return "The claim is not bound to a volume yet. Check its events and its storage class's provisioner."
+	case pvc.Status.Phase == corev1.ClaimLost:
+		return "The claim has lost its volume " + pvc.Spec.VolumeName + ", which no longer exists."
+	case pv == nil:
+		return ""
+	case pv.DeletionTimestamp != nil:
+		return "The volume is being deleted."
+	case pv.Status.Phase == corev1.VolumeFailed:
+		msg := "The volume has failed"
+		if pv.Status.Message != "" {
+			msg += ": " + pv.Status.Message
+		}
+		return msg + "."
+	}
+	return ""
+}
+
+// PodClaimView is the state of a claim a pod mounts and of the volume
+// bound to it.
+type PodClaimView struct {
+	Volume       string // the pod's name for the volume
+	Claim        string
+	Found        bool
+	Phase        string
+	StorageClass string
+	PV           string
+	PVPhase      string
+	Attachment   string // "Attached" or "Attaching" on the pod's node, for CSI volumes
+	AttachError  string
+	Problem      string
+	ClaimEvent   string // the claim's latest warning event, or latest event, when it has a problem
+}
+
+// podClaimViews looks up the claims a pod mounts, their volumes and, for CSI
+// volumes, their attachment to the pod's node. It is a hint on the pod page,
+// so claims and volumes it may not read are left out or shown without
+// their state.
+func (s *Server) podClaimViews(ctx context.Context, pod *corev1.Pod) []PodClaimView {
+	claims := podClaims(pod)
+	if len(claims) == 0 {
+		return nil
+	}
+	client := s.manager.Client()
+
+	var attachments []storagev1.VolumeAttachment
+	if pod.Spec.NodeName != "" {
+		if list, err := client.StorageV1().VolumeAttachments().List(ctx, metav1.ListOptions{}); err == nil {
+			attachments = list.Items
+		}
+	}
+
+	var views []PodClaimView
+	for _, c := range claims {
+		view := PodClaimView{Volume: c.Volume, Claim: c.Claim}
+		pvc, err := client.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx, c.Claim, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			view.Problem = claimProblem(nil, nil)
+			views = append(views, view)
+			continue
+		case err != nil:
+			continue
+		}
+		view.Found = true
+		view.Phase = string(pvc.Status.Phase)
+		if pvc.Spec.StorageClassName != nil {
+			view.StorageClass = *pvc.Spec.StorageClassName
+		}
+		view.PV = pvc.Spec.VolumeName
+
+		var pv *corev1.PersistentVolume
+		if pvc.Spec.VolumeName != "" {
+			if got, err := client.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{}); err == nil {
+				pv = got
+				view.PVPhase = string(pv.Status.Phase)
+			}
+		}
+		for _, va := range attachments {
+			if pv != nil && va.Spec.NodeName == pod.Spec.NodeName && va.Spec.Source.PersistentVolumeName != nil && *va.Spec.Source.PersistentVolumeName == pv.Name {
+				view.Attachment = "Attaching"
+				if va.Status.Attached {
+					view.Attachment = "Attached"
+				}
+				if va.Status.AttachError != nil {
+					view.AttachError = va.Status.AttachError.Message
+				}
+			}
+		}
+
+		view.Problem = claimProblem(pvc, pv)
+		if view.Problem != "" {
+			// Events are newest first. A pending claim often has only
+			// Normal ones, such as waiting for the provisioner.
+			events, _ := s.objectEvents(ctx, pod.Namespace, "PersistentVolumeClaim", pvc.Name)
+			for _, e := range events {
+				if e.Type == corev1.EventTypeWarning || view.ClaimEvent == "" {
+					view.ClaimEvent = e.Reason + ": " + e.Message
+				}
+				if e.Type == corev1.EventTypeWarning {
+					break
+				}
+			}
+		}
+		views = append(views, view)
+	}
+	return views
+}
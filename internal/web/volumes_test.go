@@ -0,0 +1,72 @@
+package web
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodClaims(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0"},
+		Spec: corev1.PodSpec{Volumes: []corev1.Volume{
+			{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-web-0"}}},
+			{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+			{Name: "scratch", VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{}}},
+		}},
+	}
+	want := []podClaim{{Volume: "data", Claim: "data-web-0"}, {Volume: "scratch", Claim: "web-0-scratch"}}
+	if got := podClaims(pod); !slices.Equal(got, want) {
+		t.Errorf("podClaims() = %v, want %v", got, want)
+	}
+}
+
+func TestVolumeFailureEvents(t *testing.T) {
+	events := []corev1.Event{
+		{Reason: "FailedMount", Message: "MountVolume.SetUp failed", Count: 4},
+		{Reason: "Unhealthy", Message: "Readiness probe failed"},
+		{Reason: "FailedAttachVolume", Message: "Multi-Attach error"},
+	}
+	got := volumeFailureEvents(events)
+	if len(got) != 2 || got[0].Reason != "FailedMount" || got[0].Count != 4 || got[1].Reason != "FailedAttachVolume" {
+		t.Errorf("volumeFailureEvents() = %+v", got)
+	}
+}
+
+func TestClaimProblem(t *testing.T) {
+	claim := func(phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			Spec:   corev1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+	deleting := claim(corev1.ClaimBound)
+	deleting.DeletionTimestamp = &metav1.Time{}
+	volume := func(phase corev1.PersistentVolumePhase, message string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{Status: corev1.PersistentVolumeStatus{Phase: phase, Message: message}}
+	}
+
+	tests := []struct {
+		name string
+		pvc  *corev1.PersistentVolumeClaim
+		pv   *corev1.PersistentVolume
+		want string // a substring, or "" for no problem
+	}{
+		{"missing", nil, nil, "does not exist"},
+		{"deleting", deleting, volume(corev1.VolumeBound, ""), "being deleted"},
+		{"pending", claim(corev1.ClaimPending), nil, "not bound"},
+		{"lost", claim(corev1.ClaimLost), nil, "lost its volume pv-1"},
+		{"bound", claim(corev1.ClaimBound), volume(corev1.VolumeBound, ""), ""},
+		{"volume unreadable", claim(corev1.ClaimBound), nil, ""},
+		{"volume failed", claim(corev1.ClaimBound), volume(corev1.VolumeFailed, "disk detached"), "failed: disk detached"},
+	}
+	for _, tt := range tests {
+		got := claimProblem(tt.pvc, tt.pv)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("%s: claimProblem() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}